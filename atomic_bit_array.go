@@ -0,0 +1,120 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+)
+
+// AtomicBitArray is a bit-array of fixed length whose bits can be read and written concurrently
+// by several goroutines without locking. Bits are stored in 64-bit words updated with sync/atomic,
+// the bit at position i being bit 63 - i%64 of word i/64, the same order as in a BitArray.
+// Users are supposed to use `NewAtomicBitArray` or `NewAtomicBitArrayFrom` to instantiate one.
+type AtomicBitArray struct {
+	length int
+	words  []uint64
+}
+
+// NewAtomicBitArray returns a new atomic bit-array of length n where all the bits are set to `0`.
+// It will panic if n is negative.
+func NewAtomicBitArray(n int) *AtomicBitArray {
+	if n < 0 {
+		panic(fmt.Sprintf("length should not be negative, given %d", n))
+	}
+	return &AtomicBitArray{length: n, words: make([]uint64, (n+63)>>6)}
+}
+
+// NewAtomicBitArrayFrom returns a new atomic bit-array with the same length and bits as ba.
+func NewAtomicBitArrayFrom(ba *BitArray) *AtomicBitArray {
+	aba := NewAtomicBitArray(ba.Len())
+	for k := range aba.words {
+		aba.words[k] = ba.word(k)
+	}
+	return aba
+}
+
+// Len returns the length (number of bits) of the atomic bit-array.
+func (aba *AtomicBitArray) Len() int {
+	return aba.length
+}
+
+// GetBit returns the bit at the `index`th position as a byte equal to `00000000` or `00000001`.
+// It will panic if index is out of range.
+func (aba *AtomicBitArray) GetBit(index int) byte {
+	w, mask := aba.locate(index)
+	if atomic.LoadUint64(w)&mask != 0 {
+		return 1
+	}
+	return 0
+}
+
+// SetBit sets the bit at the `index`th position to `1`. It will panic if index is out of range.
+func (aba *AtomicBitArray) SetBit(index int) {
+	aba.TestAndSet(index)
+}
+
+// ClearBit sets the bit at the `index`th position to `0`. It will panic if index is out of range.
+func (aba *AtomicBitArray) ClearBit(index int) {
+	aba.TestAndClear(index)
+}
+
+// TestAndSet sets the bit at the `index`th position to `1` and reports whether it was already set.
+// When several goroutines race to set the same bit, exactly one of them gets false.
+// It will panic if index is out of range.
+func (aba *AtomicBitArray) TestAndSet(index int) bool {
+	w, mask := aba.locate(index)
+	for {
+		old := atomic.LoadUint64(w)
+		if old&mask != 0 {
+			return true
+		}
+		if atomic.CompareAndSwapUint64(w, old, old|mask) {
+			return false
+		}
+	}
+}
+
+// TestAndClear sets the bit at the `index`th position to `0` and reports whether it was set.
+// When several goroutines race to clear the same bit, exactly one of them gets true.
+// It will panic if index is out of range.
+func (aba *AtomicBitArray) TestAndClear(index int) bool {
+	w, mask := aba.locate(index)
+	for {
+		old := atomic.LoadUint64(w)
+		if old&mask == 0 {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(w, old, old&^mask) {
+			return true
+		}
+	}
+}
+
+// Count returns the number of bits set to `1`.
+// Words are read atomically one after the other: concurrent writes to words already read are not seen.
+func (aba *AtomicBitArray) Count() int {
+	count := 0
+	for k := range aba.words {
+		count += bits.OnesCount64(atomic.LoadUint64(&aba.words[k]))
+	}
+	return count
+}
+
+// BitArray returns a copy of the atomic bit-array as a regular bit-array.
+// As with Count, each word is copied atomically but the copy is not a point-in-time snapshot
+// of the whole array if it is modified concurrently.
+func (aba *AtomicBitArray) BitArray() *BitArray {
+	ba := NewZeros(aba.length)
+	for k := range aba.words {
+		store64(ba.data, k<<3, atomic.LoadUint64(&aba.words[k]))
+	}
+	return ba
+}
+
+// locate returns the word holding the bit at position index and the mask of the bit in the word.
+func (aba *AtomicBitArray) locate(index int) (*uint64, uint64) {
+	if index < 0 || index >= aba.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", index, aba.length))
+	}
+	return &aba.words[index>>6], 1 << (63 - uint(index&63))
+}
@@ -0,0 +1,154 @@
+package bitarray
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestAtomicBitArray(t *testing.T) {
+	tests := []struct {
+		id     int
+		bits   string
+		set    []int
+		clear  []int
+		result string
+	}{
+		{0, "", nil, nil, ""},
+		{1, "0", []int{0}, nil, "1"},
+		{2, "1010", []int{1}, []int{0, 3}, "0110"},
+		{
+			3,
+			"0000000000000000000000000000000000000000000000000000000000000000000011",
+			[]int{0, 63, 64},
+			[]int{69},
+			"1000000000000000000000000000000000000000000000000000000000000001100010",
+		},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bits)
+		aba := NewAtomicBitArrayFrom(ba)
+		for _, i := range test.set {
+			aba.SetBit(i)
+		}
+		for _, i := range test.clear {
+			aba.ClearBit(i)
+		}
+
+		want := New()
+		want.AppendString(test.result)
+		if got := aba.BitArray(); !sameBits(got, want) {
+			t.Errorf("%d: BitArray returned bad data %08b, want %08b", test.id, got.Bytes(), want.Bytes())
+		}
+		if aba.Len() != want.Len() {
+			t.Errorf("%d: Len returned bad data %d, want %d", test.id, aba.Len(), want.Len())
+		}
+		if aba.Count() != want.Count() {
+			t.Errorf("%d: Count returned bad data %d, want %d", test.id, aba.Count(), want.Count())
+		}
+		for i := 0; i < want.Len(); i++ {
+			if aba.GetBit(i) != want.GetBit(i) {
+				t.Errorf("%d: GetBit(%d) returned bad data %d, want %d", test.id, i, aba.GetBit(i), want.GetBit(i))
+			}
+		}
+	}
+}
+
+func TestAtomicTestAndSet(t *testing.T) {
+	aba := NewAtomicBitArray(100)
+	if aba.TestAndSet(42) {
+		t.Errorf("TestAndSet of a cleared bit returned true")
+	}
+	if !aba.TestAndSet(42) {
+		t.Errorf("TestAndSet of a set bit returned false")
+	}
+	if !aba.TestAndClear(42) {
+		t.Errorf("TestAndClear of a set bit returned false")
+	}
+	if aba.TestAndClear(42) {
+		t.Errorf("TestAndClear of a cleared bit returned true")
+	}
+}
+
+func TestAtomicConcurrent(t *testing.T) {
+	const n, workers = 10000, 8
+	aba := NewAtomicBitArray(n)
+
+	// every bit is claimed by exactly one goroutine
+	claimed := make([]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for _, i := range r.Perm(n) {
+				if !aba.TestAndSet(i) {
+					claimed[w]++
+				}
+				aba.GetBit(i)
+			}
+			aba.Count()
+			aba.BitArray()
+		}(w)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, c := range claimed {
+		total += c
+	}
+	if total != n {
+		t.Errorf("TestAndSet claimed %d bits, want %d", total, n)
+	}
+	if aba.Count() != n {
+		t.Errorf("Count returned bad data %d, want %d", aba.Count(), n)
+	}
+
+	// neighbouring bits of the same words are cleared concurrently
+	released := make([]int, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += workers {
+				if aba.TestAndClear(i) {
+					released[w]++
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w, c := range released {
+		if want := (n - w + workers - 1) / workers; c != want {
+			t.Errorf("%d: TestAndClear released %d bits, want %d", w, c, want)
+		}
+	}
+	if got := aba.BitArray(); got.Count() != 0 || got.Len() != n {
+		t.Errorf("BitArray returned bad data with length %d and %d ones", got.Len(), got.Count())
+	}
+}
+
+func TestAtomicPanics(t *testing.T) {
+	aba := NewAtomicBitArray(10)
+	for id, f := range []func(){
+		func() { NewAtomicBitArray(-1) },
+		func() { aba.GetBit(10) },
+		func() { aba.SetBit(-1) },
+		func() { aba.ClearBit(10) },
+		func() { aba.TestAndSet(10) },
+		func() { aba.TestAndClear(10) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -5,7 +5,9 @@
 package bitarray
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math/bits"
 	"strconv"
 )
 
@@ -17,6 +19,7 @@ const UintSize = uintSize
 type BitArray struct {
 	data    []byte
 	padding int
+	cow     *cowState // nil until the first snapshot
 }
 
 // New returns a new, ready to be used, empty bit-array.
@@ -27,6 +30,30 @@ func New() *BitArray {
 	}
 }
 
+// NewZeros returns a new bit-array of length n where all the bits are set to `0`.
+// It will panic if n is negative.
+func NewZeros(n int) *BitArray {
+	if n < 0 {
+		panic(fmt.Sprintf("length should not be negative, given %d", n))
+	}
+	if n == 0 {
+		return New()
+	}
+
+	nbBytes := (n + 7) >> 3
+	return &BitArray{
+		data:    make([]byte, nbBytes),
+		padding: (nbBytes << 3) - n,
+	}
+}
+
+// Clone returns a deep copy of the bit-array.
+func (ba *BitArray) Clone() *BitArray {
+	data := make([]byte, len(ba.data))
+	copy(data, ba.data)
+	return &BitArray{data: data, padding: ba.padding}
+}
+
 // Len returns the length (number of bits) of the bit-array.
 func (ba *BitArray) Len() int {
 	return (len(ba.data) << 3) - int(ba.padding)
@@ -55,10 +82,60 @@ func (ba *BitArray) Padding() int {
 	return ba.padding
 }
 
+// Count returns the number of bits set to `1` in the bit-array.
+func (ba *BitArray) Count() int {
+	count := 0
+	data := ba.data
+	for len(data) >= 8 {
+		count += bits.OnesCount64(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+	}
+	for _, b := range data {
+		count += bits.OnesCount8(b)
+	}
+
+	return count
+}
+
+// NextOne returns the position of the first bit set to `1` at position `index` or after it,
+// or -1 if there is no such bit. It will panic if index is negative.
+// Iterating over all the set bits is done with:
+//
+//	for i := ba.NextOne(0); i >= 0; i = ba.NextOne(i + 1) { ... }
+func (ba *BitArray) NextOne(index int) int {
+	if index < 0 {
+		panic(fmt.Sprintf("negative index is invalid; given %d", index))
+	}
+	if index >= ba.Len() {
+		return -1
+	}
+
+	b := index >> 3
+	v := ba.data[b] & (0xff >> (index & 0x7))
+	for v == 0 {
+		b++
+		// skip whole zero words
+		for b+8 <= len(ba.data) && binary.LittleEndian.Uint64(ba.data[b:]) == 0 {
+			b += 8
+		}
+		if b >= len(ba.data) {
+			return -1
+		}
+		v = ba.data[b]
+	}
+
+	next := (b << 3) + bits.LeadingZeros8(v)
+	if next >= ba.Len() {
+		return -1
+	}
+	return next
+}
+
 // AppendOne appends a `1` to the bit array.
 func (ba *BitArray) AppendOne() {
 	if ba.padding != 0 {
 		ba.padding -= 1
+		ba.beforeWrite(len(ba.data)-1, len(ba.data))
 		ba.data[len(ba.data)-1] |= (1 << ba.padding)
 		return
 	}
@@ -109,6 +186,7 @@ func (ba *BitArray) SetBit(index int) {
 	b := (index &^ 0x7) >> 3
 	r := index - (b << 3)
 
+	ba.beforeWrite(b, b+1)
 	ba.data[b] |= 0b10000000 >> r
 }
 
@@ -120,6 +198,7 @@ func (ba *BitArray) ClearBit(index int) {
 	b := (index &^ 0x7) >> 3
 	r := index - (b << 3)
 
+	ba.beforeWrite(b, b+1)
 	ba.data[b] &^= 0b10000000 >> r
 }
 
@@ -142,15 +221,22 @@ func (ba *BitArray) Append8(v uint8, nbBits int) {
 		panic(fmt.Sprintf("nbBits should not be between 0 and 8, given %d", nbBits))
 	}
 
-	v = v & (0b11111111 >> (8 - nbBits))
-
-	if nbBits <= ba.padding {
-		ba.data[len(ba.data)-1] |= v << (ba.padding - nbBits)
-		ba.padding -= nbBits
+	if nbBits == 0 {
 		return
 	}
 
-	ba.data[len(ba.data)-1] |= v >> (nbBits - ba.padding)
+	v = v & (0b11111111 >> (8 - nbBits))
+	// the last byte is only written to when it has padding bits, it may be shared with snapshots otherwise
+	if ba.padding != 0 {
+		ba.beforeWrite(len(ba.data)-1, len(ba.data))
+		if nbBits <= ba.padding {
+			ba.data[len(ba.data)-1] |= v << (ba.padding - nbBits)
+			ba.padding -= nbBits
+			return
+		}
+		ba.data[len(ba.data)-1] |= v >> (nbBits - ba.padding)
+	}
+
 	ba.padding = (8 - nbBits + ba.padding)
 	ba.data = append(ba.data, v<<ba.padding)
 }
@@ -341,3 +427,81 @@ func (ba *BitArray) ExtractBitArray(i, j int) *BitArray {
 	res.Append8(ba.data[endingByte]>>(7-j), j+1)
 	return res
 }
+
+// load64 returns the 8 bytes of data starting at byte b as a big-endian uint64, missing bytes are read as 0's.
+func load64(data []byte, b int) uint64 {
+	if b+8 <= len(data) {
+		return binary.BigEndian.Uint64(data[b:])
+	}
+
+	var w uint64
+	for i := 0; i < 8; i++ {
+		w <<= 8
+		if b+i < len(data) {
+			w |= uint64(data[b+i])
+		}
+	}
+	return w
+}
+
+// store64 is the inverse of load64, bytes past the end of data are not written.
+func store64(data []byte, b int, w uint64) {
+	if b+8 <= len(data) {
+		binary.BigEndian.PutUint64(data[b:], w)
+		return
+	}
+
+	for i := 0; i < 8 && b+i < len(data); i++ {
+		data[b+i] = byte(w >> (56 - 8*i))
+	}
+}
+
+// bitsAt returns the n bits (n <= 64) starting at position pos as the lowest bits of a uint64.
+// Contrary to Extract it does not check its arguments, the range must lie within the data.
+func (ba *BitArray) bitsAt(pos, n int) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	b, r := pos>>3, pos&0x7
+	v := (load64(ba.data, b) << r) >> (64 - n)
+	if r+n > 64 {
+		s := r + n - 64
+		v |= uint64(ba.data[b+8] >> (8 - s))
+	}
+	return v
+}
+
+// setBitsAt overwrites the n bits (n <= 64) starting at position pos with the n lowest bits of v.
+// It does not check its arguments, the range must lie within the data.
+func (ba *BitArray) setBitsAt(pos, n int, v uint64) {
+	if n == 0 {
+		return
+	}
+
+	b, r := pos>>3, pos&0x7
+	ba.beforeWrite(b, b+9)
+	w := load64(ba.data, b)
+	if r+n <= 64 {
+		shift := 64 - r - n
+		mask := (^uint64(0) >> (64 - n)) << shift
+		store64(ba.data, b, w&^mask|(v<<shift)&mask)
+		return
+	}
+
+	s := r + n - 64
+	mask := ^uint64(0) >> r
+	store64(ba.data, b, w&^mask|(v>>s)&mask)
+	lowMask := byte(0xff << (8 - s))
+	ba.data[b+8] = ba.data[b+8]&^lowMask | byte(v<<(8-s))&lowMask
+}
+
+// appendZeros appends n bits set to `0` to the bit-array.
+func (ba *BitArray) appendZeros(n int) {
+	length := ba.Len() + n
+	nbBytes := (length + 7) >> 3
+	if nbBytes > len(ba.data) {
+		ba.data = append(ba.data, make([]byte, nbBytes-len(ba.data))...)
+	}
+	ba.padding = (len(ba.data) << 3) - length
+}
@@ -920,3 +920,105 @@ func TestExtractBitArray(t *testing.T) {
 		}
 	}
 }
+
+func TestNewZeros(t *testing.T) {
+	tests := []struct {
+		id      int
+		n       int
+		want    []byte
+		padding int
+	}{
+		{0, 0, []byte{}, 0},
+		{1, 1, []byte{0x00}, 7},
+		{2, 8, []byte{0x00}, 0},
+		{3, 13, []byte{0x00, 0x00}, 3},
+		{4, 64, make([]byte, 8), 0},
+	}
+
+	for _, test := range tests {
+		ba := NewZeros(test.n)
+		data := ba.Bytes()
+		if !bytes.Equal(data, test.want) {
+			t.Errorf("%d: NewZeros returned bad data %s, want %s", test.id, fmt.Sprintf("%#X", data), fmt.Sprintf("%#X", test.want))
+		}
+		if ba.Len() != test.n {
+			t.Errorf("%d: returned bad length %d, want: %d", test.id, ba.Len(), test.n)
+		}
+		if ba.Padding() != test.padding {
+			t.Errorf("%d: returned bad padding %d, want: %d", test.id, ba.Padding(), test.padding)
+		}
+
+		ba.AppendOne()
+		if ba.GetBit(test.n) != 1 || ba.Len() != test.n+1 {
+			t.Errorf("%d: AppendOne after NewZeros did not work correctly", test.id)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	ba := New()
+	ba.AppendString("1101111010101101101")
+
+	clone := ba.Clone()
+	clone.ClearBit(0)
+	clone.AppendOne()
+
+	if ba.GetBit(0) != 1 || ba.Len() != 19 {
+		t.Errorf("Clone shares data with the original bit-array")
+	}
+	if clone.GetBit(0) != 0 || clone.Len() != 20 || clone.Extract(1, 20) != 0x5EADB {
+		t.Errorf("Clone returned bad data %#X with length %d", clone.Bytes(), clone.Len())
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		id     int
+		bitSeq string
+		want   int
+	}{
+		{0, "", 0},
+		{1, "1", 1},
+		{2, "0000000", 0},
+		{3, "110111101010110110111110111011110000", 24},
+		{4, "0001000000101011001101001001010110100000011100100111110101010111", 29},
+		{5, "010001011000001111101111011010111100001010101111011000011010001000110001001010010111", 42},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bitSeq)
+		if count := ba.Count(); count != test.want {
+			t.Errorf("%d: Count returned %d, want %d", test.id, count, test.want)
+		}
+	}
+}
+
+func TestNextOne(t *testing.T) {
+	tests := []struct {
+		id     int
+		bitSeq string
+		want   []int
+	}{
+		{0, "", []int{}},
+		{1, "0000000000", []int{}},
+		{2, "1", []int{0}},
+		{3, "00101010", []int{2, 4, 6}},
+		{4, "000000000000000000000000000000000000000000000000000000000000000000000000000000000001", []int{83}},
+		{5, "1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001", []int{0, 96}},
+		{6, "110111101010110110111110111011110000", []int{0, 1, 3, 4, 5, 6, 8, 10, 12, 13, 15, 16, 18, 19, 20, 21, 22, 24, 25, 26, 28, 29, 30, 31}},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bitSeq)
+
+		result := []int{}
+		for i := ba.NextOne(0); i >= 0; i = ba.NextOne(i + 1) {
+			result = append(result, i)
+		}
+		if fmt.Sprint(result) != fmt.Sprint(test.want) {
+			t.Errorf("%d: NextOne iterated over %v, want %v", test.id, result, test.want)
+		}
+	}
+}
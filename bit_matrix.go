@@ -0,0 +1,359 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+var (
+	// ErrSingularMatrix is returned when inverting a matrix that is not invertible.
+	ErrSingularMatrix = errors.New("bitarray: singular matrix")
+	// ErrInconsistentSystem is returned when solving a linear system that has no solution.
+	ErrInconsistentSystem = errors.New("bitarray: inconsistent linear system")
+)
+
+// BitMatrix is a matrix over GF(2), where addition is XOR and multiplication is AND.
+// Each row is a bit-array whose bytes are carved out of a single buffer at 64-bit word boundaries,
+// the unused bytes of the last word being always 0, so that adding a row to another is done a word at a time.
+type BitMatrix struct {
+	rows, cols int
+	data       []*BitArray
+}
+
+// NewBitMatrix returns a new matrix of rows x cols where all the bits are set to `0`.
+// It will panic if rows or cols is negative.
+func NewBitMatrix(rows, cols int) *BitMatrix {
+	if rows < 0 || cols < 0 {
+		panic(fmt.Sprintf("dimensions should not be negative, given %dx%d", rows, cols))
+	}
+
+	m := &BitMatrix{rows: rows, cols: cols, data: make([]*BitArray, rows)}
+	if cols == 0 {
+		for i := range m.data {
+			m.data[i] = New()
+		}
+		return m
+	}
+
+	nbBytes := (cols + 7) >> 3
+	stride := (cols + 63) >> 6 << 3
+	buf := make([]byte, rows*stride)
+	for i := range m.data {
+		m.data[i] = &BitArray{
+			data:    buf[i*stride : i*stride+nbBytes : (i+1)*stride],
+			padding: (nbBytes << 3) - cols,
+		}
+	}
+	return m
+}
+
+// NewIdentityBitMatrix returns the identity matrix of size n x n.
+// It will panic if n is negative.
+func NewIdentityBitMatrix(n int) *BitMatrix {
+	m := NewBitMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.data[i].SetBit(i)
+	}
+	return m
+}
+
+// BitMatrixFromRows returns a new matrix whose rows are copies of the given bit-arrays.
+// All the rows must have the same length, otherwise BitMatrixFromRows will panic.
+func BitMatrixFromRows(rows []*BitArray) *BitMatrix {
+	cols := 0
+	if len(rows) > 0 {
+		cols = rows[0].Len()
+	}
+
+	m := NewBitMatrix(len(rows), cols)
+	for i, row := range rows {
+		m.SetRow(i, row)
+	}
+	return m
+}
+
+// Rows returns the number of rows of the matrix.
+func (m *BitMatrix) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns of the matrix.
+func (m *BitMatrix) Cols() int {
+	return m.cols
+}
+
+func (m *BitMatrix) checkIndex(i, j int) {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		panic(fmt.Sprintf("matrix index out of range [%d, %d] with dimensions %dx%d", i, j, m.rows, m.cols))
+	}
+}
+
+func (m *BitMatrix) checkRow(i int) {
+	if i < 0 || i >= m.rows {
+		panic(fmt.Sprintf("row index out of range [%d] with %d rows", i, m.rows))
+	}
+}
+
+// GetBit returns the bit at row i and column j and will panic if the position is out of range.
+func (m *BitMatrix) GetBit(i, j int) byte {
+	m.checkIndex(i, j)
+	return m.data[i].GetBit(j)
+}
+
+// SetBit sets the bit at row i and column j to `1` and will panic if the position is out of range.
+func (m *BitMatrix) SetBit(i, j int) {
+	m.checkIndex(i, j)
+	m.data[i].SetBit(j)
+}
+
+// ClearBit sets the bit at row i and column j to `0` and will panic if the position is out of range.
+func (m *BitMatrix) ClearBit(i, j int) {
+	m.checkIndex(i, j)
+	m.data[i].ClearBit(j)
+}
+
+// Row returns a copy of the row i and will panic if i is out of range.
+func (m *BitMatrix) Row(i int) *BitArray {
+	m.checkRow(i)
+	return m.data[i].Clone()
+}
+
+// SetRow overwrites the row i with row, which must have Cols() bits, otherwise SetRow will panic.
+func (m *BitMatrix) SetRow(i int, row *BitArray) {
+	m.checkRow(i)
+	m.data[i].checkSameLen(row)
+	copy(m.data[i].data, row.data)
+}
+
+// Col returns a copy of the column j and will panic if j is out of range.
+func (m *BitMatrix) Col(j int) *BitArray {
+	if j < 0 || j >= m.cols {
+		panic(fmt.Sprintf("column index out of range [%d] with %d columns", j, m.cols))
+	}
+	col := NewZeros(m.rows)
+	for i, row := range m.data {
+		if row.GetBit(j) == 1 {
+			col.SetBit(i)
+		}
+	}
+	return col
+}
+
+// Clone returns a deep copy of the matrix.
+func (m *BitMatrix) Clone() *BitMatrix {
+	c := NewBitMatrix(m.rows, m.cols)
+	for i, row := range m.data {
+		copy(c.data[i].data, row.data)
+	}
+	return c
+}
+
+// Equal returns whether both matrices have the same dimensions and the same bits.
+func (m *BitMatrix) Equal(other *BitMatrix) bool {
+	if m.rows != other.rows || m.cols != other.cols {
+		return false
+	}
+	for i, row := range m.data {
+		if string(row.data) != string(other.data[i].data) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the rows of the matrix as sequences of 0's and 1's separated by new lines.
+func (m *BitMatrix) String() string {
+	var sb strings.Builder
+	for i, row := range m.data {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		for j := 0; j < m.cols; j++ {
+			sb.WriteByte('0' + row.GetBit(j))
+		}
+	}
+	return sb.String()
+}
+
+// xorRow adds the row src to the row dst, both having the same number of columns.
+// Rows of a matrix are extended to the capacity of their data, a whole number of 64-bit words.
+func xorRow(dst, src *BitArray) {
+	d, s := dst.data[:cap(dst.data)], src.data[:cap(src.data)]
+	for len(d) >= 8 {
+		binary.LittleEndian.PutUint64(d, binary.LittleEndian.Uint64(d)^binary.LittleEndian.Uint64(s))
+		d, s = d[8:], s[8:]
+	}
+	for k := range d {
+		d[k] ^= s[k]
+	}
+}
+
+// Transpose returns the transposed matrix.
+func (m *BitMatrix) Transpose() *BitMatrix {
+	t := NewBitMatrix(m.cols, m.rows)
+	for i, row := range m.data {
+		for j := row.NextOne(0); j >= 0; j = row.NextOne(j + 1) {
+			t.data[j].SetBit(i)
+		}
+	}
+	return t
+}
+
+// Mul returns the product m x other. The number of columns of m must be the number of rows of other,
+// otherwise Mul will panic.
+func (m *BitMatrix) Mul(other *BitMatrix) *BitMatrix {
+	if m.cols != other.rows {
+		panic(fmt.Sprintf("incompatible dimensions for a product; given %dx%d and %dx%d", m.rows, m.cols, other.rows, other.cols))
+	}
+
+	// the row i of the product is the sum of the rows of other selected by the bits of the row i of m
+	p := NewBitMatrix(m.rows, other.cols)
+	for i, row := range m.data {
+		for k := row.NextOne(0); k >= 0; k = row.NextOne(k + 1) {
+			xorRow(p.data[i], other.data[k])
+		}
+	}
+	return p
+}
+
+// MulVec returns the product of the matrix by the column vector v, which must have Cols() bits,
+// otherwise MulVec will panic.
+func (m *BitMatrix) MulVec(v *BitArray) *BitArray {
+	if v.Len() != m.cols {
+		panic(fmt.Sprintf("incompatible dimensions for a product; given %dx%d and a vector of length %d", m.rows, m.cols, v.Len()))
+	}
+
+	res := NewZeros(m.rows)
+	for i, row := range m.data {
+		parity := 0
+		for w := 0; w<<6 < m.cols; w++ {
+			parity ^= bits.OnesCount64(row.word(w) & v.word(w))
+		}
+		if parity&1 == 1 {
+			res.SetBit(i)
+		}
+	}
+	return res
+}
+
+// eliminate reduces the matrix in place to its reduced row echelon form by Gauss-Jordan elimination
+// and returns the columns of the pivots. The same row operations are applied to aug if it is not nil.
+func (m *BitMatrix) eliminate(aug *BitMatrix) []int {
+	pivots := []int{}
+	r := 0
+	for c := 0; c < m.cols && r < m.rows; c++ {
+		p := r
+		for p < m.rows && m.data[p].GetBit(c) == 0 {
+			p++
+		}
+		if p == m.rows {
+			continue
+		}
+
+		m.data[r], m.data[p] = m.data[p], m.data[r]
+		if aug != nil {
+			aug.data[r], aug.data[p] = aug.data[p], aug.data[r]
+		}
+		for i := 0; i < m.rows; i++ {
+			if i != r && m.data[i].GetBit(c) == 1 {
+				xorRow(m.data[i], m.data[r])
+				if aug != nil {
+					xorRow(aug.data[i], aug.data[r])
+				}
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+	return pivots
+}
+
+// ReducedRowEchelon returns the reduced row echelon form of the matrix and the columns of its pivots:
+// the row k of the result has its first `1` at column pivots[k], the only `1` of that column.
+func (m *BitMatrix) ReducedRowEchelon() (*BitMatrix, []int) {
+	r := m.Clone()
+	pivots := r.eliminate(nil)
+	return r, pivots
+}
+
+// Rank returns the rank of the matrix.
+func (m *BitMatrix) Rank() int {
+	return len(m.Clone().eliminate(nil))
+}
+
+// Inverse returns the inverse of a square matrix or ErrSingularMatrix if it is not invertible.
+// It will panic if the matrix is not square.
+func (m *BitMatrix) Inverse() (*BitMatrix, error) {
+	if m.rows != m.cols {
+		panic(fmt.Sprintf("only square matrices can be inverted; given %dx%d", m.rows, m.cols))
+	}
+
+	inv := NewIdentityBitMatrix(m.rows)
+	if pivots := m.Clone().eliminate(inv); len(pivots) < m.rows {
+		return nil, ErrSingularMatrix
+	}
+	return inv, nil
+}
+
+// NullSpace returns a basis of the null space of the matrix, the vectors x such that m x = 0.
+// Each vector has Cols() bits, the basis is empty if the columns of the matrix are linearly independent.
+func (m *BitMatrix) NullSpace() []*BitArray {
+	r, pivots := m.ReducedRowEchelon()
+
+	isPivot := make([]bool, m.cols)
+	for _, c := range pivots {
+		isPivot[c] = true
+	}
+
+	// each free variable set to 1, the others to 0, determines the pivot variables
+	basis := []*BitArray{}
+	for f := 0; f < m.cols; f++ {
+		if isPivot[f] {
+			continue
+		}
+		x := NewZeros(m.cols)
+		x.SetBit(f)
+		for k, c := range pivots {
+			if r.data[k].GetBit(f) == 1 {
+				x.SetBit(c)
+			}
+		}
+		basis = append(basis, x)
+	}
+	return basis
+}
+
+// Solve returns a solution x of the linear system m x = b or ErrInconsistentSystem if there is none.
+// When there are several solutions, the free variables are set to `0`;
+// the other solutions are obtained by adding vectors of the null space.
+// b must have Rows() bits, otherwise Solve will panic.
+func (m *BitMatrix) Solve(b *BitArray) (*BitArray, error) {
+	if b.Len() != m.rows {
+		panic(fmt.Sprintf("incompatible dimensions for a system; given %dx%d and a vector of length %d", m.rows, m.cols, b.Len()))
+	}
+
+	aug := NewBitMatrix(m.rows, 1)
+	for i := 0; i < m.rows; i++ {
+		if b.GetBit(i) == 1 {
+			aug.data[i].SetBit(0)
+		}
+	}
+	pivots := m.Clone().eliminate(aug)
+
+	for i := len(pivots); i < m.rows; i++ {
+		if aug.data[i].GetBit(0) == 1 {
+			return nil, ErrInconsistentSystem
+		}
+	}
+
+	x := NewZeros(m.cols)
+	for k, c := range pivots {
+		if aug.data[k].GetBit(0) == 1 {
+			x.SetBit(c)
+		}
+	}
+	return x, nil
+}
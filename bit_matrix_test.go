@@ -0,0 +1,307 @@
+package bitarray
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func bitMatrixOf(rows ...string) *BitMatrix {
+	bas := make([]*BitArray, len(rows))
+	for i, row := range rows {
+		bas[i] = New()
+		bas[i].AppendString(row)
+	}
+	return BitMatrixFromRows(bas)
+}
+
+func randomBitMatrix(r *rand.Rand, rows, cols int) *BitMatrix {
+	m := NewBitMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if r.Intn(2) == 1 {
+				m.SetBit(i, j)
+			}
+		}
+	}
+	return m
+}
+
+func randomBitArray(r *rand.Rand, n int) *BitArray {
+	ba := NewZeros(n)
+	for i := 0; i < n; i++ {
+		if r.Intn(2) == 1 {
+			ba.SetBit(i)
+		}
+	}
+	return ba
+}
+
+func sameBits(ba1, ba2 *BitArray) bool {
+	return ba1.Len() == ba2.Len() && string(ba1.Bytes()) == string(ba2.Bytes())
+}
+
+func TestBitMatrixAccess(t *testing.T) {
+	m := bitMatrixOf(
+		"1010",
+		"0110",
+		"0001",
+	)
+	if m.Rows() != 3 || m.Cols() != 4 {
+		t.Fatalf("dimensions are %dx%d, want 3x4", m.Rows(), m.Cols())
+	}
+
+	m.SetBit(2, 0)
+	m.ClearBit(0, 2)
+	want := "1000\n0110\n1001"
+	if m.String() != want {
+		t.Errorf("String returned bad data %q, want %q", m.String(), want)
+	}
+	if m.GetBit(1, 1) != 1 || m.GetBit(1, 3) != 0 {
+		t.Errorf("GetBit returned bad data")
+	}
+
+	row := m.Row(1)
+	row.SetBit(0)
+	if m.GetBit(1, 0) != 0 {
+		t.Errorf("modifying the result of Row modified the matrix")
+	}
+	m.SetRow(0, row)
+	if m.String() != "1110\n0110\n1001" {
+		t.Errorf("SetRow returned bad data %q", m.String())
+	}
+
+	col := m.Col(0)
+	if col.Len() != 3 || col.Extract(0, 3) != 0b101 {
+		t.Errorf("Col returned bad data %b, want 101", col.Extract(0, 3))
+	}
+
+	if !m.Clone().Equal(m) || m.Equal(bitMatrixOf("1110", "0110", "1000")) || m.Equal(NewBitMatrix(3, 5)) {
+		t.Errorf("Equal returned bad data")
+	}
+	if NewIdentityBitMatrix(3).String() != "100\n010\n001" {
+		t.Errorf("NewIdentityBitMatrix returned bad data %q", NewIdentityBitMatrix(3).String())
+	}
+}
+
+func TestBitMatrixPanics(t *testing.T) {
+	m := NewBitMatrix(2, 3)
+	tests := []struct {
+		id int
+		f  func()
+	}{
+		{0, func() { NewBitMatrix(-1, 2) }},
+		{1, func() { m.GetBit(2, 0) }},
+		{2, func() { m.SetBit(0, 3) }},
+		{3, func() { m.ClearBit(-1, 0) }},
+		{4, func() { m.Row(2) }},
+		{5, func() { m.Col(3) }},
+		{6, func() { m.SetRow(0, NewZeros(4)) }},
+		{7, func() { m.Mul(NewBitMatrix(2, 2)) }},
+		{8, func() { m.MulVec(NewZeros(2)) }},
+		{9, func() { m.Inverse() }},
+		{10, func() { m.Solve(NewZeros(3)) }},
+		{11, func() { BitMatrixFromRows([]*BitArray{NewZeros(2), NewZeros(3)}) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", test.id)
+				}
+			}()
+			test.f()
+		}()
+	}
+}
+
+func TestTransposeMul(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		id      int
+		n, k, p int
+	}{
+		{0, 0, 0, 0},
+		{1, 1, 1, 1},
+		{2, 3, 5, 7},
+		{3, 64, 64, 64},
+		{4, 70, 130, 65},
+		{5, 10, 0, 10},
+	}
+
+	for _, test := range tests {
+		a, b := randomBitMatrix(r, test.n, test.k), randomBitMatrix(r, test.k, test.p)
+
+		at := a.Transpose()
+		if at.Rows() != test.k || at.Cols() != test.n {
+			t.Fatalf("%d: Transpose returned a %dx%d matrix, want %dx%d", test.id, at.Rows(), at.Cols(), test.k, test.n)
+		}
+		for i := 0; i < test.n; i++ {
+			for j := 0; j < test.k; j++ {
+				if at.GetBit(j, i) != a.GetBit(i, j) {
+					t.Fatalf("%d: Transpose returned bad data at (%d, %d)", test.id, j, i)
+				}
+			}
+		}
+
+		c := a.Mul(b)
+		for i := 0; i < test.n; i++ {
+			for j := 0; j < test.p; j++ {
+				var want byte
+				for k := 0; k < test.k; k++ {
+					want ^= a.GetBit(i, k) & b.GetBit(k, j)
+				}
+				if c.GetBit(i, j) != want {
+					t.Fatalf("%d: Mul returned bad data %d at (%d, %d), want %d", test.id, c.GetBit(i, j), i, j, want)
+				}
+			}
+		}
+
+		// (AB)^T = B^T A^T
+		if !c.Transpose().Equal(b.Transpose().Mul(at)) {
+			t.Errorf("%d: (AB)^T is not B^T A^T", test.id)
+		}
+
+		v := randomBitArray(r, test.k)
+		mv := a.MulVec(v)
+		for i := 0; i < test.n; i++ {
+			var want byte
+			for k := 0; k < test.k; k++ {
+				want ^= a.GetBit(i, k) & v.GetBit(k)
+			}
+			if mv.GetBit(i) != want {
+				t.Fatalf("%d: MulVec returned bad data %d at %d, want %d", test.id, mv.GetBit(i), i, want)
+			}
+		}
+	}
+}
+
+func TestReducedRowEchelon(t *testing.T) {
+	tests := []struct {
+		id     int
+		m      *BitMatrix
+		want   *BitMatrix
+		pivots []int
+	}{
+		{
+			0,
+			bitMatrixOf("110", "011", "101"),
+			bitMatrixOf("101", "011", "000"),
+			[]int{0, 1},
+		},
+		{
+			1,
+			bitMatrixOf("0011", "0110", "0101"),
+			bitMatrixOf("0101", "0011", "0000"),
+			[]int{1, 2},
+		},
+		{
+			2,
+			NewIdentityBitMatrix(4),
+			NewIdentityBitMatrix(4),
+			[]int{0, 1, 2, 3},
+		},
+		{
+			3,
+			NewBitMatrix(2, 3),
+			NewBitMatrix(2, 3),
+			[]int{},
+		},
+	}
+
+	for _, test := range tests {
+		r, pivots := test.m.ReducedRowEchelon()
+		if !r.Equal(test.want) {
+			t.Errorf("%d: ReducedRowEchelon returned bad data\n%s\nwant\n%s", test.id, r, test.want)
+		}
+		if len(pivots) != len(test.pivots) {
+			t.Fatalf("%d: ReducedRowEchelon returned pivots %v, want %v", test.id, pivots, test.pivots)
+		}
+		for k := range pivots {
+			if pivots[k] != test.pivots[k] {
+				t.Fatalf("%d: ReducedRowEchelon returned pivots %v, want %v", test.id, pivots, test.pivots)
+			}
+		}
+		if test.m.Rank() != len(test.pivots) {
+			t.Errorf("%d: Rank returned %d, want %d", test.id, test.m.Rank(), len(test.pivots))
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id, n := range []int{1, 2, 8, 63, 64, 100} {
+		invertible := 0
+		for attempt := 0; attempt < 20; attempt++ {
+			m := randomBitMatrix(r, n, n)
+			inv, err := m.Inverse()
+			if m.Rank() < n {
+				if !errors.Is(err, ErrSingularMatrix) {
+					t.Errorf("%d: Inverse of a singular matrix returned error %v, want %v", id, err, ErrSingularMatrix)
+				}
+				continue
+			}
+
+			invertible++
+			if err != nil {
+				t.Fatalf("%d: Inverse returned error %v", id, err)
+			}
+			if !m.Mul(inv).Equal(NewIdentityBitMatrix(n)) || !inv.Mul(m).Equal(NewIdentityBitMatrix(n)) {
+				t.Fatalf("%d: Inverse returned a matrix that is not the inverse", id)
+			}
+		}
+		if invertible == 0 {
+			t.Errorf("%d: no invertible matrix was tested", id)
+		}
+	}
+}
+
+func TestNullSpaceSolve(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	tests := []struct {
+		id         int
+		rows, cols int
+	}{
+		{0, 1, 1},
+		{1, 3, 5},
+		{2, 5, 3},
+		{3, 40, 70},
+		{4, 70, 70},
+		{5, 100, 64},
+	}
+
+	for _, test := range tests {
+		m := randomBitMatrix(r, test.rows, test.cols)
+		zero := NewZeros(test.rows)
+
+		basis := m.NullSpace()
+		if len(basis) != test.cols-m.Rank() {
+			t.Errorf("%d: NullSpace returned %d vectors, want %d", test.id, len(basis), test.cols-m.Rank())
+		}
+		for k, x := range basis {
+			if x.Count() == 0 || !sameBits(m.MulVec(x), zero) {
+				t.Fatalf("%d: vector %d of the null space is not a non-zero solution of m x = 0", test.id, k)
+			}
+		}
+		if len(basis) > 0 && BitMatrixFromRows(basis).Rank() != len(basis) {
+			t.Errorf("%d: NullSpace returned linearly dependent vectors", test.id)
+		}
+
+		b := m.MulVec(randomBitArray(r, test.cols))
+		x, err := m.Solve(b)
+		if err != nil {
+			t.Fatalf("%d: Solve returned error %v", test.id, err)
+		}
+		if !sameBits(m.MulVec(x), b) {
+			t.Errorf("%d: Solve returned a wrong solution", test.id)
+		}
+	}
+
+	m := bitMatrixOf("110", "011", "101")
+	b := New()
+	b.AppendString("001")
+	if _, err := m.Solve(b); !errors.Is(err, ErrInconsistentSystem) {
+		t.Errorf("Solve of an inconsistent system returned error %v, want %v", err, ErrInconsistentSystem)
+	}
+}
@@ -0,0 +1,346 @@
+// Package bitcodec marshals Go structs to and from bit-arrays, field by field, following `bits` struct tags.
+// It is meant for packed binary headers whose fields are not aligned on bytes.
+//
+// Fields are encoded in their order in the struct, most significant bit first, with no padding between them.
+// The tag of a field is its width in bits followed by options, separated by commas:
+//
+//	Version  uint8   `bits:"3"`         // unsigned integer on 3 bits
+//	Offset   int16   `bits:"4,signed"`  // two's complement integer on 4 bits
+//	Port     uint16  `bits:"16,le"`     // little-endian: least significant byte first, the width must be a multiple of 8
+//	Flag     bool                        // 1 bit, or the width of its tag, 1 when true
+//	_        uint8   `bits:"5"`         // 5 reserved bits: written as 0's and skipped when read
+//	Spare    uint8   `bits:"2,reserved"` // same as _
+//	Count    uint8   `bits:"4"`
+//	Values   []int8  `bits:"6,signed,len=Count"` // Count elements of 6 bits, Count being an earlier field
+//	Table    [3]bool                     // fixed arrays hold their number of elements
+//	Sub      Header                      // nested structs are encoded in place
+//	Internal int     `bits:"-"`         // not encoded
+//
+// Untagged integers take the width of their type, as do tags with an empty width, such as `bits:",len=Count"`.
+// The width and options of an array or a slice apply to its elements.
+// Unexported fields are ignored, except the reserved `_` ones.
+//
+// When the fields are only known at run time, a Layout describes them as a list of named fields
+// and exchanges their values as maps, see NewLayout.
+package bitcodec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"reflect"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+var (
+	// ErrInvalidTag is returned when a struct tag is malformed or does not suit the type of its field.
+	ErrInvalidTag = errors.New("bitcodec: invalid tag")
+	// ErrUnsupportedType is returned for a type that cannot be encoded, such as floats, strings, maps or pointers.
+	ErrUnsupportedType = errors.New("bitcodec: unsupported type")
+	// ErrOverflow is returned when marshaling a value that does not fit in the width of its field,
+	// or when unmarshaling an unsigned value that does not fit in its signed Go type.
+	ErrOverflow = errors.New("bitcodec: value overflows its field")
+	// ErrLength is returned when marshaling a slice whose length is not the value of its length field.
+	ErrLength = errors.New("bitcodec: slice length does not match its length field")
+)
+
+// Marshal returns the bits of the struct, or pointer to a struct, v.
+func Marshal(v interface{}) (*bitarray.BitArray, error) {
+	ba := bitarray.New()
+	if err := MarshalAppend(ba, v); err != nil {
+		return nil, err
+	}
+	return ba, nil
+}
+
+// MarshalAppend appends the bits of the struct, or pointer to a struct, v to ba.
+// On error, some bits may have been appended.
+func MarshalAppend(ba *bitarray.BitArray, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a struct", ErrUnsupportedType, v)
+	}
+	sf, err := fieldsOf(rv.Type())
+	if err != nil {
+		return err
+	}
+	return encodeStruct(ba, sf, rv, &fieldPath{name: sf.name})
+}
+
+// Unmarshal decodes the bits of ba starting at position 0 into the struct pointed to by v.
+// It returns the number of bits read, the following bits of ba, such as a payload, are not read.
+// It returns io.ErrUnexpectedEOF if ba is too short.
+func Unmarshal(ba *bitarray.BitArray, v interface{}) (int, error) {
+	return UnmarshalAt(ba, 0, v)
+}
+
+// UnmarshalAt decodes the bits of ba starting at position pos into the struct pointed to by v
+// and returns the position following the last bit read.
+func UnmarshalAt(ba *bitarray.BitArray, pos int, v interface{}) (int, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return pos, fmt.Errorf("%w: %T is not a non-nil pointer to a struct", ErrUnsupportedType, v)
+	}
+	if pos < 0 || pos > ba.Len() {
+		return pos, fmt.Errorf("bitcodec: position %d out of range with length %d", pos, ba.Len())
+	}
+	sf, err := fieldsOf(rv.Elem().Type())
+	if err != nil {
+		return pos, err
+	}
+	d := &decoder{ba: ba, pos: pos}
+	err = d.decodeStruct(sf, rv.Elem(), &fieldPath{name: sf.name})
+	return d.pos, err
+}
+
+// fieldPath is the path of the field being encoded or decoded, it is only formatted to report an error.
+type fieldPath struct {
+	parent *fieldPath
+	name   string // "" for an element of an array or a slice
+	index  int
+}
+
+func (p *fieldPath) String() string {
+	switch {
+	case p.parent == nil:
+		return p.name
+	case p.name == "":
+		return fmt.Sprintf("%s[%d]", p.parent, p.index)
+	}
+	return p.parent.String() + "." + p.name
+}
+
+func encodeStruct(ba *bitarray.BitArray, sf *structFields, rv reflect.Value, path *fieldPath) error {
+	for _, f := range sf.fields {
+		fpath := &fieldPath{parent: path, name: f.name}
+		fv := rv.Field(f.index)
+		switch {
+		case f.reserved:
+			for n := f.bits; n > 0; n -= 64 {
+				ba.Append64(0, min(n, 64))
+			}
+		case f.elem != nil:
+			if f.lenField >= 0 {
+				if length := rv.Field(sf.fields[f.lenField].index); !sameInt(length, fv.Len()) {
+					return fmt.Errorf("%w: %s has %d elements", ErrLength, fpath, fv.Len())
+				}
+			}
+			// elements encoding no bits are left as they are, whatever their number
+			epath := &fieldPath{parent: fpath}
+			for i := 0; i < fv.Len() && f.elem.minWidth > 0; i++ {
+				epath.index = i
+				if err := encodeValue(ba, f.elem, fv.Index(i), epath); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := encodeValue(ba, f, fv, fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sameInt returns whether the integer value v is equal to n.
+func sameInt(v reflect.Value, n int) bool {
+	if v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 {
+		return v.Int() == int64(n)
+	}
+	return v.Uint() == uint64(n)
+}
+
+func encodeValue(ba *bitarray.BitArray, f *field, v reflect.Value, path *fieldPath) error {
+	if f.sub != nil {
+		return encodeStruct(ba, f.sub, v, path)
+	}
+	if v.Kind() == reflect.Bool {
+		if v.Bool() {
+			ba.Append64(1, f.bits)
+		} else {
+			ba.Append64(0, f.bits)
+		}
+		return nil
+	}
+
+	var u uint64
+	if v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64 {
+		var ok bool
+		if u, ok = packInt(f, v.Int()); !ok {
+			return overflowInt(f, v.Int(), path.String())
+		}
+	} else {
+		var ok bool
+		if u, ok = packUint(f, v.Uint()); !ok {
+			return overflowUint(f, v.Uint(), path.String())
+		}
+	}
+	ba.Append64(u, f.bits)
+	return nil
+}
+
+// packInt returns the f.bits bits encoding x in the integer field f, and whether x fits in it.
+func packInt(f *field, x int64) (uint64, bool) {
+	if !f.signed {
+		if x < 0 {
+			return 0, false
+		}
+		return packUint(f, uint64(x))
+	}
+	if f.bits < 64 && (x < -1<<uint(f.bits-1) || x >= 1<<uint(f.bits-1)) {
+		return 0, false
+	}
+	u := uint64(x)
+	if f.bits < 64 {
+		u &= 1<<uint(f.bits) - 1
+	}
+	if f.le {
+		u = bits.ReverseBytes64(u) >> uint(64-f.bits)
+	}
+	return u, true
+}
+
+// packUint returns the f.bits bits encoding u in the integer field f, and whether u fits in it.
+func packUint(f *field, u uint64) (uint64, bool) {
+	if f.bits < 64 && u >= 1<<uint(f.bits) {
+		return 0, false
+	}
+	if f.le {
+		u = bits.ReverseBytes64(u) >> uint(64-f.bits)
+	}
+	return u, true
+}
+
+// overflowInt returns the error of packing x in the field f at path.
+func overflowInt(f *field, x int64, path string) error {
+	switch {
+	case !f.signed && x < 0:
+		return fmt.Errorf("%w: %s: negative value %d of an unsigned field", ErrOverflow, path, x)
+	case f.signed:
+		return fmt.Errorf("%w: %s: %d on %d signed bits", ErrOverflow, path, x, f.bits)
+	}
+	return overflowUint(f, uint64(x), path)
+}
+
+// overflowUint returns the error of packing u in the field f at path.
+func overflowUint(f *field, u uint64, path string) error {
+	return fmt.Errorf("%w: %s: %d on %d bits", ErrOverflow, path, u, f.bits)
+}
+
+// unpack returns the value of the f.bits bits u of the integer field f, as an unsigned integer.
+func unpack(f *field, u uint64) uint64 {
+	if f.le {
+		u = bits.ReverseBytes64(u) >> uint(64-f.bits)
+	}
+	return u
+}
+
+// unpackInt returns the value of the f.bits bits u of the integer field f, sign-extended if f is signed.
+func unpackInt(f *field, u uint64) int64 {
+	u = unpack(f, u)
+	if f.signed {
+		return int64(u<<uint(64-f.bits)) >> uint(64-f.bits)
+	}
+	return int64(u)
+}
+
+type decoder struct {
+	ba  *bitarray.BitArray
+	pos int
+}
+
+// read returns the n <= 64 next bits.
+func (d *decoder) read(n int) (uint64, error) {
+	if n > d.ba.Len()-d.pos {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v := d.ba.Extract(d.pos, d.pos+n)
+	d.pos += n
+	return v, nil
+}
+
+func (d *decoder) decodeStruct(sf *structFields, rv reflect.Value, path *fieldPath) error {
+	for _, f := range sf.fields {
+		fpath := &fieldPath{parent: path, name: f.name}
+		fv := rv.Field(f.index)
+		switch {
+		case f.reserved:
+			if f.bits > d.ba.Len()-d.pos {
+				return io.ErrUnexpectedEOF
+			}
+			d.pos += f.bits
+		case f.elem != nil:
+			if f.lenField >= 0 {
+				length := rv.Field(sf.fields[f.lenField].index)
+				var n uint64
+				if length.Kind() >= reflect.Int && length.Kind() <= reflect.Int64 {
+					if length.Int() < 0 {
+						return fmt.Errorf("%w: %s: negative length %d", ErrLength, fpath, length.Int())
+					}
+					n = uint64(length.Int())
+				} else {
+					n = length.Uint()
+				}
+				// each element takes at least minWidth bits, a larger length cannot be read
+				if w := f.elem.minWidth; w > 0 && n > uint64((d.ba.Len()-d.pos)/w) {
+					return io.ErrUnexpectedEOF
+				}
+				if n > uint64(maxInt) {
+					return fmt.Errorf("%w: %s: length %d is too large", ErrLength, fpath, n)
+				}
+				fv.Set(reflect.MakeSlice(fv.Type(), int(n), int(n)))
+			}
+			// elements encoding no bits are left as they are, whatever their number
+			epath := &fieldPath{parent: fpath}
+			for i := 0; i < fv.Len() && f.elem.minWidth > 0; i++ {
+				epath.index = i
+				if err := d.decodeValue(f.elem, fv.Index(i), epath); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := d.decodeValue(f, fv, fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeValue(f *field, v reflect.Value, path *fieldPath) error {
+	if f.sub != nil {
+		return d.decodeStruct(f.sub, v, path)
+	}
+	u, err := d.read(f.bits)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case v.Kind() == reflect.Bool:
+		v.SetBool(u != 0)
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		x := unpackInt(f, u)
+		if v.OverflowInt(x) || (!f.signed && x < 0) {
+			return fmt.Errorf("%w: %s: %d does not fit in %s", ErrOverflow, path, u, v.Type())
+		}
+		v.SetInt(x)
+	default:
+		v.SetUint(unpack(f, u))
+	}
+	return nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
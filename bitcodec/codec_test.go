@@ -0,0 +1,226 @@
+package bitcodec
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+type inner struct {
+	A uint8  `bits:"2"`
+	B uint32 `bits:"20"`
+}
+
+type header struct {
+	Version  uint8  `bits:"3"`
+	Length   uint16 `bits:"13"`
+	Delta    int8   `bits:"4,signed"`
+	Port     uint16 `bits:"16,le"`
+	Flag     bool
+	_        uint8  `bits:"3"`
+	Count    uint8  `bits:"4"`
+	Values   []int8 `bits:"6,signed,len=Count"`
+	Table    [3]bool
+	Sub      inner
+	Spare    uint8 `bits:"2,reserved"`
+	Internal int   `bits:"-"`
+	private  int
+}
+
+func bitArrayOf(s string) *bitarray.BitArray {
+	ba := bitarray.New()
+	for _, c := range s {
+		ba.AppendBit(byte(c - '0'))
+	}
+	return ba
+}
+
+func bitString(ba *bitarray.BitArray) string {
+	var sb strings.Builder
+	for i := 0; i < ba.Len(); i++ {
+		sb.WriteByte('0' + ba.GetBit(i))
+	}
+	return sb.String()
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		id   int
+		v    interface{}
+		bits []string
+	}{
+		{0, struct{}{}, nil},
+		{1, struct{ A, B bool }{true, false}, []string{"1", "0"}},
+		{2, struct{ A uint8 }{0xa5}, []string{"10100101"}},
+		{3, struct {
+			A int16 `bits:"16,signed,le"`
+		}{-2}, []string{"1111111011111111"}},
+		{
+			4,
+			&header{
+				Version: 5, Length: 10, Delta: -3, Port: 0x1234, Flag: true,
+				Count: 2, Values: []int8{-1, 5}, Table: [3]bool{true, false, true},
+				Sub: inner{A: 3, B: 0xabcde}, Spare: 3, Internal: 42, private: 7,
+			},
+			[]string{
+				"101",              // Version
+				"0000000001010",    // Length
+				"1101",             // Delta
+				"0011010000010010", // Port, 0x34 then 0x12
+				"1",                // Flag
+				"000",              // _
+				"0010",             // Count
+				"111111", "000101", // Values
+				"101",                        // Table
+				"11", "10101011110011011110", // Sub
+				"00", // Spare
+			},
+		},
+	}
+
+	for _, test := range tests {
+		ba, err := Marshal(test.v)
+		want := strings.Join(test.bits, "")
+		if err != nil || bitString(ba) != want {
+			t.Errorf("%d: Marshal returned bad data (%v, %v), want %s", test.id, ba, err, want)
+			continue
+		}
+
+		// unmarshal into a new value of the same type, followed by a payload
+		rv := reflect.New(reflect.Indirect(reflect.ValueOf(test.v)).Type())
+		ba.AppendString("1011")
+		n, err := Unmarshal(ba, rv.Interface())
+		if err != nil || n != len(want) {
+			t.Errorf("%d: Unmarshal returned (%d, %v), want (%d, nil)", test.id, n, err, len(want))
+		}
+		if h, ok := test.v.(*header); ok {
+			// reserved, skipped and unexported fields are not decoded
+			h.Spare, h.Internal, h.private = 0, 0, 0
+		}
+		if got := rv.Elem().Interface(); !reflect.DeepEqual(got, reflect.Indirect(reflect.ValueOf(test.v)).Interface()) {
+			t.Errorf("%d: Unmarshal returned bad data %+v, want %+v", test.id, got, test.v)
+		}
+	}
+}
+
+func TestUnmarshalAt(t *testing.T) {
+	var v struct {
+		A uint8 `bits:"3"`
+		B int8  `bits:"3,signed"`
+	}
+	ba := bitArrayOf("11110110")
+	pos, err := UnmarshalAt(ba, 2, &v)
+	if err != nil || pos != 8 || v.A != 0b110 || v.B != -2 {
+		t.Errorf("UnmarshalAt returned bad data (%d, %v, %+v), want (8, nil, {A:6 B:-2})", pos, err, v)
+	}
+}
+
+func TestZeroWidthElements(t *testing.T) {
+	type empty struct{}
+	type message struct {
+		N      uint8
+		Marker []empty `bits:",len=N"`
+		A      uint8   `bits:"3"`
+	}
+	m := message{N: 200, Marker: make([]empty, 200), A: 5}
+	ba, err := Marshal(m)
+	if err != nil || bitString(ba) != "11001000"+"101" {
+		t.Fatalf("Marshal returned bad data (%v, %v)", ba, err)
+	}
+	var got message
+	if n, err := Unmarshal(ba, &got); err != nil || n != 11 || !reflect.DeepEqual(got, m) {
+		t.Errorf("Unmarshal returned bad data (%d, %v, %+v), want (11, nil, %+v)", n, err, got, m)
+	}
+
+	// zero-sized elements take no memory, whatever their number
+	var large struct {
+		N      uint64
+		Marker []empty `bits:",len=N"`
+	}
+	if _, err := Unmarshal(bitArrayOf(strings.Repeat("0", 23)+"1"+strings.Repeat("0", 40)), &large); err != nil || len(large.Marker) != 1<<40 {
+		t.Errorf("Unmarshal of a large slice of empty elements returned (%v, %d elements)", err, len(large.Marker))
+	}
+}
+
+func TestErrorPath(t *testing.T) {
+	type element struct {
+		A uint8 `bits:"2"`
+	}
+	type outer struct {
+		N uint8
+		S []element `bits:",len=N"`
+	}
+	_, err := Marshal(outer{N: 2, S: []element{{1}, {4}}})
+	if want := "bitcodec: value overflows its field: outer.S[1].A: 4 on 2 bits"; err == nil || err.Error() != want {
+		t.Errorf("Marshal returned error %v, want %s", err, want)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		id  int
+		v   interface{}
+		err error
+	}{
+		{0, 42, ErrUnsupportedType},
+		{1, struct{ A float64 }{}, ErrUnsupportedType},
+		{2, struct {
+			A uint8 `bits:"3"`
+		}{8}, ErrOverflow},
+		{3, struct {
+			A int8 `bits:"3"`
+		}{-1}, ErrOverflow},
+		{4, struct {
+			A int8 `bits:"3,signed"`
+		}{4}, ErrOverflow},
+		{5, struct {
+			A int8 `bits:"3,signed"`
+		}{-5}, ErrOverflow},
+		{6, struct {
+			N uint8
+			S []uint8 `bits:",len=N"`
+		}{1, []uint8{1, 2}}, ErrLength},
+		{7, struct {
+			A uint8 `bits:"9"`
+		}{}, ErrInvalidTag},
+	}
+
+	for _, test := range tests {
+		if _, err := Marshal(test.v); !errors.Is(err, test.err) {
+			t.Errorf("%d: Marshal returned %v, want %v", test.id, err, test.err)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var h header
+	if _, err := Unmarshal(bitArrayOf("1010"), &h); err != io.ErrUnexpectedEOF {
+		t.Errorf("Unmarshal of short data returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := Unmarshal(bitArrayOf("1010"), h); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Unmarshal into a non pointer returned %v, want %v", err, ErrUnsupportedType)
+	}
+
+	// a length larger than the remaining bits
+	var s struct {
+		N uint8
+		S []uint8 `bits:",len=N"`
+	}
+	if _, err := Unmarshal(bitArrayOf("11111111"+strings.Repeat("0", 64)), &s); err != io.ErrUnexpectedEOF {
+		t.Errorf("Unmarshal with a large length returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	var o struct {
+		A int8 `bits:"8"`
+	}
+	if _, err := Unmarshal(bitArrayOf("11111111"), &o); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Unmarshal of an unsigned value too large for its type returned %v, want %v", err, ErrOverflow)
+	}
+	if _, err := UnmarshalAt(bitArrayOf("1"), 2, &o); err == nil {
+		t.Errorf("UnmarshalAt out of range did not return an error")
+	}
+}
@@ -0,0 +1,303 @@
+package bitcodec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+var (
+	// ErrInvalidLayout is returned when building a Layout from malformed fields.
+	ErrInvalidLayout = errors.New("bitcodec: invalid layout")
+	// ErrConstraint is returned when a value breaks the range or constant of its field, or when reserved bits are not 0.
+	ErrConstraint = errors.New("bitcodec: constraint violated")
+	// ErrMissingValue is returned when encoding values lacking a field that has no constant.
+	ErrMissingValue = errors.New("bitcodec: missing value")
+	// ErrUnknownField is returned when encoding a value whose name is not a field of the layout.
+	ErrUnknownField = errors.New("bitcodec: unknown field")
+)
+
+// Field describes a field of a Layout. Fields are built with Uint, Int and Reserved,
+// and refined with LittleEndian, Range and Const:
+//
+//	bitcodec.Uint("version", 4).Const(4)
+//	bitcodec.Uint("ihl", 4).Range(5, 15)
+//	bitcodec.Reserved(1)
+//	bitcodec.Int("delta", 12)
+//	bitcodec.Uint("port", 16).LittleEndian()
+type Field struct {
+	f        field
+	hasRange bool
+	min, max int64
+	hasConst bool
+	constant int64
+}
+
+// Uint returns an unsigned integer field of the given width, at most 63 bits as values are int64's.
+func Uint(name string, bits int) Field {
+	return Field{f: field{name: name, bits: bits}}
+}
+
+// Int returns a two's complement integer field of the given width, at most 64 bits.
+func Int(name string, bits int) Field {
+	return Field{f: field{name: name, bits: bits, signed: true}}
+}
+
+// Reserved returns a field of the given width whose bits must be 0.
+// Reserved fields have no name, are written as 0's and are not part of decoded values.
+func Reserved(bits int) Field {
+	return Field{f: field{name: "_", bits: bits, reserved: true}}
+}
+
+// LittleEndian returns a copy of the field encoded least significant byte first.
+// Its width must be a multiple of 8.
+func (fd Field) LittleEndian() Field {
+	fd.f.le = true
+	return fd
+}
+
+// Range returns a copy of the field whose values must lie in [min, max].
+func (fd Field) Range(min, max int64) Field {
+	fd.hasRange, fd.min, fd.max = true, min, max
+	return fd
+}
+
+// Const returns a copy of the field whose value must be v. Encode writes v when the field has no value.
+func (fd Field) Const(v int64) Field {
+	fd.hasConst, fd.constant = true, v
+	return fd
+}
+
+// check returns why the value x of the field is not allowed, or "" if it is.
+func (fd *Field) check(x int64) string {
+	switch {
+	case fd.hasConst && x != fd.constant:
+		return fmt.Sprintf("want %d", fd.constant)
+	case fd.hasRange && (x < fd.min || x > fd.max):
+		return fmt.Sprintf("out of range [%d, %d]", fd.min, fd.max)
+	}
+	return ""
+}
+
+// Layout is a runtime schema of consecutive bit fields, such as a protocol header.
+// Values are exchanged as maps from field names to int64's.
+type Layout struct {
+	fields []Field
+	length int
+	byName map[string]int
+}
+
+// NewLayout returns the layout made of fields, in this order and with no padding between them.
+func NewLayout(fields ...Field) (*Layout, error) {
+	l := &Layout{fields: make([]Field, len(fields)), byName: map[string]int{}}
+	copy(l.fields, fields)
+	for i := range l.fields {
+		fd := &l.fields[i]
+		if err := fd.validate(); err != nil {
+			return nil, err
+		}
+		if !fd.f.reserved {
+			if _, ok := l.byName[fd.f.name]; ok {
+				return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidLayout, fd.f.name)
+			}
+			l.byName[fd.f.name] = i
+		}
+		l.length += fd.f.bits
+	}
+	return l, nil
+}
+
+func (fd *Field) validate() error {
+	f := &fd.f
+	switch {
+	case f.reserved:
+		if f.bits <= 0 {
+			return fmt.Errorf("%w: reserved field with width %d", ErrInvalidLayout, f.bits)
+		}
+		return nil
+	case f.name == "" || f.name == "_":
+		return fmt.Errorf("%w: field with no name", ErrInvalidLayout)
+	case f.bits <= 0 || f.bits > 64 || (!f.signed && f.bits > 63):
+		return fmt.Errorf("%w: %s: bad width %d", ErrInvalidLayout, f.name, f.bits)
+	case f.le && f.bits%8 != 0:
+		return fmt.Errorf("%w: %s: little-endian width %d is not a multiple of 8", ErrInvalidLayout, f.name, f.bits)
+	case fd.hasRange && fd.min > fd.max:
+		return fmt.Errorf("%w: %s: empty range [%d, %d]", ErrInvalidLayout, f.name, fd.min, fd.max)
+	}
+	if fd.hasConst {
+		if _, ok := packInt(f, fd.constant); !ok {
+			return fmt.Errorf("%w: %s: constant %d does not fit in the field", ErrInvalidLayout, f.name, fd.constant)
+		}
+		if reason := fd.check(fd.constant); reason != "" {
+			return fmt.Errorf("%w: %s: constant %d %s", ErrInvalidLayout, f.name, fd.constant, reason)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of bits of the layout.
+func (l *Layout) Len() int {
+	return l.length
+}
+
+// Encode returns the bits of values, see EncodeAppend.
+func (l *Layout) Encode(values map[string]int64) (*bitarray.BitArray, error) {
+	ba := bitarray.New()
+	if err := l.EncodeAppend(ba, values); err != nil {
+		return nil, err
+	}
+	return ba, nil
+}
+
+// EncodeAppend appends the bits of values to ba, in the order of the fields.
+// Each field must have a value that fits in its width and satisfies its constraints,
+// except fields with a constant which take it when they have no value. Reserved fields are written as 0's.
+// On error, nothing is appended.
+func (l *Layout) EncodeAppend(ba *bitarray.BitArray, values map[string]int64) error {
+	for name := range values {
+		if _, ok := l.byName[name]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownField, name)
+		}
+	}
+
+	words := make([]uint64, len(l.fields))
+	for i := range l.fields {
+		fd := &l.fields[i]
+		if fd.f.reserved {
+			continue
+		}
+		x, ok := values[fd.f.name]
+		if !ok {
+			if !fd.hasConst {
+				return fmt.Errorf("%w: %s", ErrMissingValue, fd.f.name)
+			}
+			x = fd.constant
+		}
+		u, ok := packInt(&fd.f, x)
+		if !ok {
+			return overflowInt(&fd.f, x, fd.f.name)
+		}
+		if reason := fd.check(x); reason != "" {
+			return fmt.Errorf("%w: %s: %d: %s", ErrConstraint, fd.f.name, x, reason)
+		}
+		words[i] = u
+	}
+
+	for i := range l.fields {
+		if l.fields[i].f.reserved {
+			for n := l.fields[i].f.bits; n > 0; n -= 64 {
+				ba.Append64(0, min(n, 64))
+			}
+			continue
+		}
+		ba.Append64(words[i], l.fields[i].f.bits)
+	}
+	return nil
+}
+
+// Decode returns the values of the fields read from ba starting at position pos.
+// It does not check constraints, see Validate. It returns io.ErrUnexpectedEOF if ba is too short.
+func (l *Layout) Decode(ba *bitarray.BitArray, pos int) (map[string]int64, error) {
+	if err := l.checkRange(ba, pos); err != nil {
+		return nil, err
+	}
+	values := make(map[string]int64, len(l.byName))
+	for i := range l.fields {
+		f := &l.fields[i].f
+		if !f.reserved {
+			values[f.name] = unpackInt(f, ba.Extract(pos, pos+f.bits))
+		}
+		pos += f.bits
+	}
+	return values, nil
+}
+
+// Validate checks the fields read from ba starting at position pos: values must satisfy
+// the constraints of their field and reserved bits must be 0. It returns the first violation,
+// wrapping ErrConstraint, or io.ErrUnexpectedEOF if ba is too short.
+func (l *Layout) Validate(ba *bitarray.BitArray, pos int) error {
+	if err := l.checkRange(ba, pos); err != nil {
+		return err
+	}
+	for i := range l.fields {
+		fd := &l.fields[i]
+		if _, reason := fd.read(ba, pos); reason != "" {
+			return fmt.Errorf("%w: %s at bit %d: %s", ErrConstraint, fd.f.name, pos, reason)
+		}
+		pos += fd.f.bits
+	}
+	return nil
+}
+
+// Dump writes an annotated table of the fields read from ba starting at position pos:
+// the offset, width, raw bits and value of each field, followed by the constraint it violates, if any.
+// If ba is too short, the fields it holds are written and io.ErrUnexpectedEOF is returned.
+//
+//	OFFSET  WIDTH  BITS      FIELD    VALUE
+//	0       4      0100      version  4
+//	4       4      0011      ihl      3      ! out of range [5, 15]
+//	8       1      0         _        0
+func (l *Layout) Dump(w io.Writer, ba *bitarray.BitArray, pos int) error {
+	if pos < 0 || pos > ba.Len() {
+		return fmt.Errorf("bitcodec: position %d out of range with length %d", pos, ba.Len())
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "OFFSET\tWIDTH\tBITS\tFIELD\tVALUE\t")
+	var err error
+	for i := range l.fields {
+		fd := &l.fields[i]
+		if fd.f.bits > ba.Len()-pos {
+			err = io.ErrUnexpectedEOF
+			break
+		}
+		value, reason := fd.read(ba, pos)
+		if reason != "" {
+			reason = "! " + reason
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\n", pos, fd.f.bits, bitsOf(ba, pos, fd.f.bits), fd.f.name, value, reason)
+		pos += fd.f.bits
+	}
+	if ferr := tw.Flush(); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+// read returns the formatted value of the field at position pos of ba, and the constraint it violates, if any.
+func (fd *Field) read(ba *bitarray.BitArray, pos int) (string, string) {
+	if fd.f.reserved {
+		for n := fd.f.bits; n > 0; n -= 64 {
+			if ba.Extract(pos, pos+min(n, 64)) != 0 {
+				return "", "reserved bits not 0"
+			}
+			pos += 64
+		}
+		return "0", ""
+	}
+	x := unpackInt(&fd.f, ba.Extract(pos, pos+fd.f.bits))
+	return fmt.Sprint(x), fd.check(x)
+}
+
+func (l *Layout) checkRange(ba *bitarray.BitArray, pos int) error {
+	if pos < 0 || pos > ba.Len() {
+		return fmt.Errorf("bitcodec: position %d out of range with length %d", pos, ba.Len())
+	}
+	if l.length > ba.Len()-pos {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// bitsOf returns the n bits of ba starting at position pos as a string of 0's and 1's.
+func bitsOf(ba *bitarray.BitArray, pos, n int) string {
+	var sb strings.Builder
+	sb.Grow(n)
+	for i := pos; i < pos+n; i++ {
+		sb.WriteByte('0' + ba.GetBit(i))
+	}
+	return sb.String()
+}
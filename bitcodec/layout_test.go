@@ -0,0 +1,188 @@
+package bitcodec
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testLayout(t *testing.T) *Layout {
+	l, err := NewLayout(
+		Uint("version", 4).Const(4),
+		Uint("ihl", 4).Range(5, 15),
+		Reserved(2),
+		Int("delta", 6),
+		Uint("port", 16).LittleEndian(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLayoutEncodeDecode(t *testing.T) {
+	l := testLayout(t)
+	if l.Len() != 32 {
+		t.Errorf("Len returned %d, want 32", l.Len())
+	}
+
+	tests := []struct {
+		id     int
+		values map[string]int64
+		bits   []string
+		want   map[string]int64
+	}{
+		{
+			0,
+			map[string]int64{"version": 4, "ihl": 5, "delta": -3, "port": 0x1234},
+			[]string{"0100", "0101", "00", "111101", "0011010000010010"},
+			nil,
+		},
+		{
+			1,
+			map[string]int64{"ihl": 15, "delta": 31, "port": 0},
+			[]string{"0100", "1111", "00", "011111", "0000000000000000"},
+			map[string]int64{"version": 4, "ihl": 15, "delta": 31, "port": 0},
+		},
+	}
+
+	for _, test := range tests {
+		ba, err := l.Encode(test.values)
+		want := strings.Join(test.bits, "")
+		if err != nil || bitString(ba) != want {
+			t.Errorf("%d: Encode returned bad data (%v, %v), want %s", test.id, ba, err, want)
+			continue
+		}
+		if test.want == nil {
+			test.want = test.values
+		}
+
+		// decode behind a prefix
+		prefixed := bitArrayOf("101")
+		prefixed.AppendBitArray(ba)
+		values, err := l.Decode(prefixed, 3)
+		if err != nil || !reflect.DeepEqual(values, test.want) {
+			t.Errorf("%d: Decode returned bad data (%v, %v), want %v", test.id, values, err, test.want)
+		}
+		if err := l.Validate(prefixed, 3); err != nil {
+			t.Errorf("%d: Validate returned %v, want nil", test.id, err)
+		}
+	}
+}
+
+func TestLayoutEncodeErrors(t *testing.T) {
+	l := testLayout(t)
+	tests := []struct {
+		id     int
+		values map[string]int64
+		err    error
+	}{
+		{0, map[string]int64{"ihl": 5, "delta": 0}, ErrMissingValue},
+		{1, map[string]int64{"ihl": 5, "delta": 0, "port": 0, "ttl": 1}, ErrUnknownField},
+		{2, map[string]int64{"ihl": 5, "delta": 0, "port": 0, "_": 0}, ErrUnknownField},
+		{3, map[string]int64{"version": 6, "ihl": 5, "delta": 0, "port": 0}, ErrConstraint},
+		{4, map[string]int64{"ihl": 4, "delta": 0, "port": 0}, ErrConstraint},
+		{5, map[string]int64{"ihl": 16, "delta": 0, "port": 0}, ErrOverflow},
+		{6, map[string]int64{"ihl": 5, "delta": -33, "port": 0}, ErrOverflow},
+		{7, map[string]int64{"ihl": 5, "delta": 0, "port": -1}, ErrOverflow},
+	}
+
+	for _, test := range tests {
+		ba := bitArrayOf("1")
+		if err := l.EncodeAppend(ba, test.values); !errors.Is(err, test.err) || ba.Len() != 1 {
+			t.Errorf("%d: EncodeAppend returned %v and %d bits, want %v and 1 bit", test.id, err, ba.Len(), test.err)
+		}
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	l := testLayout(t)
+	tests := []struct {
+		id   int
+		bits string
+		err  error
+	}{
+		{0, "0100" + "0101" + "00" + "000000" + "0000000000000000", nil},
+		{1, "0110" + "0101" + "00" + "000000" + "0000000000000000", ErrConstraint},
+		{2, "0100" + "0001" + "00" + "000000" + "0000000000000000", ErrConstraint},
+		{3, "0100" + "0101" + "01" + "000000" + "0000000000000000", ErrConstraint},
+		{4, "0100" + "0101" + "00" + "000000" + "000000000000000", io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		ba := bitArrayOf(test.bits)
+		if err := l.Validate(ba, 0); !errors.Is(err, test.err) {
+			t.Errorf("%d: Validate returned %v, want %v", test.id, err, test.err)
+		}
+		// decoding does not check constraints
+		if _, err := l.Decode(ba, 0); test.err == io.ErrUnexpectedEOF && err != io.ErrUnexpectedEOF || test.err != io.ErrUnexpectedEOF && err != nil {
+			t.Errorf("%d: Decode returned %v", test.id, err)
+		}
+	}
+
+	if err := l.Validate(bitArrayOf("0"), 2); err == nil || errors.Is(err, ErrConstraint) {
+		t.Errorf("Validate out of range returned %v, want a range error", err)
+	}
+}
+
+func TestLayoutDump(t *testing.T) {
+	l := testLayout(t)
+	ba := bitArrayOf("0100" + "0011" + "10" + "111101" + "0011010000010010")
+
+	var sb strings.Builder
+	if err := l.Dump(&sb, ba, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"OFFSET  WIDTH  BITS              FIELD    VALUE  \n" +
+		"0       4      0100              version  4      \n" +
+		"4       4      0011              ihl      3      ! out of range [5, 15]\n" +
+		"8       2      10                _               ! reserved bits not 0\n" +
+		"10      6      111101            delta    -3     \n" +
+		"16      16     0011010000010010  port     4660   \n"
+	if sb.String() != want {
+		t.Errorf("Dump returned bad data\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	// a truncated input dumps the fields it holds: the header then version, ihl and _
+	sb.Reset()
+	if err := l.Dump(&sb, ba, 20); err != io.ErrUnexpectedEOF {
+		t.Errorf("Dump of short data returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if lines := strings.Count(sb.String(), "\n"); lines != 4 {
+		t.Errorf("Dump of short data wrote %d lines, want 4", lines)
+	}
+}
+
+func TestNewLayoutErrors(t *testing.T) {
+	tests := []struct {
+		id     int
+		fields []Field
+	}{
+		{0, []Field{Uint("", 3)}},
+		{1, []Field{Uint("_", 3)}},
+		{2, []Field{Uint("a", 0)}},
+		{3, []Field{Uint("a", 64)}},
+		{4, []Field{Int("a", 65)}},
+		{5, []Field{Uint("a", 12).LittleEndian()}},
+		{6, []Field{Uint("a", 3).Range(4, 2)}},
+		{7, []Field{Uint("a", 3).Const(8)}},
+		{8, []Field{Int("a", 3).Const(-5)}},
+		{9, []Field{Uint("a", 3).Range(2, 4).Const(5)}},
+		{10, []Field{Uint("a", 3), Int("a", 3)}},
+		{11, []Field{Reserved(0)}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewLayout(test.fields...); !errors.Is(err, ErrInvalidLayout) {
+			t.Errorf("%d: NewLayout returned %v, want %v", test.id, err, ErrInvalidLayout)
+		}
+	}
+
+	l, err := NewLayout(Reserved(3), Uint("a", 63), Reserved(70), Int("b", 64))
+	if err != nil || l.Len() != 200 {
+		t.Errorf("NewLayout returned (%v, %v), want a layout of 200 bits", l, err)
+	}
+}
@@ -0,0 +1,207 @@
+package bitcodec
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// field describes how a struct field, or an element of an array or slice field, is encoded.
+type field struct {
+	name     string
+	index    int // index of the field in its struct, -1 for an element
+	typ      reflect.Type
+	bits     int // width of an integer or a boolean, or total width of a reserved field
+	signed   bool
+	le       bool
+	reserved bool
+	lenField int           // for a slice, index in fields of the integer field holding its length
+	elem     *field        // for an array or a slice
+	sub      *structFields // for a struct
+	minWidth int           // for an element, the smallest number of bits of its encoded values
+}
+
+// structFields is the compiled form of a struct type.
+type structFields struct {
+	name   string
+	fields []*field
+}
+
+var cache sync.Map // reflect.Type -> *structFields
+
+// fieldsOf returns the compiled form of the struct type t, compiling it on first use.
+func fieldsOf(t reflect.Type) (*structFields, error) {
+	if sf, ok := cache.Load(t); ok {
+		return sf.(*structFields), nil
+	}
+	sf, err := compileStruct(t, t.Name(), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	cache.Store(t, sf)
+	return sf, nil
+}
+
+// compileStruct compiles the struct type t, visiting holds the struct types being compiled.
+func compileStruct(t reflect.Type, path string, visiting map[reflect.Type]bool) (*structFields, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("%w: %s: recursive type %s", ErrUnsupportedType, path, t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	sf := &structFields{name: t.Name()}
+	byName := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		sfield := t.Field(i)
+		tag, tagged := sfield.Tag.Lookup("bits")
+		if tag == "-" || (sfield.PkgPath != "" && sfield.Name != "_") {
+			// skipped and unexported fields are not encoded
+			continue
+		}
+
+		f, err := compileField(sfield, tag, tagged, path+"."+sfield.Name, sf.fields, byName, visiting)
+		if err != nil {
+			return nil, err
+		}
+		f.index = i
+		byName[sfield.Name] = len(sf.fields)
+		sf.fields = append(sf.fields, f)
+	}
+	return sf, nil
+}
+
+// compileField compiles a struct field, previous are the fields already compiled in the same struct.
+func compileField(sfield reflect.StructField, tag string, tagged bool, path string, previous []*field, byName map[string]int, visiting map[reflect.Type]bool) (*field, error) {
+	f := &field{name: sfield.Name, typ: sfield.Type, lenField: -1}
+	opts := strings.Split(tag, ",")
+	if tagged && opts[0] != "" {
+		n, err := strconv.Atoi(opts[0])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%w: %s: bad width %q", ErrInvalidTag, path, opts[0])
+		}
+		f.bits = n
+	}
+	for _, opt := range opts[1:] {
+		switch {
+		case opt == "signed":
+			f.signed = true
+		case opt == "le":
+			f.le = true
+		case opt == "reserved":
+			f.reserved = true
+		case strings.HasPrefix(opt, "len="):
+			k, ok := byName[opt[len("len="):]]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: length field %q is not an earlier field", ErrInvalidTag, path, opt[len("len="):])
+			}
+			if !isInteger(previous[k].typ) || previous[k].reserved {
+				return nil, fmt.Errorf("%w: %s: length field %q is not an integer", ErrInvalidTag, path, opt[len("len="):])
+			}
+			f.lenField = k
+		default:
+			return nil, fmt.Errorf("%w: %s: unknown option %q", ErrInvalidTag, path, opt)
+		}
+	}
+
+	if sfield.Name == "_" || f.reserved {
+		if f.bits == 0 || f.signed || f.le || f.lenField >= 0 {
+			return nil, fmt.Errorf("%w: %s: a reserved field only takes a width", ErrInvalidTag, path)
+		}
+		f.reserved = true
+		return f, nil
+	}
+
+	switch t := sfield.Type; t.Kind() {
+	case reflect.Array, reflect.Slice:
+		if (t.Kind() == reflect.Slice) != (f.lenField >= 0) {
+			return nil, fmt.Errorf("%w: %s: slices, and only slices, need a length field", ErrInvalidTag, path)
+		}
+		elem := *f
+		elem.typ, elem.index, elem.lenField = t.Elem(), -1, -1
+		if err := compileValue(&elem, path+"[]", visiting); err != nil {
+			return nil, err
+		}
+		elem.minWidth = minWidth(&elem)
+		if t.Kind() == reflect.Slice && elem.minWidth == 0 && elem.typ.Size() != 0 {
+			// the length of such a slice is not bounded by the bits read, it could not be allocated safely
+			return nil, fmt.Errorf("%w: %s: elements of %s encode no bits but take memory", ErrUnsupportedType, path, elem.typ)
+		}
+		f.elem = &elem
+		f.bits, f.signed, f.le = 0, false, false
+		return f, nil
+	}
+	if f.lenField >= 0 {
+		return nil, fmt.Errorf("%w: %s: only slices take a length field", ErrInvalidTag, path)
+	}
+	return f, compileValue(f, path, visiting)
+}
+
+// minWidth returns the smallest number of bits of the encoded values of f, 0 for empty structs for instance.
+func minWidth(f *field) int {
+	switch {
+	case f.sub != nil:
+		n := 0
+		for _, sf := range f.sub.fields {
+			n += minWidth(sf)
+		}
+		return n
+	case f.elem != nil:
+		if f.typ.Kind() == reflect.Array {
+			return f.typ.Len() * minWidth(f.elem)
+		}
+		return 0
+	}
+	return f.bits
+}
+
+// compileValue checks the options of a boolean, integer or struct value and sets its default width.
+func compileValue(f *field, path string, visiting map[reflect.Type]bool) error {
+	t := f.typ
+	switch {
+	case t.Kind() == reflect.Struct:
+		if f.bits != 0 || f.signed || f.le {
+			return fmt.Errorf("%w: %s: a struct takes no width nor option", ErrInvalidTag, path)
+		}
+		sub, err := compileStruct(t, path, visiting)
+		f.sub = sub
+		return err
+
+	case t.Kind() == reflect.Bool:
+		if f.signed || f.le {
+			return fmt.Errorf("%w: %s: a bool takes no option", ErrInvalidTag, path)
+		}
+		if f.bits == 0 {
+			f.bits = 1
+		}
+		if f.bits > 64 {
+			return fmt.Errorf("%w: %s: width %d is larger than 64", ErrInvalidTag, path, f.bits)
+		}
+		return nil
+
+	case isInteger(t):
+		size := t.Bits()
+		if f.bits == 0 {
+			f.bits = size
+		}
+		if f.bits > size {
+			return fmt.Errorf("%w: %s: width %d is larger than the %d bits of %s", ErrInvalidTag, path, f.bits, size, t)
+		}
+		if f.le && f.bits%8 != 0 {
+			return fmt.Errorf("%w: %s: little-endian width %d is not a multiple of 8", ErrInvalidTag, path, f.bits)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %s", ErrUnsupportedType, path, t)
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package bitcodec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var typeOfHeader = reflect.TypeOf(header{})
+
+type node struct {
+	N        uint8
+	Children []node `bits:",len=N"`
+}
+
+func TestInvalidTags(t *testing.T) {
+	tests := []struct {
+		id  int
+		v   interface{}
+		err error
+	}{
+		{0, struct {
+			A uint8 `bits:"x"`
+		}{}, ErrInvalidTag},
+		{1, struct {
+			A uint8 `bits:"0"`
+		}{}, ErrInvalidTag},
+		{2, struct {
+			A uint8 `bits:"3,big"`
+		}{}, ErrInvalidTag},
+		{3, struct {
+			A uint16 `bits:"12,le"`
+		}{}, ErrInvalidTag},
+		{4, struct {
+			A bool `bits:"1,signed"`
+		}{}, ErrInvalidTag},
+		{5, struct {
+			S []uint8 `bits:"8,len=N"`
+			N uint8
+		}{}, ErrInvalidTag},
+		{6, struct {
+			N bool
+			S []uint8 `bits:"8,len=N"`
+		}{}, ErrInvalidTag},
+		{7, struct {
+			S []uint8 `bits:"8"`
+		}{}, ErrInvalidTag},
+		{8, struct {
+			N uint8
+			A uint8 `bits:"8,len=N"`
+		}{}, ErrInvalidTag},
+		{9, struct {
+			_ uint8
+		}{}, ErrInvalidTag},
+		{10, struct {
+			_ uint8 `bits:"3,signed"`
+		}{}, ErrInvalidTag},
+		{11, struct {
+			A inner `bits:"3"`
+		}{}, ErrInvalidTag},
+		{12, struct {
+			A string
+		}{}, ErrUnsupportedType},
+		{13, struct {
+			A [2][2]uint8
+		}{}, ErrUnsupportedType},
+		{14, node{}, ErrUnsupportedType},
+		{15, struct {
+			A bool `bits:"65"`
+		}{}, ErrInvalidTag},
+		{16, struct {
+			N     uint32
+			Items []struct {
+				X int `bits:"-"`
+			} `bits:",len=N"`
+		}{}, ErrUnsupportedType},
+	}
+
+	for _, test := range tests {
+		if _, err := Marshal(test.v); !errors.Is(err, test.err) {
+			t.Errorf("%d: Marshal returned %v, want %v", test.id, err, test.err)
+		}
+	}
+}
+
+func TestFieldsCache(t *testing.T) {
+	if _, err := Marshal(header{}); err != nil {
+		t.Fatal(err)
+	}
+	sf1, _ := cache.Load(typeOfHeader)
+	if _, err := Marshal(&header{}); err != nil {
+		t.Fatal(err)
+	}
+	sf2, _ := cache.Load(typeOfHeader)
+	if sf1 == nil || sf1 != sf2 {
+		t.Errorf("compiled struct was not cached")
+	}
+}
@@ -0,0 +1,72 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// And sets the receiving bit-array to the bitwise AND of itself and other.
+// Both bit-arrays must have the same length, otherwise And will panic.
+func (ba *BitArray) And(other *BitArray) {
+	ba.checkSameLen(other)
+	ba.beforeWrite(0, len(ba.data))
+	dst, src := ba.data, other.data
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)&binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for k := range dst {
+		dst[k] &= src[k]
+	}
+}
+
+// Or sets the receiving bit-array to the bitwise OR of itself and other.
+// Both bit-arrays must have the same length, otherwise Or will panic.
+func (ba *BitArray) Or(other *BitArray) {
+	ba.checkSameLen(other)
+	ba.beforeWrite(0, len(ba.data))
+	dst, src := ba.data, other.data
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)|binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for k := range dst {
+		dst[k] |= src[k]
+	}
+}
+
+// Xor sets the receiving bit-array to the bitwise XOR of itself and other.
+// Both bit-arrays must have the same length, otherwise Xor will panic.
+func (ba *BitArray) Xor(other *BitArray) {
+	ba.checkSameLen(other)
+	ba.beforeWrite(0, len(ba.data))
+	dst, src := ba.data, other.data
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)^binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for k := range dst {
+		dst[k] ^= src[k]
+	}
+}
+
+// AndNot clears in the receiving bit-array every bit that is set in other (bitwise AND NOT).
+// Both bit-arrays must have the same length, otherwise AndNot will panic.
+func (ba *BitArray) AndNot(other *BitArray) {
+	ba.checkSameLen(other)
+	ba.beforeWrite(0, len(ba.data))
+	dst, src := ba.data, other.data
+	for len(dst) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(dst)&^binary.LittleEndian.Uint64(src))
+		dst, src = dst[8:], src[8:]
+	}
+	for k := range dst {
+		dst[k] &^= src[k]
+	}
+}
+
+func (ba *BitArray) checkSameLen(other *BitArray) {
+	if ba.Len() != other.Len() {
+		panic(fmt.Sprintf("bit-arrays should have the same length; given %d and %d", ba.Len(), other.Len()))
+	}
+}
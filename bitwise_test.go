@@ -0,0 +1,66 @@
+package bitarray
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBitwise(t *testing.T) {
+	tests := []struct {
+		id     int
+		a      string
+		b      string
+		and    string
+		or     string
+		xor    string
+		andNot string
+	}{
+		{0, "", "", "", "", "", ""},
+		{1, "1", "0", "0", "1", "1", "1"},
+		{2, "1100", "1010", "1000", "1110", "0110", "0100"},
+		{
+			3,
+			"110111101010110110111110111011110000110111101010110110111110111011110000",
+			"000100000010101100110100100101011010000001110010011111010101011110101010",
+			"000100000010100100110100100001010000000001100010010110010100011010100000",
+			"110111101010111110111110111111111010110111111010111111111111111111111010",
+			"110011101000011010001010011110101010110110011000101001101011100101011010",
+			"110011101000010010001010011010100000110110001000100000101010100001010000",
+		},
+	}
+
+	for _, test := range tests {
+		for _, op := range []struct {
+			name string
+			f    func(ba, other *BitArray)
+			want string
+		}{
+			{"And", (*BitArray).And, test.and},
+			{"Or", (*BitArray).Or, test.or},
+			{"Xor", (*BitArray).Xor, test.xor},
+			{"AndNot", (*BitArray).AndNot, test.andNot},
+		} {
+			ba, other, want := New(), New(), New()
+			ba.AppendString(test.a)
+			other.AppendString(test.b)
+			want.AppendString(op.want)
+
+			op.f(ba, other)
+			if !bytes.Equal(ba.Bytes(), want.Bytes()) || ba.Len() != want.Len() {
+				t.Errorf("%d: %s returned bad data %s, want %s", test.id, op.name, fmt.Sprintf("%#X", ba.Bytes()), fmt.Sprintf("%#X", want.Bytes()))
+			}
+		}
+	}
+}
+
+func TestBitwiseLengthMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("And did not panic on bit-arrays of different lengths")
+		}
+	}()
+
+	ba, other := NewZeros(8), NewZeros(9)
+	ba.And(other)
+}
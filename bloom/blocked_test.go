@@ -0,0 +1,152 @@
+package bloom
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBlockedFilter(t *testing.T) {
+	tests := []struct {
+		id     int
+		n      int
+		fpRate float64
+	}{
+		{0, 1000, 0.01},
+		{1, 10000, 0.01},
+		{2, 5000, 0.1},
+	}
+
+	for _, test := range tests {
+		f := NewBlocked(test.n, test.fpRate)
+		if f.M()%blockBits != 0 {
+			t.Errorf("%d: M returned %d, want a multiple of %d", test.id, f.M(), blockBits)
+		}
+		for i := 0; i < test.n; i++ {
+			f.Add(key(i))
+		}
+		for i := 0; i < test.n; i++ {
+			if !f.Test(key(i)) {
+				t.Fatalf("%d: Test returned false for added key %d", test.id, i)
+			}
+		}
+
+		falsePositives := 0
+		const queries = 100000
+		for i := test.n; i < test.n+queries; i++ {
+			if f.Test(key(i)) {
+				falsePositives++
+			}
+		}
+		// blocking costs a bit of accuracy
+		if rate := float64(falsePositives) / queries; rate > 2*test.fpRate {
+			t.Errorf("%d: false positive rate is %g, want at most %g", test.id, rate, 2*test.fpRate)
+		}
+
+		if c := f.EstimatedCount(); math.Abs(float64(c)-float64(test.n)) > 0.05*float64(test.n) {
+			t.Errorf("%d: EstimatedCount returned %d, want about %d", test.id, c, test.n)
+		}
+	}
+}
+
+func TestBlockedFilterSingleBlock(t *testing.T) {
+	f := NewBlockedWithParameters(4*blockBits, 8)
+	f.Add([]byte("key"))
+
+	blocks := map[int]int{}
+	for i := 0; i < f.M(); i++ {
+		if f.bits.GetBit(i) == 1 {
+			blocks[i/blockBits]++
+		}
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("a key set bits in %d blocks, want 1", len(blocks))
+	}
+	for _, count := range blocks {
+		if count != 8 {
+			t.Errorf("a key set %d bits, want 8", count)
+		}
+	}
+}
+
+func TestBlockedFilterTestAndAdd(t *testing.T) {
+	f := NewBlocked(100, 0.01)
+	for i := 0; i < 100; i++ {
+		if f.TestAndAdd(key(i)) {
+			t.Errorf("TestAndAdd returned true for new key %d", i)
+		}
+		if !f.TestAndAdd(key(i)) {
+			t.Errorf("TestAndAdd returned false for added key %d", i)
+		}
+	}
+
+	f.ClearAll()
+	if f.Test(key(0)) || f.EstimatedCount() != 0 {
+		t.Errorf("ClearAll did not remove the keys")
+	}
+}
+
+func TestBlockedFilterUnionIntersect(t *testing.T) {
+	f1, f2 := NewBlocked(1000, 0.01), NewBlocked(1000, 0.01)
+	for i := 0; i < 600; i++ {
+		f1.Add(key(i))
+	}
+	for i := 400; i < 1000; i++ {
+		f2.Add(key(i))
+	}
+
+	union := NewBlocked(1000, 0.01)
+	if err := union.Union(f1); err != nil {
+		t.Fatal(err)
+	}
+	if err := union.Union(f2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !union.Test(key(i)) {
+			t.Fatalf("union: Test returned false for added key %d", i)
+		}
+	}
+
+	if err := f1.Intersect(f2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 400; i < 600; i++ {
+		if !f1.Test(key(i)) {
+			t.Fatalf("intersection: Test returned false for common key %d", i)
+		}
+	}
+
+	if err := f1.Union(NewBlocked(10000, 0.01)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Union of filters with different parameters returned %v, want %v", err, ErrIncompatible)
+	}
+}
+
+func TestNewBlockedWithParameters(t *testing.T) {
+	tests := []struct {
+		id   int
+		m, k int
+		want int
+	}{
+		{0, 1, 1, 512},
+		{1, 512, 3, 512},
+		{2, 513, 3, 1024},
+	}
+
+	for _, test := range tests {
+		if m := NewBlockedWithParameters(test.m, test.k).M(); m != test.want {
+			t.Errorf("%d: M returned %d, want %d", test.id, m, test.want)
+		}
+	}
+
+	for id, k := range []int{0, blockBits + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: NewBlockedWithParameters did not panic for k=%d", id, k)
+				}
+			}()
+			NewBlockedWithParameters(blockBits, k)
+		}()
+	}
+}
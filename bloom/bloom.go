@@ -0,0 +1,199 @@
+// Package bloom implements Bloom filters, probabilistic sets answering membership queries
+// with no false negatives and a tunable rate of false positives, stored in bit-arrays.
+//
+// The k positions of a key are derived by double hashing (Kirsch and Mitzenmacher):
+// the two halves h1 and h2 of the 128-bit FNV-1a hash of the key, each one mixed with the finalizer of MurmurHash3
+// as FNV spreads poorly its input over the low bits, give the positions (h1 + i*h2) mod m for i < k.
+// The hash being fixed, filters built in one process can be serialized and queried in another.
+//
+// Besides the standard Filter, BlockedFilter confines the positions of a key to a single 512-bit block
+// (one cache miss per query) and CountingFilter stores 4-bit counters to support removals.
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// ErrIncompatible is returned when combining filters with different parameters.
+var ErrIncompatible = errors.New("bloom: incompatible filters")
+
+// maxHashes is the largest number of hash functions of a Filter or a CountingFilter,
+// far more than the optimal number for any practical false positive rate.
+const maxHashes = 64
+
+// Interface is implemented by all the filters of the package.
+type Interface interface {
+	// Add adds key to the filter.
+	Add(key []byte)
+	// Test returns whether key may be in the filter. A false result means that key is not in the filter.
+	Test(key []byte) bool
+	// TestAndAdd adds key to the filter and returns whether it may have been in the filter before.
+	TestAndAdd(key []byte) bool
+	// EstimatedCount returns an estimation of the number of distinct keys in the filter.
+	EstimatedCount() uint64
+	// M returns the number of positions (bits or counters) of the filter.
+	M() int
+	// K returns the number of hash functions of the filter.
+	K() int
+}
+
+// Filter is a standard Bloom filter of m bits and k hash functions.
+type Filter struct {
+	m    int
+	k    int
+	bits *bitarray.BitArray
+}
+
+// New returns an empty filter sized to hold n items with a false positive rate of at most fpRate.
+// It will panic if fpRate is not strictly between 0 and 1.
+func New(n int, fpRate float64) *Filter {
+	m, k := EstimateParameters(n, fpRate)
+	return NewWithParameters(m, k)
+}
+
+// NewWithParameters returns an empty filter of m bits using k hash functions.
+// It will panic if m is not positive or if k is not between 1 and the smaller of m and 64.
+func NewWithParameters(m, k int) *Filter {
+	if m <= 0 || k <= 0 || k > m || k > maxHashes {
+		panic(fmt.Sprintf("m should be positive and k between 1 and min(m, %d); given m=%d and k=%d", maxHashes, m, k))
+	}
+	return &Filter{m: m, k: k, bits: bitarray.NewZeros(m)}
+}
+
+// EstimateParameters returns the number of bits m and of hash functions k minimizing the size of a filter
+// holding n items with a false positive rate of at most fpRate:
+// m = ceil(-n*ln(fpRate) / ln(2)^2) and k = round(m/n * ln(2)).
+// It will panic if fpRate is not strictly between 0 and 1.
+func EstimateParameters(n int, fpRate float64) (m, k int) {
+	if !(fpRate > 0 && fpRate < 1) {
+		panic(fmt.Sprintf("false positive rate should be between 0 and 1 (excluded); given %g", fpRate))
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	m = int(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return m, k
+}
+
+// M returns the number of bits of the filter.
+func (f *Filter) M() int {
+	return f.m
+}
+
+// K returns the number of hash functions of the filter.
+func (f *Filter) K() int {
+	return f.k
+}
+
+// hash returns the two mixed 64-bit halves of the 128-bit FNV-1a hash of key.
+func hash(key []byte) (h1, h2 uint64) {
+	h := fnv.New128a()
+	h.Write(key)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	return fmix64(binary.BigEndian.Uint64(sum[:8])), fmix64(binary.BigEndian.Uint64(sum[8:]))
+}
+
+// fmix64 is the 64-bit finalizer of MurmurHash3, every bit of the input affects every bit of the output.
+func fmix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// location returns the i-th position of a key of hashes h1 and h2 among m positions.
+func location(h1, h2 uint64, i, m int) int {
+	return int((h1 + uint64(i)*h2) % uint64(m))
+}
+
+// Add adds key to the filter.
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hash(key)
+	for i := 0; i < f.k; i++ {
+		f.bits.SetBit(location(h1, h2, i, f.m))
+	}
+}
+
+// Test returns whether key may be in the filter. A false result means that key was never added.
+func (f *Filter) Test(key []byte) bool {
+	h1, h2 := hash(key)
+	for i := 0; i < f.k; i++ {
+		if f.bits.GetBit(location(h1, h2, i, f.m)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAndAdd adds key to the filter and returns whether it may have been in the filter before.
+func (f *Filter) TestAndAdd(key []byte) bool {
+	h1, h2 := hash(key)
+	present := true
+	for i := 0; i < f.k; i++ {
+		l := location(h1, h2, i, f.m)
+		if f.bits.GetBit(l) == 0 {
+			present = false
+			f.bits.SetBit(l)
+		}
+	}
+	return present
+}
+
+// Union adds to f all the keys of other. Both filters must have the same parameters.
+func (f *Filter) Union(other *Filter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	f.bits.Or(other.bits)
+	return nil
+}
+
+// Intersect keeps in f only the keys also in other. Both filters must have the same parameters.
+// The result may have more false positives than a filter built from the keys of the intersection.
+func (f *Filter) Intersect(other *Filter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	f.bits.And(other.bits)
+	return nil
+}
+
+func (f *Filter) checkCompatible(other *Filter) error {
+	if f.m != other.m || f.k != other.k {
+		return fmt.Errorf("%w: m=%d, k=%d and m=%d, k=%d", ErrIncompatible, f.m, f.k, other.m, other.k)
+	}
+	return nil
+}
+
+// EstimatedCount returns an estimation of the number of distinct keys added to the filter
+// computed from the number X of bits set: -m/k * ln(1 - X/m) (Swamidass and Baldi).
+// The estimation is meaningless once all the bits are set and EstimatedCount then returns math.MaxUint64.
+func (f *Filter) EstimatedCount() uint64 {
+	return estimateCount(f.bits.Count(), f.m, f.k)
+}
+
+func estimateCount(ones, m, k int) uint64 {
+	if ones >= m {
+		return math.MaxUint64
+	}
+	return uint64(math.Round(-float64(m) / float64(k) * math.Log1p(-float64(ones)/float64(m))))
+}
+
+// ClearAll removes all the keys of the filter.
+func (f *Filter) ClearAll() {
+	f.bits = bitarray.NewZeros(f.m)
+}
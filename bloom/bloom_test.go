@@ -0,0 +1,172 @@
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func key(i int) []byte {
+	return []byte(fmt.Sprintf("key-%d", i))
+}
+
+func TestEstimateParameters(t *testing.T) {
+	tests := []struct {
+		id     int
+		n      int
+		fpRate float64
+		m, k   int
+	}{
+		{0, 1000, 0.01, 9586, 7},
+		{1, 1000, 0.001, 14378, 10},
+		{2, 1000000, 0.01, 9585059, 7},
+		{3, 100, 0.5, 145, 1},
+		{4, 0, 0.01, 10, 7},
+	}
+
+	for _, test := range tests {
+		m, k := EstimateParameters(test.n, test.fpRate)
+		if m != test.m || k != test.k {
+			t.Errorf("%d: EstimateParameters returned m=%d, k=%d, want m=%d, k=%d", test.id, m, k, test.m, test.k)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		id     int
+		n      int
+		fpRate float64
+	}{
+		{0, 1000, 0.01},
+		{1, 10000, 0.001},
+		{2, 5000, 0.1},
+	}
+
+	for _, test := range tests {
+		f := New(test.n, test.fpRate)
+		for i := 0; i < test.n; i++ {
+			f.Add(key(i))
+		}
+		for i := 0; i < test.n; i++ {
+			if !f.Test(key(i)) {
+				t.Fatalf("%d: Test returned false for added key %d", test.id, i)
+			}
+		}
+
+		falsePositives := 0
+		const queries = 100000
+		for i := test.n; i < test.n+queries; i++ {
+			if f.Test(key(i)) {
+				falsePositives++
+			}
+		}
+		if rate := float64(falsePositives) / queries; rate > 1.5*test.fpRate {
+			t.Errorf("%d: false positive rate is %g, want at most %g", test.id, rate, test.fpRate)
+		}
+
+		if c := f.EstimatedCount(); math.Abs(float64(c)-float64(test.n)) > 0.05*float64(test.n) {
+			t.Errorf("%d: EstimatedCount returned %d, want about %d", test.id, c, test.n)
+		}
+	}
+}
+
+func TestTestAndAdd(t *testing.T) {
+	f := New(100, 0.01)
+	for i := 0; i < 100; i++ {
+		if f.TestAndAdd(key(i)) {
+			t.Errorf("TestAndAdd returned true for new key %d", i)
+		}
+		if !f.TestAndAdd(key(i)) {
+			t.Errorf("TestAndAdd returned false for added key %d", i)
+		}
+	}
+	if c := f.EstimatedCount(); c < 95 || c > 105 {
+		t.Errorf("EstimatedCount returned %d, want about 100", c)
+	}
+}
+
+func TestUnionIntersect(t *testing.T) {
+	f1, f2 := New(1000, 0.01), New(1000, 0.01)
+	for i := 0; i < 600; i++ {
+		f1.Add(key(i))
+	}
+	for i := 400; i < 1000; i++ {
+		f2.Add(key(i))
+	}
+
+	union := New(1000, 0.01)
+	if err := union.Union(f1); err != nil {
+		t.Fatal(err)
+	}
+	if err := union.Union(f2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !union.Test(key(i)) {
+			t.Fatalf("union: Test returned false for added key %d", i)
+		}
+	}
+
+	if err := f1.Intersect(f2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 400; i < 600; i++ {
+		if !f1.Test(key(i)) {
+			t.Fatalf("intersection: Test returned false for common key %d", i)
+		}
+	}
+	if c := f1.EstimatedCount(); c < 150 || c > 300 {
+		t.Errorf("intersection: EstimatedCount returned %d, want about 200", c)
+	}
+
+	if err := f1.Union(New(2000, 0.01)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Union of filters with different parameters returned %v, want %v", err, ErrIncompatible)
+	}
+	if err := f1.Intersect(NewWithParameters(f1.M(), f1.K()+1)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Intersect of filters with different parameters returned %v, want %v", err, ErrIncompatible)
+	}
+}
+
+func TestEstimatedCountFull(t *testing.T) {
+	f := NewWithParameters(8, 1)
+	for i := 0; f.bits.Count() < 8; i++ {
+		f.Add(key(i))
+	}
+	if c := f.EstimatedCount(); c != math.MaxUint64 {
+		t.Errorf("EstimatedCount of a full filter returned %d, want %d", c, uint64(math.MaxUint64))
+	}
+
+	f.ClearAll()
+	if c := f.EstimatedCount(); c != 0 {
+		t.Errorf("EstimatedCount of a cleared filter returned %d, want 0", c)
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	tests := []struct {
+		id int
+		f  func()
+	}{
+		{0, func() { New(10, 0) }},
+		{1, func() { New(10, 1) }},
+		{2, func() { New(10, math.NaN()) }},
+		{3, func() { NewWithParameters(0, 1) }},
+		{4, func() { NewWithParameters(10, 0) }},
+		{5, func() { NewWithParameters(10, 11) }},
+		{6, func() { NewWithParameters(1000, 65) }},
+		{7, func() { NewCountingWithParameters(1000, 65) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", test.id)
+				}
+			}()
+			test.f()
+		}()
+	}
+}
@@ -0,0 +1,166 @@
+package bloom
+
+import (
+	"fmt"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+const (
+	counterBits = 4
+	// maxCount is the value at which counters saturate.
+	maxCount = 1<<counterBits - 1
+)
+
+// CountingFilter is a Bloom filter storing a 4-bit counter instead of a bit at each position, which allows removals.
+// Positions are the ones of a standard filter with the same parameters.
+// Counters saturate at 15: a saturated counter is never incremented nor decremented anymore,
+// as its actual value is unknown, so that removals never introduce false negatives.
+type CountingFilter struct {
+	m        int
+	k        int
+	counters *bitarray.PackedInts
+}
+
+// NewCounting returns an empty counting filter sized to hold n items with a false positive rate of at most fpRate.
+// It will panic if fpRate is not strictly between 0 and 1.
+func NewCounting(n int, fpRate float64) *CountingFilter {
+	m, k := EstimateParameters(n, fpRate)
+	return NewCountingWithParameters(m, k)
+}
+
+// NewCountingWithParameters returns an empty counting filter of m counters using k hash functions.
+// It will panic if m is not positive or if k is not between 1 and the smaller of m and 64.
+func NewCountingWithParameters(m, k int) *CountingFilter {
+	if m <= 0 || k <= 0 || k > m || k > maxHashes {
+		panic(fmt.Sprintf("m should be positive and k between 1 and min(m, %d); given m=%d and k=%d", maxHashes, m, k))
+	}
+	return &CountingFilter{m: m, k: k, counters: bitarray.NewPackedInts(counterBits, m)}
+}
+
+// M returns the number of counters of the filter.
+func (f *CountingFilter) M() int {
+	return f.m
+}
+
+// K returns the number of hash functions of the filter.
+func (f *CountingFilter) K() int {
+	return f.k
+}
+
+func (f *CountingFilter) increment(l int) {
+	if c := f.counters.Get(l); c < maxCount {
+		f.counters.Set(l, c+1)
+	}
+}
+
+// Add adds key to the filter. A key can be added several times and must then be removed as many times.
+func (f *CountingFilter) Add(key []byte) {
+	h1, h2 := hash(key)
+	for i := 0; i < f.k; i++ {
+		f.increment(location(h1, h2, i, f.m))
+	}
+}
+
+// Test returns whether key may be in the filter. A false result means that key is not in the filter.
+func (f *CountingFilter) Test(key []byte) bool {
+	h1, h2 := hash(key)
+	for i := 0; i < f.k; i++ {
+		if f.counters.Get(location(h1, h2, i, f.m)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAndAdd adds key to the filter and returns whether it may have been in the filter before.
+func (f *CountingFilter) TestAndAdd(key []byte) bool {
+	present := f.Test(key)
+	f.Add(key)
+	return present
+}
+
+// Remove removes key from the filter and returns true, or returns false without changing the filter
+// if key is not in the filter. Removing a key that was never added, but tests positive, removes other keys.
+func (f *CountingFilter) Remove(key []byte) bool {
+	if !f.Test(key) {
+		return false
+	}
+
+	h1, h2 := hash(key)
+	for i := 0; i < f.k; i++ {
+		l := location(h1, h2, i, f.m)
+		// the same position can be drawn twice, it may already have been decremented to 0
+		if c := f.counters.Get(l); c > 0 && c < maxCount {
+			f.counters.Set(l, c-1)
+		}
+	}
+	return true
+}
+
+// EstimatedCount returns an estimation of the number of distinct keys in the filter,
+// computed as for a standard filter from the number of non-zero counters.
+func (f *CountingFilter) EstimatedCount() uint64 {
+	nonZero := 0
+	for i := 0; i < f.m; i++ {
+		if f.counters.Get(i) != 0 {
+			nonZero++
+		}
+	}
+	return estimateCount(nonZero, f.m, f.k)
+}
+
+// ClearAll removes all the keys of the filter.
+func (f *CountingFilter) ClearAll() {
+	f.counters = bitarray.NewPackedInts(counterBits, f.m)
+}
+
+// Union adds to f the keys of other. Both filters must have the same parameters.
+// Counters are summed, saturating at 15, rather than maxed: a key of f and a key of other
+// sharing a position must both keep it set when one of them is removed.
+func (f *CountingFilter) Union(other *CountingFilter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	for i := 0; i < f.m; i++ {
+		c := f.counters.Get(i) + other.counters.Get(i)
+		if c > maxCount {
+			c = maxCount
+		}
+		f.counters.Set(i, c)
+	}
+	return nil
+}
+
+// Intersect keeps in f only the keys also in other. Both filters must have the same parameters.
+// Each counter becomes the minimum of both counters, which still counts the keys of the intersection.
+// The result may have more false positives than a filter built from the keys of the intersection.
+func (f *CountingFilter) Intersect(other *CountingFilter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	for i := 0; i < f.m; i++ {
+		if c := other.counters.Get(i); c < f.counters.Get(i) {
+			f.counters.Set(i, c)
+		}
+	}
+	return nil
+}
+
+func (f *CountingFilter) checkCompatible(other *CountingFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return fmt.Errorf("%w: m=%d, k=%d and m=%d, k=%d", ErrIncompatible, f.m, f.k, other.m, other.k)
+	}
+	return nil
+}
+
+// Filter returns the standard filter made of the positions of the non-zero counters.
+func (f *CountingFilter) Filter() *Filter {
+	filter := NewWithParameters(f.m, f.k)
+	for i := 0; i < f.m; i++ {
+		if f.counters.Get(i) != 0 {
+			filter.bits.SetBit(i)
+		}
+	}
+	return filter
+}
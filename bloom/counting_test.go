@@ -0,0 +1,151 @@
+package bloom
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var (
+	_ Interface = (*Filter)(nil)
+	_ Interface = (*BlockedFilter)(nil)
+	_ Interface = (*CountingFilter)(nil)
+)
+
+func TestCountingFilter(t *testing.T) {
+	const n = 2000
+	f := NewCounting(n, 0.01)
+	for i := 0; i < n; i++ {
+		f.Add(key(i))
+	}
+	if c := f.EstimatedCount(); math.Abs(float64(c)-n) > 0.05*n {
+		t.Errorf("EstimatedCount returned %d, want about %d", c, n)
+	}
+
+	for i := 0; i < n; i += 2 {
+		if !f.Remove(key(i)) {
+			t.Fatalf("Remove returned false for added key %d", i)
+		}
+	}
+	for i := 1; i < n; i += 2 {
+		if !f.Test(key(i)) {
+			t.Fatalf("Test returned false for key %d after removing other keys", i)
+		}
+	}
+
+	stillPresent := 0
+	for i := 0; i < n; i += 2 {
+		if f.Test(key(i)) {
+			stillPresent++
+		}
+	}
+	if stillPresent > n/50 {
+		t.Errorf("%d removed keys still test positive, want at most %d", stillPresent, n/50)
+	}
+	if c := f.EstimatedCount(); math.Abs(float64(c)-n/2) > 0.05*n/2 {
+		t.Errorf("EstimatedCount returned %d after removals, want about %d", c, n/2)
+	}
+
+	if !f.Filter().Test(key(1)) || f.Filter().M() != f.M() || f.Filter().K() != f.K() {
+		t.Errorf("Filter returned a standard filter different from the counting one")
+	}
+}
+
+func TestCountingFilterMultiset(t *testing.T) {
+	f := NewCountingWithParameters(1000, 3)
+	for j := 0; j < 3; j++ {
+		if got := f.TestAndAdd([]byte("key")); got != (j > 0) {
+			t.Errorf("%d: TestAndAdd returned %t, want %t", j, got, j > 0)
+		}
+	}
+	for j := 0; j < 3; j++ {
+		if !f.Remove([]byte("key")) {
+			t.Fatalf("%d: Remove returned false for a key added 3 times", j)
+		}
+	}
+	if f.Test([]byte("key")) || f.Remove([]byte("key")) {
+		t.Errorf("key is still in the filter after being removed as many times as it was added")
+	}
+}
+
+func TestCountingFilterSaturation(t *testing.T) {
+	f := NewCountingWithParameters(100, 2)
+	for j := 0; j < 20; j++ {
+		f.Add([]byte("key"))
+	}
+	h1, h2 := hash([]byte("key"))
+	for i := 0; i < f.K(); i++ {
+		if c := f.counters.Get(location(h1, h2, i, f.M())); c != maxCount {
+			t.Fatalf("counter %d is %d, want %d", i, c, maxCount)
+		}
+	}
+
+	// saturated counters are sticky: the key can not be removed anymore
+	for j := 0; j < 20; j++ {
+		f.Remove([]byte("key"))
+	}
+	if !f.Test([]byte("key")) {
+		t.Errorf("Test returned false after removing a key with saturated counters")
+	}
+
+	f.ClearAll()
+	if f.Test([]byte("key")) {
+		t.Errorf("Test returned true after ClearAll")
+	}
+}
+
+func TestCountingUnionIntersect(t *testing.T) {
+	newFilter := func(from, to int) *CountingFilter {
+		f := NewCounting(1000, 0.01)
+		for i := from; i < to; i++ {
+			f.Add(key(i))
+		}
+		return f
+	}
+
+	// removing the keys of one filter keeps the keys of the other one
+	union := newFilter(0, 600)
+	if err := union.Union(newFilter(400, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 400; i++ {
+		union.Remove(key(i))
+	}
+	for i := 400; i < 1000; i++ {
+		if !union.Test(key(i)) {
+			t.Fatalf("union: Test returned false for added key %d", i)
+		}
+	}
+
+	intersection := newFilter(0, 600)
+	if err := intersection.Intersect(newFilter(400, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 400; i < 500; i++ {
+		intersection.Remove(key(i))
+	}
+	for i := 500; i < 600; i++ {
+		if !intersection.Test(key(i)) {
+			t.Fatalf("intersection: Test returned false for common key %d", i)
+		}
+	}
+
+	// counters saturate
+	f, g := NewCountingWithParameters(10, 1), NewCountingWithParameters(10, 1)
+	f.counters.Set(0, 10)
+	g.counters.Set(0, 10)
+	g.counters.Set(1, 3)
+	if err := f.Union(g); err != nil || f.counters.Get(0) != maxCount || f.counters.Get(1) != 3 {
+		t.Errorf("Union returned counters (%d, %d), want (%d, 3)", f.counters.Get(0), f.counters.Get(1), maxCount)
+	}
+	if err := f.Intersect(NewCountingWithParameters(10, 1)); err != nil || f.EstimatedCount() != 0 {
+		t.Errorf("Intersect with an empty filter did not empty the filter")
+	}
+
+	if err := f.Union(NewCountingWithParameters(20, 1)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Union of filters with different parameters returned %v, want %v", err, ErrIncompatible)
+	}
+	if err := f.Intersect(NewCountingWithParameters(10, 2)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Intersect of filters with different parameters returned %v, want %v", err, ErrIncompatible)
+	}
+}
@@ -0,0 +1,246 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// The serialized form of a filter is made of big-endian integers:
+//	- 4 bytes: the magic, "BLMF" for a Filter, "BLMB" for a BlockedFilter and "BLMC" for a CountingFilter,
+//	- uint64: the number of positions m,
+//	- uint32: the number of hash functions k,
+//	- for a Filter or a BlockedFilter, ceil(m/8) bytes: the bits, bit i being the bit 7 - i%8
+//	  (starting from the least significant bit) of the byte i/8,
+//	- for a CountingFilter, ceil(m/2) bytes: the counters, counter i being the high 4 bits of the byte i/2 if i is even
+//	  and its low 4 bits otherwise.
+// Padding bits of the last byte are 0's.
+
+const (
+	filterMagic         = "BLMF"
+	blockedFilterMagic  = "BLMB"
+	countingFilterMagic = "BLMC"
+	headerSize          = 4 + 8 + 4
+)
+
+// ErrInvalidFormat is returned when reading a malformed serialized filter.
+var ErrInvalidFormat = errors.New("bloom: invalid format")
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (f *Filter) SerializedSizeInBytes() int {
+	return headerSize + (f.m+7)/8
+}
+
+// WriteTo writes the filter to w.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	return writeFilter(w, filterMagic, f.m, f.k, f.bits.Bytes())
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	return marshal(f)
+}
+
+// ReadFrom replaces the filter by the one read from r.
+// It reads exactly the bytes of one filter, so filters stored back to back can be read with successive calls.
+func (f *Filter) ReadFrom(r io.Reader) (int64, error) {
+	m, k, read, err := readHeader(r, filterMagic, maxHashes)
+	if err != nil {
+		return read, err
+	}
+
+	bits, n, err := readBits(r, m)
+	read += n
+	if err != nil {
+		return read, err
+	}
+
+	f.m, f.k, f.bits = m, k, bits
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	return unmarshal(f, data)
+}
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (f *BlockedFilter) SerializedSizeInBytes() int {
+	return headerSize + f.m/8
+}
+
+// WriteTo writes the filter to w.
+func (f *BlockedFilter) WriteTo(w io.Writer) (int64, error) {
+	return writeFilter(w, blockedFilterMagic, f.m, f.k, f.bits.Bytes())
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *BlockedFilter) MarshalBinary() ([]byte, error) {
+	return marshal(f)
+}
+
+// ReadFrom replaces the filter by the one read from r.
+// It reads exactly the bytes of one filter, so filters stored back to back can be read with successive calls.
+func (f *BlockedFilter) ReadFrom(r io.Reader) (int64, error) {
+	m, k, read, err := readHeader(r, blockedFilterMagic, blockBits)
+	if err != nil {
+		return read, err
+	}
+	if m%blockBits != 0 {
+		return read, fmt.Errorf("%w: m=%d, k=%d for a blocked filter", ErrInvalidFormat, m, k)
+	}
+
+	bits, n, err := readBits(r, m)
+	read += n
+	if err != nil {
+		return read, err
+	}
+
+	f.m, f.k, f.bits = m, k, bits
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *BlockedFilter) UnmarshalBinary(data []byte) error {
+	return unmarshal(f, data)
+}
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (f *CountingFilter) SerializedSizeInBytes() int {
+	return headerSize + (f.m+1)/2
+}
+
+// WriteTo writes the filter to w.
+func (f *CountingFilter) WriteTo(w io.Writer) (int64, error) {
+	data := make([]byte, (f.m+1)/2)
+	for i := 0; i < f.m; i++ {
+		data[i/2] |= byte(f.counters.Get(i)) << uint(4*(1-i%2))
+	}
+	return writeFilter(w, countingFilterMagic, f.m, f.k, data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *CountingFilter) MarshalBinary() ([]byte, error) {
+	return marshal(f)
+}
+
+// ReadFrom replaces the filter by the one read from r.
+// It reads exactly the bytes of one filter, so filters stored back to back can be read with successive calls.
+func (f *CountingFilter) ReadFrom(r io.Reader) (int64, error) {
+	m, k, read, err := readHeader(r, countingFilterMagic, maxHashes)
+	if err != nil {
+		return read, err
+	}
+
+	data, n, err := readBytes(r, (m+1)/2)
+	read += n
+	if err != nil {
+		return read, err
+	}
+	if m%2 == 1 && data[len(data)-1]&0x0F != 0 {
+		return read, fmt.Errorf("%w: padding bits are not 0", ErrInvalidFormat)
+	}
+
+	counters := bitarray.NewPackedInts(counterBits, m)
+	for i := 0; i < m; i++ {
+		counters.Set(i, uint64(data[i/2]>>uint(4*(1-i%2))&0x0F))
+	}
+
+	f.m, f.k, f.counters = m, k, counters
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *CountingFilter) UnmarshalBinary(data []byte) error {
+	return unmarshal(f, data)
+}
+
+func writeFilter(w io.Writer, magic string, m, k int, data []byte) (int64, error) {
+	buf := make([]byte, headerSize, headerSize+len(data))
+	copy(buf, magic)
+	binary.BigEndian.PutUint64(buf[4:], uint64(m))
+	binary.BigEndian.PutUint32(buf[12:], uint32(k))
+	buf = append(buf, data...)
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// readHeader reads the header of a filter and checks its magic and parameters:
+// m must be positive and k between 1 and the smaller of m and maxK.
+func readHeader(r io.Reader, magic string, maxK int) (m, k int, read int64, err error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(r, header[:])
+	read = int64(n)
+	if err != nil {
+		return 0, 0, read, unexpectedEOF(err)
+	}
+
+	if string(header[:4]) != magic {
+		return 0, 0, read, fmt.Errorf("%w: bad magic %q, want %q", ErrInvalidFormat, header[:4], magic)
+	}
+	m64 := binary.BigEndian.Uint64(header[4:])
+	k32 := binary.BigEndian.Uint32(header[12:])
+	if m64 == 0 || m64 > math.MaxInt32*8 || k32 == 0 || uint64(k32) > m64 || k32 > uint32(maxK) {
+		return 0, 0, read, fmt.Errorf("%w: m=%d, k=%d", ErrInvalidFormat, m64, k32)
+	}
+	return int(m64), int(k32), read, nil
+}
+
+// readBits reads a bit-array of m bits stored on ceil(m/8) bytes with 0 padding bits.
+func readBits(r io.Reader, m int) (*bitarray.BitArray, int64, error) {
+	data, n, err := readBytes(r, (m+7)/8)
+	if err != nil {
+		return nil, n, err
+	}
+
+	padding := len(data)*8 - m
+	if data[len(data)-1]&(1<<uint(padding)-1) != 0 {
+		return nil, n, fmt.Errorf("%w: padding bits are not 0", ErrInvalidFormat)
+	}
+	bits := bitarray.New()
+	bits.AppendBytes(data, padding)
+	return bits, n, nil
+}
+
+// readBytes reads exactly n bytes from r. As n comes from an untrusted header,
+// the buffer grows as bytes arrive instead of being allocated upfront.
+func readBytes(r io.Reader, n int) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	read, err := io.CopyN(&buf, r, int64(n))
+	if err != nil {
+		return nil, read, unexpectedEOF(err)
+	}
+	return buf.Bytes(), read, nil
+}
+
+func marshal(f io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshal(f io.ReaderFrom, data []byte) error {
+	n, err := f.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if n != int64(len(data)) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, int64(len(data))-n)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"testing"
+)
+
+// The serialized form must not change between versions as filters are shared between processes.
+func TestMarshalBinary(t *testing.T) {
+	f := NewWithParameters(20, 3)
+	f.Add([]byte("hello"))
+	f.Add([]byte("world"))
+
+	want := []byte{
+		0x42, 0x4C, 0x4D, 0x46, // magic
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x14, // m = 20
+		0x00, 0x00, 0x00, 0x03, // k = 3
+		0x01, 0x05, 0x80, // bits 7, 13, 15 and 16
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("MarshalBinary returned bad data %x, want %x", data, want)
+	}
+	if len(data) != f.SerializedSizeInBytes() {
+		t.Errorf("SerializedSizeInBytes returned %d, want %d", f.SerializedSizeInBytes(), len(data))
+	}
+
+	g := New(1, 0.5)
+	if err := g.UnmarshalBinary(want); err != nil {
+		t.Fatal(err)
+	}
+	if g.M() != 20 || g.K() != 3 || !g.Test([]byte("hello")) || !g.Test([]byte("world")) {
+		t.Errorf("UnmarshalBinary returned a filter with m=%d, k=%d not containing the added keys", g.M(), g.K())
+	}
+}
+
+func TestReadFromBackToBack(t *testing.T) {
+	filters := []*Filter{New(1000, 0.01), New(10, 0.1), NewWithParameters(1, 1)}
+	var buf bytes.Buffer
+	for i, f := range filters {
+		for j := 0; j < 10*i; j++ {
+			f.Add(key(j))
+		}
+		if _, err := f.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, f := range filters {
+		g := &Filter{}
+		n, err := g.ReadFrom(&buf)
+		if err != nil {
+			t.Fatalf("%d: ReadFrom returned error %v", i, err)
+		}
+		if n != int64(f.SerializedSizeInBytes()) {
+			t.Errorf("%d: ReadFrom read %d bytes, want %d", i, n, f.SerializedSizeInBytes())
+		}
+		if g.M() != f.M() || g.K() != f.K() || !bytes.Equal(g.bits.Bytes(), f.bits.Bytes()) {
+			t.Errorf("%d: ReadFrom returned a different filter", i)
+		}
+	}
+}
+
+// withK returns a copy of the serialized filter data with k hash functions.
+func withK(data []byte, k uint32) []byte {
+	data = append([]byte{}, data...)
+	binary.BigEndian.PutUint32(data[12:], k)
+	return data
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	valid, _ := NewWithParameters(20, 3).MarshalBinary()
+	tests := []struct {
+		id   int
+		data []byte
+		err  error
+	}{
+		{0, nil, io.ErrUnexpectedEOF},
+		{1, valid[:10], io.ErrUnexpectedEOF},
+		{2, valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{3, append([]byte("BLMX"), valid[4:]...), ErrInvalidFormat},
+		{4, append(append([]byte{}, valid[:12]...), 0, 0, 0, 0, 0, 0, 0), ErrInvalidFormat},
+		{5, append(append([]byte{}, valid[:4]...), make([]byte, 12)...), ErrInvalidFormat},
+		{6, append(append([]byte{}, valid[:len(valid)-1]...), 0x01), ErrInvalidFormat},
+		{7, append(append([]byte{}, valid...), 0), ErrInvalidFormat},
+		{8, withK(valid, 21), ErrInvalidFormat},
+		{9, withK(valid, math.MaxInt32), ErrInvalidFormat},
+	}
+
+	for _, test := range tests {
+		f := New(1, 0.5)
+		if err := f.UnmarshalBinary(test.data); !errors.Is(err, test.err) {
+			t.Errorf("%d: UnmarshalBinary returned error %v, want %v", test.id, err, test.err)
+		}
+	}
+}
+
+// A header announcing a huge filter must not allocate it before its bytes are read.
+func TestReadFromLargeHeader(t *testing.T) {
+	header := []byte{
+		0x42, 0x4C, 0x4D, 0x46, // magic
+		0x00, 0x00, 0x00, 0x03, 0xFF, 0xFF, 0xFF, 0xF8, // m = MaxInt32 * 8
+		0x00, 0x00, 0x00, 0x01, // k = 1
+	}
+
+	for i, f := range []encoding.BinaryUnmarshaler{&Filter{}, &CountingFilter{}} {
+		copy(header, []string{filterMagic, countingFilterMagic}[i])
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if err := f.UnmarshalBinary(header); err != io.ErrUnexpectedEOF {
+			t.Errorf("%d: UnmarshalBinary returned error %v, want %v", i, err, io.ErrUnexpectedEOF)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("%d: UnmarshalBinary allocated %d bytes for a truncated filter", i, allocated)
+		}
+	}
+}
+
+func TestMarshalBinaryCounting(t *testing.T) {
+	f := NewCountingWithParameters(5, 1)
+	f.counters.Set(0, 1)
+	f.counters.Set(1, 15)
+	f.counters.Set(4, 7)
+
+	want := []byte{
+		0x42, 0x4C, 0x4D, 0x43, // magic
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, // m = 5
+		0x00, 0x00, 0x00, 0x01, // k = 1
+		0x1F, 0x00, 0x70,
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("MarshalBinary returned bad data %x, want %x", data, want)
+	}
+
+	g := &CountingFilter{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []uint64{1, 15, 0, 0, 7} {
+		if g.counters.Get(i) != c {
+			t.Errorf("UnmarshalBinary returned counter %d = %d, want %d", i, g.counters.Get(i), c)
+		}
+	}
+
+	want[len(want)-1] = 0x71
+	if err := g.UnmarshalBinary(want); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("UnmarshalBinary with non-zero padding returned error %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestMarshalBinaryVariants(t *testing.T) {
+	blocked, counting := NewBlocked(1000, 0.01), NewCounting(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		blocked.Add(key(i))
+		counting.Add(key(i))
+	}
+
+	blockedData, err := blocked.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blockedData) != blocked.SerializedSizeInBytes() {
+		t.Errorf("blocked: SerializedSizeInBytes returned %d, want %d", blocked.SerializedSizeInBytes(), len(blockedData))
+	}
+	b := &BlockedFilter{}
+	if err := b.UnmarshalBinary(blockedData); err != nil {
+		t.Fatal(err)
+	}
+	if b.M() != blocked.M() || b.K() != blocked.K() || !bytes.Equal(b.bits.Bytes(), blocked.bits.Bytes()) {
+		t.Errorf("blocked: UnmarshalBinary returned a different filter")
+	}
+
+	countingData, err := counting.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(countingData) != counting.SerializedSizeInBytes() {
+		t.Errorf("counting: SerializedSizeInBytes returned %d, want %d", counting.SerializedSizeInBytes(), len(countingData))
+	}
+	c := &CountingFilter{}
+	if err := c.UnmarshalBinary(countingData); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !c.Test(key(i)) {
+			t.Fatalf("counting: Test returned false for added key %d after UnmarshalBinary", i)
+		}
+	}
+
+	// a filter can only be read as its own type
+	if err := (&Filter{}).UnmarshalBinary(blockedData); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("reading a blocked filter as a standard one returned error %v, want %v", err, ErrInvalidFormat)
+	}
+	if err := (&BlockedFilter{}).UnmarshalBinary(countingData); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("reading a counting filter as a blocked one returned error %v, want %v", err, ErrInvalidFormat)
+	}
+	standard, _ := NewWithParameters(blockBits+8, 3).MarshalBinary()
+	copy(standard, blockedFilterMagic)
+	if err := (&BlockedFilter{}).UnmarshalBinary(standard); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("reading a blocked filter of %d bits returned error %v, want %v", blockBits+8, err, ErrInvalidFormat)
+	}
+
+	// blocked filters take up to 512 hash functions, the other ones up to 64
+	if err := b.UnmarshalBinary(withK(blockedData, blockBits)); err != nil {
+		t.Errorf("reading a blocked filter with k=%d returned error %v", blockBits, err)
+	}
+	if err := b.UnmarshalBinary(withK(blockedData, blockBits+1)); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("reading a blocked filter with k=%d returned error %v, want %v", blockBits+1, err, ErrInvalidFormat)
+	}
+	if err := c.UnmarshalBinary(withK(countingData, maxHashes+1)); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("reading a counting filter with k=%d returned error %v, want %v", maxHashes+1, err, ErrInvalidFormat)
+	}
+}
@@ -0,0 +1,87 @@
+package bitarray
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Equal returns whether both bit-arrays have the same length and the same bits.
+// Contrary to comparing their Bytes, "0" and "00" are different.
+func (ba *BitArray) Equal(other *BitArray) bool {
+	n := (ba.Len() + 7) / 8
+	return ba.Len() == other.Len() && bytes.Equal(ba.data[:n], other.data[:n])
+}
+
+// Compare compares the bit-arrays lexicographically, bit by bit, a bit-array being smaller than the longer ones it is a prefix of.
+// The result is 0 if ba == other, -1 if ba < other, and +1 if ba > other.
+func (ba *BitArray) Compare(other *BitArray) int {
+	n := ba.Len()
+	if other.Len() < n {
+		n = other.Len()
+	}
+
+	nb := n >> 3
+	if c := bytes.Compare(ba.data[:nb], other.data[:nb]); c != 0 {
+		return c
+	}
+	if r := n & 0x7; r != 0 {
+		a, b := ba.bitsAt(nb<<3, r), other.bitsAt(nb<<3, r)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case ba.Len() < other.Len():
+		return -1
+	case ba.Len() > other.Len():
+		return 1
+	}
+	return 0
+}
+
+// HasPrefix returns whether the bit-array begins with the bits of prefix.
+func (ba *BitArray) HasPrefix(prefix *BitArray) bool {
+	return prefix.Len() <= ba.Len() && equalBits(ba, 0, prefix, 0, prefix.Len())
+}
+
+// HasSuffix returns whether the bit-array ends with the bits of suffix.
+func (ba *BitArray) HasSuffix(suffix *BitArray) bool {
+	return suffix.Len() <= ba.Len() && equalBits(ba, ba.Len()-suffix.Len(), suffix, 0, suffix.Len())
+}
+
+// equalBits returns whether the n bits of a starting at position i are equal to the n bits of b starting at position j.
+func equalBits(a *BitArray, i int, b *BitArray, j int, n int) bool {
+	for ; n >= 64; i, j, n = i+64, j+64, n-64 {
+		if a.bitsAt(i, 64) != b.bitsAt(j, 64) {
+			return false
+		}
+	}
+	return a.bitsAt(i, n) == b.bitsAt(j, n)
+}
+
+// Hash64 returns a 64-bit FNV-1a hash of the length and the bits of the bit-array.
+// It is stable: it only depends on the length and the bits, across processes and versions of this package.
+func (ba *BitArray) Hash64() uint64 {
+	const offset64, prime64 = 14695981039346656037, 1099511628211
+
+	h := uint64(offset64)
+	for k := 56; k >= 0; k -= 8 {
+		h = (h ^ uint64(ba.Len())>>uint(k)&0xff) * prime64
+	}
+	for _, b := range ba.data[:(ba.Len()+7)/8] {
+		h = (h ^ uint64(b)) * prime64
+	}
+	return h
+}
+
+// Key returns a compact binary string made of the length and the bits of the bit-array, usable as a map key:
+// two bit-arrays have the same key if and only if they are Equal.
+func (ba *BitArray) Key() string {
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(ba.Len()))
+	return string(length[:n]) + string(ba.data[:(ba.Len()+7)/8])
+}
@@ -0,0 +1,143 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		id      int
+		a       string
+		b       string
+		compare int
+	}{
+		{0, "", "", 0},
+		{1, "", "0", -1},
+		{2, "0", "00", -1},
+		{3, "00", "0", 1},
+		{4, "1", "01", 1},
+		{5, "0110", "0110", 0},
+		{6, "0110", "0111", -1},
+		{7, "011011110000", "0110111100001", -1},
+		{8, "011011110001", "0110111100001", 1},
+		{9, "1101111010101101101111101110111100001", "1101111010101101101111101110111100001", 0},
+		{10, "1101111010101101101111101110111100001", "1101111010101101101111101110111100000", 1},
+	}
+
+	for _, test := range tests {
+		a, b := bitArrayOfString(test.a), bitArrayOfString(test.b)
+		if got := a.Compare(b); got != test.compare {
+			t.Errorf("%d: Compare returned bad data %d, want %d", test.id, got, test.compare)
+		}
+		if got := b.Compare(a); got != -test.compare {
+			t.Errorf("%d: reversed Compare returned bad data %d, want %d", test.id, got, -test.compare)
+		}
+		if got := a.Equal(b); got != (test.compare == 0) {
+			t.Errorf("%d: Equal returned bad data %t, want %t", test.id, got, test.compare == 0)
+		}
+		if got := a.Key() == b.Key(); got != (test.compare == 0) {
+			t.Errorf("%d: Key equality returned bad data %t, want %t", test.id, got, test.compare == 0)
+		}
+		if got := a.Hash64() == b.Hash64(); got != (test.compare == 0) {
+			t.Errorf("%d: Hash64 equality returned bad data %t, want %t", test.id, got, test.compare == 0)
+		}
+	}
+}
+
+func TestCompareRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 1000; id++ {
+		a := randomBitArray(r, r.Intn(150))
+		b := a.Clone()
+		switch r.Intn(3) {
+		case 0:
+			b = randomBitArray(r, r.Intn(150))
+		case 1:
+			if b.Len() > 0 {
+				i := r.Intn(b.Len())
+				b = b.ExtractBitArray(0, i)
+				b.AppendBit(byte(r.Intn(2)))
+			}
+		}
+
+		sa, sb := bitString(a), bitString(b)
+		if got, want := a.Compare(b), strings.Compare(sa, sb); got != want {
+			t.Fatalf("%d: Compare of %s and %s returned bad data %d, want %d", id, sa, sb, got, want)
+		}
+		if got, want := a.HasPrefix(b), strings.HasPrefix(sa, sb); got != want {
+			t.Fatalf("%d: HasPrefix of %s and %s returned bad data %t, want %t", id, sa, sb, got, want)
+		}
+		if got, want := a.HasSuffix(b), strings.HasSuffix(sa, sb); got != want {
+			t.Fatalf("%d: HasSuffix of %s and %s returned bad data %t, want %t", id, sa, sb, got, want)
+		}
+		if got, want := a.Equal(b), sa == sb; got != want || (a.Key() == b.Key()) != want {
+			t.Fatalf("%d: Equal and Key of %s and %s returned bad data %t, want %t", id, sa, sb, got, want)
+		}
+	}
+}
+
+func TestPrefixSuffix(t *testing.T) {
+	tests := []struct {
+		id     int
+		bits   string
+		affix  string
+		prefix bool
+		suffix bool
+	}{
+		{0, "", "", true, true},
+		{1, "0", "", true, true},
+		{2, "0", "00", false, false},
+		{3, "0110", "01", true, false},
+		{4, "0110", "10", false, true},
+		{5, "0110", "0110", true, true},
+		{6, "00000000", "0", true, true},
+	}
+
+	for _, test := range tests {
+		ba, affix := bitArrayOfString(test.bits), bitArrayOfString(test.affix)
+		if got := ba.HasPrefix(affix); got != test.prefix {
+			t.Errorf("%d: HasPrefix returned bad data %t, want %t", test.id, got, test.prefix)
+		}
+		if got := ba.HasSuffix(affix); got != test.suffix {
+			t.Errorf("%d: HasSuffix returned bad data %t, want %t", test.id, got, test.suffix)
+		}
+	}
+}
+
+func TestHash64(t *testing.T) {
+	for id, bits := range []string{"", "0", "00", "1", "1101111010101101", "110111101010110110111"} {
+		ba := bitArrayOfString(bits)
+
+		// FNV-1a of the big-endian length followed by the bytes
+		h := fnv.New64a()
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(ba.Len()))
+		h.Write(length[:])
+		h.Write(ba.Bytes())
+		if got, want := ba.Hash64(), h.Sum64(); got != want {
+			t.Errorf("%d: Hash64 returned bad data %#x, want %#x", id, got, want)
+		}
+	}
+
+	// a stable value, which must not change across versions
+	if got := bitArrayOfString("1101111010101101").Hash64(); got != 0xf1bce6cc6d30be74 {
+		t.Errorf("Hash64 returned bad data %#x, want 0xf1bce6cc6d30be74", got)
+	}
+}
+
+func TestKeyAsMapKey(t *testing.T) {
+	m := map[string]int{}
+	for id, bits := range []string{"", "0", "00", "000", "00000000", "000000000", "1", "10"} {
+		m[bitArrayOfString(bits).Key()] = id
+	}
+	if len(m) != 8 {
+		t.Errorf("%d distinct keys, want 8", len(m))
+	}
+	if m[bitArrayOfString("00").Key()] != 2 {
+		t.Errorf("Key returned different keys for equal bit-arrays")
+	}
+}
@@ -0,0 +1,58 @@
+package crc
+
+// Parameters of standard CRCs from the reveng catalogue.
+var (
+	CRC3GSM      = Params{Name: "CRC-3/GSM", Width: 3, Poly: 0x3, Init: 0x0, RefIn: false, RefOut: false, XorOut: 0x7, Check: 0x4}
+	CRC4G704     = Params{Name: "CRC-4/G-704", Width: 4, Poly: 0x3, Init: 0x0, RefIn: true, RefOut: true, XorOut: 0x0, Check: 0x7}
+	CRC5EPCC1G2  = Params{Name: "CRC-5/EPC-C1G2", Width: 5, Poly: 0x09, Init: 0x09, RefIn: false, RefOut: false, XorOut: 0x00, Check: 0x00}
+	CRC5USB      = Params{Name: "CRC-5/USB", Width: 5, Poly: 0x05, Init: 0x1f, RefIn: true, RefOut: true, XorOut: 0x1f, Check: 0x19}
+	CRC6G704     = Params{Name: "CRC-6/G-704", Width: 6, Poly: 0x03, Init: 0x00, RefIn: true, RefOut: true, XorOut: 0x00, Check: 0x06}
+	CRC7MMC      = Params{Name: "CRC-7/MMC", Width: 7, Poly: 0x09, Init: 0x00, RefIn: false, RefOut: false, XorOut: 0x00, Check: 0x75}
+	CRC8SMBUS    = Params{Name: "CRC-8/SMBUS", Width: 8, Poly: 0x07, Init: 0x00, RefIn: false, RefOut: false, XorOut: 0x00, Check: 0xf4}
+	CRC8MAXIMDOW = Params{Name: "CRC-8/MAXIM-DOW", Width: 8, Poly: 0x31, Init: 0x00, RefIn: true, RefOut: true, XorOut: 0x00, Check: 0xa1}
+	CRC8AUTOSAR  = Params{Name: "CRC-8/AUTOSAR", Width: 8, Poly: 0x2f, Init: 0xff, RefIn: false, RefOut: false, XorOut: 0xff, Check: 0xdf}
+	CRC10ATM     = Params{Name: "CRC-10/ATM", Width: 10, Poly: 0x233, Init: 0x000, RefIn: false, RefOut: false, XorOut: 0x000, Check: 0x199}
+	CRC11FLEXRAY = Params{Name: "CRC-11/FLEXRAY", Width: 11, Poly: 0x385, Init: 0x01a, RefIn: false, RefOut: false, XorOut: 0x000, Check: 0x5a3}
+	CRC12UMTS    = Params{Name: "CRC-12/UMTS", Width: 12, Poly: 0x80f, Init: 0x000, RefIn: false, RefOut: true, XorOut: 0x000, Check: 0xdaf}
+	CRC15CAN     = Params{Name: "CRC-15/CAN", Width: 15, Poly: 0x4599, Init: 0x0000, RefIn: false, RefOut: false, XorOut: 0x0000, Check: 0x059e}
+	CRC16ARC     = Params{Name: "CRC-16/ARC", Width: 16, Poly: 0x8005, Init: 0x0000, RefIn: true, RefOut: true, XorOut: 0x0000, Check: 0xbb3d}
+	CRC16IBM3740 = Params{Name: "CRC-16/IBM-3740", Width: 16, Poly: 0x1021, Init: 0xffff, RefIn: false, RefOut: false, XorOut: 0x0000, Check: 0x29b1}
+	CRC16IBMSDLC = Params{Name: "CRC-16/IBM-SDLC", Width: 16, Poly: 0x1021, Init: 0xffff, RefIn: true, RefOut: true, XorOut: 0xffff, Check: 0x906e}
+	CRC16KERMIT  = Params{Name: "CRC-16/KERMIT", Width: 16, Poly: 0x1021, Init: 0x0000, RefIn: true, RefOut: true, XorOut: 0x0000, Check: 0x2189}
+	CRC16MODBUS  = Params{Name: "CRC-16/MODBUS", Width: 16, Poly: 0x8005, Init: 0xffff, RefIn: true, RefOut: true, XorOut: 0x0000, Check: 0x4b37}
+	CRC16USB     = Params{Name: "CRC-16/USB", Width: 16, Poly: 0x8005, Init: 0xffff, RefIn: true, RefOut: true, XorOut: 0xffff, Check: 0xb4c8}
+	CRC16XMODEM  = Params{Name: "CRC-16/XMODEM", Width: 16, Poly: 0x1021, Init: 0x0000, RefIn: false, RefOut: false, XorOut: 0x0000, Check: 0x31c3}
+	CRC17CANFD   = Params{Name: "CRC-17/CAN-FD", Width: 17, Poly: 0x1685b, Init: 0x00000, RefIn: false, RefOut: false, XorOut: 0x00000, Check: 0x04f03}
+	CRC21CANFD   = Params{Name: "CRC-21/CAN-FD", Width: 21, Poly: 0x102899, Init: 0x000000, RefIn: false, RefOut: false, XorOut: 0x000000, Check: 0x0ed841}
+	CRC24BLE     = Params{Name: "CRC-24/BLE", Width: 24, Poly: 0x00065b, Init: 0x555555, RefIn: true, RefOut: true, XorOut: 0x000000, Check: 0xc25a56}
+	CRC24OPENPGP = Params{Name: "CRC-24/OPENPGP", Width: 24, Poly: 0x864cfb, Init: 0xb704ce, RefIn: false, RefOut: false, XorOut: 0x000000, Check: 0x21cf02}
+	CRC32BZIP2   = Params{Name: "CRC-32/BZIP2", Width: 32, Poly: 0x04c11db7, Init: 0xffffffff, RefIn: false, RefOut: false, XorOut: 0xffffffff, Check: 0xfc891918}
+	CRC32CKSUM   = Params{Name: "CRC-32/CKSUM", Width: 32, Poly: 0x04c11db7, Init: 0x00000000, RefIn: false, RefOut: false, XorOut: 0xffffffff, Check: 0x765e7680}
+	CRC32ISCSI   = Params{Name: "CRC-32/ISCSI", Width: 32, Poly: 0x1edc6f41, Init: 0xffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffff, Check: 0xe3069283}
+	CRC32ISOHDLC = Params{Name: "CRC-32/ISO-HDLC", Width: 32, Poly: 0x04c11db7, Init: 0xffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffff, Check: 0xcbf43926}
+	CRC32MPEG2   = Params{Name: "CRC-32/MPEG-2", Width: 32, Poly: 0x04c11db7, Init: 0xffffffff, RefIn: false, RefOut: false, XorOut: 0x00000000, Check: 0x0376e6e7}
+	CRC40GSM     = Params{Name: "CRC-40/GSM", Width: 40, Poly: 0x0004820009, Init: 0x0000000000, RefIn: false, RefOut: false, XorOut: 0xffffffffff, Check: 0xd4164fc646}
+	CRC64ECMA182 = Params{Name: "CRC-64/ECMA-182", Width: 64, Poly: 0x42f0e1eba9ea3693, Init: 0x0, RefIn: false, RefOut: false, XorOut: 0x0, Check: 0x6c40df5f0b497347}
+	CRC64GOISO   = Params{Name: "CRC-64/GO-ISO", Width: 64, Poly: 0x000000000000001b, Init: 0xffffffffffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffffffffffff, Check: 0xb90956c775a41001}
+	CRC64XZ      = Params{Name: "CRC-64/XZ", Width: 64, Poly: 0x42f0e1eba9ea3693, Init: 0xffffffffffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffffffffffff, Check: 0x995dc9bbdf1939fa}
+)
+
+// Catalogue lists the parameters of the standard CRCs defined by the package.
+var Catalogue = []Params{
+	CRC3GSM, CRC4G704, CRC5EPCC1G2, CRC5USB, CRC6G704, CRC7MMC,
+	CRC8SMBUS, CRC8MAXIMDOW, CRC8AUTOSAR, CRC10ATM, CRC11FLEXRAY, CRC12UMTS, CRC15CAN,
+	CRC16ARC, CRC16IBM3740, CRC16IBMSDLC, CRC16KERMIT, CRC16MODBUS, CRC16USB, CRC16XMODEM,
+	CRC17CANFD, CRC21CANFD, CRC24BLE, CRC24OPENPGP,
+	CRC32BZIP2, CRC32CKSUM, CRC32ISCSI, CRC32ISOHDLC, CRC32MPEG2, CRC40GSM,
+	CRC64ECMA182, CRC64GOISO, CRC64XZ,
+}
+
+// Lookup returns the parameters of the CRC of the catalogue with the given name, such as "CRC-32/ISO-HDLC".
+func Lookup(name string) (Params, bool) {
+	for _, p := range Catalogue {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Params{}, false
+}
@@ -0,0 +1,46 @@
+package crc
+
+import (
+	"testing"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+func TestCheck(t *testing.T) {
+	check := []byte("123456789")
+	for id, p := range Catalogue {
+		c := New(p)
+		if got := c.ChecksumBytes(check); got != p.Check {
+			t.Errorf("%d: %s: ChecksumBytes returned %#x, want %#x", id, p.Name, got, p.Check)
+		}
+		if got := c.Checksum(bitArrayOf(check)); got != p.Check {
+			t.Errorf("%d: %s: Checksum returned %#x, want %#x", id, p.Name, got, p.Check)
+		}
+
+		// the check string at an unaligned position
+		ba := bitarray.New()
+		ba.AppendString("101")
+		ba.AppendBitArray(bitArrayOf(check))
+		ba.AppendString("11")
+		if got := c.ChecksumRange(ba, 3, 3+8*len(check)); got != p.Check {
+			t.Errorf("%d: %s: ChecksumRange returned %#x, want %#x", id, p.Name, got, p.Check)
+		}
+
+		if got := c.Checksum(bitarray.New()); got != reference(p, bitarray.New(), 0, 0) {
+			t.Errorf("%d: %s: Checksum of the empty bit-array returned %#x, want %#x", id, p.Name, got, reference(p, bitarray.New(), 0, 0))
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	p, ok := Lookup("CRC-16/ARC")
+	if !ok || p != CRC16ARC {
+		t.Errorf("Lookup(CRC-16/ARC) returned (%v, %t), want (%v, true)", p, ok, CRC16ARC)
+	}
+	if _, ok := Lookup("CRC-16/UNKNOWN"); ok {
+		t.Errorf("Lookup of an unknown CRC returned true")
+	}
+	if New(CRC5USB).Params() != CRC5USB {
+		t.Errorf("Params returned bad data")
+	}
+}
@@ -0,0 +1,144 @@
+// Package crc computes cyclic redundancy checks over bit-arrays, bit-exactly: the input does not need to be
+// a whole number of bytes, as in protocols such as CAN or USB whose CRCs cover arbitrary numbers of bits.
+//
+// CRCs are described by the parameters of the Rocksoft model (Williams, "A painless guide to CRC error detection
+// algorithms"), as listed in the catalogue of CRC parameters of Greg Cook (reveng).
+package crc
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// Params are the parameters of a CRC in the Rocksoft model.
+type Params struct {
+	// Name is the name of the CRC in the reveng catalogue.
+	Name string
+	// Width is the number of bits of the CRC, between 1 and 64.
+	Width int
+	// Poly is the generator polynomial in normal notation: its leading term x^Width is implicit.
+	Poly uint64
+	// Init is the initial value of the register.
+	Init uint64
+	// RefIn tells whether each group of 8 input bits is consumed from its last bit to its first one.
+	RefIn bool
+	// RefOut tells whether the register is reflected before the final XOR.
+	RefOut bool
+	// XorOut is XOR-ed with the register to give the CRC.
+	XorOut uint64
+	// Check is the CRC of the ASCII string "123456789".
+	Check uint64
+}
+
+// CRC computes the CRC described by its parameters.
+// A table of 256 entries is precomputed to process 8 bits at a time.
+type CRC struct {
+	params Params
+	shift  uint   // 64 - Width, the register is kept in the most significant bits of a uint64
+	poly   uint64 // Poly aligned on the most significant bits
+	table  [256]uint64
+}
+
+// New returns the CRC of parameters p.
+// It will panic if the width is not between 1 and 64 or if Poly, Init or XorOut do not fit on Width bits.
+func New(p Params) *CRC {
+	if p.Width < 1 || p.Width > 64 {
+		panic(fmt.Sprintf("width should be between 1 and 64, given %d", p.Width))
+	}
+	shift := uint(64 - p.Width)
+	for _, v := range []uint64{p.Poly, p.Init, p.XorOut} {
+		if v<<shift>>shift != v {
+			panic(fmt.Sprintf("%#x does not fit on %d bits", v, p.Width))
+		}
+	}
+
+	c := &CRC{params: p, shift: shift, poly: p.Poly << shift}
+	for i := range c.table {
+		reg := uint64(i) << 56
+		for k := 0; k < 8; k++ {
+			reg = c.step(reg, 0)
+		}
+		c.table[i] = reg
+	}
+	return c
+}
+
+// Params returns the parameters of the CRC.
+func (c *CRC) Params() Params {
+	return c.params
+}
+
+// step feeds one bit to the register.
+func (c *CRC) step(reg uint64, bit uint64) uint64 {
+	top := reg>>63 ^ bit
+	reg <<= 1
+	if top == 1 {
+		reg ^= c.poly
+	}
+	return reg
+}
+
+// Checksum returns the CRC of all the bits of ba.
+func (c *CRC) Checksum(ba *bitarray.BitArray) uint64 {
+	return c.ChecksumRange(ba, 0, ba.Len())
+}
+
+// ChecksumRange returns the CRC of the bits of ba in the range [i, j).
+// When RefIn is set, the bits are consumed by groups of 8 starting at i, each group from its last bit to its first one;
+// the last group may be shorter.
+// It will panic if the range is invalid.
+func (c *CRC) ChecksumRange(ba *bitarray.BitArray, i, j int) uint64 {
+	if i < 0 || j > ba.Len() || i > j {
+		panic(fmt.Sprintf("invalid range [%d, %d) with length %d", i, j, ba.Len()))
+	}
+	return c.finalize(c.update(c.params.Init<<c.shift, ba, i, j))
+}
+
+// ChecksumBytes returns the CRC of data.
+func (c *CRC) ChecksumBytes(data []byte) uint64 {
+	reg := c.params.Init << c.shift
+	for _, b := range data {
+		reg = c.updateByte(reg, b)
+	}
+	return c.finalize(reg)
+}
+
+func (c *CRC) updateByte(reg uint64, b byte) uint64 {
+	if c.params.RefIn {
+		b = bits.Reverse8(b)
+	}
+	return reg<<8 ^ c.table[byte(reg>>56)^b]
+}
+
+func (c *CRC) update(reg uint64, ba *bitarray.BitArray, i, j int) uint64 {
+	for ; i+64 <= j; i += 64 {
+		w := ba.Extract(i, i+64)
+		for k := 56; k >= 0; k -= 8 {
+			reg = c.updateByte(reg, byte(w>>uint(k)))
+		}
+	}
+	for ; i+8 <= j; i += 8 {
+		reg = c.updateByte(reg, byte(ba.Extract(i, i+8)))
+	}
+
+	if n := j - i; n > 0 {
+		v := ba.Extract(i, j)
+		if c.params.RefIn {
+			v = bits.Reverse64(v) >> uint(64-n)
+		}
+		for k := n - 1; k >= 0; k-- {
+			reg = c.step(reg, v>>uint(k)&1)
+		}
+	}
+	return reg
+}
+
+func (c *CRC) finalize(reg uint64) uint64 {
+	reg >>= c.shift
+	if c.params.RefOut {
+		reg = bits.Reverse64(reg) >> c.shift
+	}
+	return reg ^ c.params.XorOut
+}
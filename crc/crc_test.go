@@ -0,0 +1,134 @@
+package crc
+
+import (
+	"hash/crc32"
+	"hash/crc64"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+func bitArrayOf(data []byte) *bitarray.BitArray {
+	ba := bitarray.New()
+	ba.AppendBytes(data, 0)
+	return ba
+}
+
+// reference computes a CRC one bit at a time with a register of Width bits.
+func reference(p Params, ba *bitarray.BitArray, i, j int) uint64 {
+	mask := ^uint64(0) >> uint(64-p.Width)
+	reg := p.Init
+	for g := i; g < j; g += 8 {
+		end := g + 8
+		if end > j {
+			end = j
+		}
+		for k := 0; k < end-g; k++ {
+			pos := g + k
+			if p.RefIn {
+				pos = end - 1 - k
+			}
+			top := reg>>uint(p.Width-1)&1 ^ uint64(ba.GetBit(pos))
+			reg = reg << 1 & mask
+			if top == 1 {
+				reg ^= p.Poly
+			}
+		}
+	}
+	if p.RefOut {
+		reg = bits.Reverse64(reg) >> uint(64-p.Width)
+	}
+	return reg ^ p.XorOut
+}
+
+func TestBitExact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ba := bitarray.New()
+	for k := 0; k < 1000; k++ {
+		ba.AppendBit(byte(r.Intn(2)))
+	}
+
+	for id, p := range Catalogue {
+		c := New(p)
+		for q := 0; q < 50; q++ {
+			i := r.Intn(ba.Len())
+			j := i + r.Intn(ba.Len()-i+1)
+			if got, want := c.ChecksumRange(ba, i, j), reference(p, ba, i, j); got != want {
+				t.Fatalf("%d: %s: ChecksumRange(%d, %d) returned %#x, want %#x", id, p.Name, i, j, got, want)
+			}
+		}
+	}
+}
+
+// Without init, reflection nor final XOR, a message followed by its CRC is divisible by the polynomial:
+// this is how receivers such as CAN controllers check frames of any number of bits.
+func TestResidue(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id, p := range []Params{CRC15CAN, CRC17CANFD, CRC21CANFD, CRC10ATM, CRC64ECMA182} {
+		c := New(p)
+		for q := 0; q < 20; q++ {
+			ba := bitarray.New()
+			for k := 0; k < 19+r.Intn(200); k++ {
+				ba.AppendBit(byte(r.Intn(2)))
+			}
+			ba.Append64(c.Checksum(ba), p.Width)
+			if got := c.Checksum(ba); got != 0 {
+				t.Fatalf("%d: %s: CRC of a message followed by its CRC returned %#x, want 0", id, p.Name, got)
+			}
+		}
+	}
+}
+
+func TestStandardLibrary(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	ieee, castagnoli := New(CRC32ISOHDLC), New(CRC32ISCSI)
+	xz, iso := New(CRC64XZ), New(CRC64GOISO)
+	ecmaTable, isoTable := crc64.MakeTable(crc64.ECMA), crc64.MakeTable(crc64.ISO)
+
+	for id := 0; id < 50; id++ {
+		data := make([]byte, r.Intn(300))
+		r.Read(data)
+		ba := bitArrayOf(data)
+
+		if got, want := ieee.Checksum(ba), uint64(crc32.ChecksumIEEE(data)); got != want {
+			t.Errorf("%d: CRC-32/ISO-HDLC returned %#x, want %#x", id, got, want)
+		}
+		if got, want := castagnoli.Checksum(ba), uint64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))); got != want {
+			t.Errorf("%d: CRC-32/ISCSI returned %#x, want %#x", id, got, want)
+		}
+		if got, want := xz.Checksum(ba), crc64.Checksum(data, ecmaTable); got != want {
+			t.Errorf("%d: CRC-64/XZ returned %#x, want %#x", id, got, want)
+		}
+		if got, want := iso.Checksum(ba), crc64.Checksum(data, isoTable); got != want {
+			t.Errorf("%d: CRC-64/GO-ISO returned %#x, want %#x", id, got, want)
+		}
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		id int
+		f  func()
+	}{
+		{0, func() { New(Params{Width: 0, Poly: 1}) }},
+		{1, func() { New(Params{Width: 65, Poly: 1}) }},
+		{2, func() { New(Params{Width: 8, Poly: 0x107}) }},
+		{3, func() { New(Params{Width: 8, Poly: 0x07, Init: 0x100}) }},
+		{4, func() { New(Params{Width: 8, Poly: 0x07, XorOut: 0x1ff}) }},
+		{5, func() { New(CRC8SMBUS).ChecksumRange(bitarray.NewZeros(8), 4, 9) }},
+		{6, func() { New(CRC8SMBUS).ChecksumRange(bitarray.NewZeros(8), 5, 4) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", test.id)
+				}
+			}()
+			test.f()
+		}()
+	}
+}
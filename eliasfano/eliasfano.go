@@ -0,0 +1,141 @@
+// Package eliasfano implements the Elias-Fano encoding of monotone (non-decreasing) sequences of integers.
+// Each value is split into its l lowest bits, packed contiguously in a bit-array,
+// and its remaining highest bits, stored in unary as gaps in a second bit-array indexed for select queries.
+// A sequence of n values lower than u uses at most n*(2 + ceil(log2(u/n))) bits plus a small directory.
+package eliasfano
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// EliasFano is an immutable, compressed, monotone sequence of uint64 values.
+type EliasFano struct {
+	n       int
+	last    uint64
+	lowBits int
+	low     *bitarray.BitArray
+	high    *bitarray.BitArray
+	rs      *bitarray.RankSelect
+}
+
+// New encodes values which must be sorted in non-decreasing order, otherwise New will panic.
+func New(values []uint64) *EliasFano {
+	n := len(values)
+	ef := &EliasFano{n: n, low: bitarray.New()}
+	if n == 0 {
+		ef.high = bitarray.New()
+		ef.rs = bitarray.NewRankSelect(ef.high)
+		return ef
+	}
+
+	for i := 1; i < n; i++ {
+		if values[i] < values[i-1] {
+			panic(fmt.Sprintf("values should be sorted; values[%d]=%d < values[%d]=%d", i, values[i], i-1, values[i-1]))
+		}
+	}
+
+	ef.last = values[n-1]
+	// l = floor(log2(u / n)) with u = last + 1
+	u := ef.last + 1
+	if u == 0 {
+		u = ef.last
+	}
+	if q := u / uint64(n); q > 0 {
+		ef.lowBits = bits.Len64(q) - 1
+	}
+
+	ef.high = bitarray.NewZeros(n + int(ef.last>>ef.lowBits) + 1)
+	for i, v := range values {
+		ef.low.Append64(v, ef.lowBits)
+		ef.high.SetBit(int(v>>ef.lowBits) + i)
+	}
+	ef.rs = bitarray.NewRankSelect(ef.high)
+	return ef
+}
+
+// Len returns the number of values of the sequence.
+func (ef *EliasFano) Len() int {
+	return ef.n
+}
+
+// SizeInBits returns the number of bits used by the encoded sequence, including the select directory.
+func (ef *EliasFano) SizeInBits() int {
+	return ef.low.Len() + ef.high.Len() + ef.rs.SizeInBits()
+}
+
+func (ef *EliasFano) lowAt(i int) uint64 {
+	if ef.lowBits == 0 {
+		return 0
+	}
+	return ef.low.Extract(i*ef.lowBits, (i+1)*ef.lowBits)
+}
+
+// Access returns the i-th value of the sequence and will panic if i is out of range.
+func (ef *EliasFano) Access(i int) uint64 {
+	if i < 0 || i >= ef.n {
+		panic(fmt.Sprintf("index out of range [%d] with length %d", i, ef.n))
+	}
+	return uint64(ef.rs.Select1(i)-i)<<ef.lowBits | ef.lowAt(i)
+}
+
+// NextGEQ returns the index and the value of the first element of the sequence greater than or equal to x.
+// The returned boolean is false if there is no such element.
+func (ef *EliasFano) NextGEQ(x uint64) (int, uint64, bool) {
+	if ef.n == 0 || x > ef.last {
+		return ef.n, 0, false
+	}
+
+	// the elements whose high part is at least x's one start right after the (x >> l)-th 0 of the high bits
+	hx := int(x >> ef.lowBits)
+	pos := 0
+	if hx > 0 {
+		pos = ef.rs.Select0(hx-1) + 1
+	}
+
+	it := &Iterator{ef: ef, i: pos - hx, pos: pos}
+	for {
+		i := it.i
+		v, _ := it.Next()
+		if v >= x {
+			return i, v, true
+		}
+	}
+}
+
+// Iterator returns an iterator over the values of the sequence.
+func (ef *EliasFano) Iterator() *Iterator {
+	return &Iterator{ef: ef}
+}
+
+// Values returns the decoded sequence.
+func (ef *EliasFano) Values() []uint64 {
+	values := make([]uint64, 0, ef.n)
+	it := ef.Iterator()
+	for v, ok := it.Next(); ok; v, ok = it.Next() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Iterator iterates sequentially over the values of a sequence, it is faster than successive calls to Access.
+type Iterator struct {
+	ef  *EliasFano
+	i   int // index of the next value
+	pos int // position in the high bits from where to look for the next value
+}
+
+// Next returns the next value of the sequence, the returned boolean is false when the sequence is exhausted.
+func (it *Iterator) Next() (uint64, bool) {
+	if it.i >= it.ef.n {
+		return 0, false
+	}
+
+	p := it.ef.high.NextOne(it.pos)
+	v := uint64(p-it.i)<<it.ef.lowBits | it.ef.lowAt(it.i)
+	it.pos = p + 1
+	it.i++
+	return v, true
+}
@@ -0,0 +1,112 @@
+package eliasfano
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomSorted(r *rand.Rand, n int, universe uint64) []uint64 {
+	values := make([]uint64, n)
+	for i := range values {
+		values[i] = uint64(r.Int63n(int64(universe)))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+func TestAccessAndIteration(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		id     int
+		values []uint64
+	}{
+		{0, []uint64{}},
+		{1, []uint64{0}},
+		{2, []uint64{5, 5, 5}},
+		{3, []uint64{2, 3, 5, 7, 11, 13, 24}},
+		{4, []uint64{0, 1 << 40, 1<<40 + 1, math.MaxUint64}},
+		{5, randomSorted(r, 1000, 1000)},
+		{6, randomSorted(r, 5000, 1<<32)},
+		{7, randomSorted(r, 10000, 50)},
+	}
+
+	for _, test := range tests {
+		ef := New(test.values)
+		if ef.Len() != len(test.values) {
+			t.Errorf("%d: Len returned %d, want %d", test.id, ef.Len(), len(test.values))
+		}
+		for i, v := range test.values {
+			if ef.Access(i) != v {
+				t.Fatalf("%d: Access(%d) returned %d, want %d", test.id, i, ef.Access(i), v)
+			}
+		}
+		if fmt.Sprint(ef.Values()) != fmt.Sprint(test.values) {
+			t.Errorf("%d: iteration returned %v, want %v", test.id, ef.Values(), test.values)
+		}
+	}
+}
+
+func TestNextGEQ(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id, values := range [][]uint64{
+		{},
+		{7},
+		{3, 3, 9, 100, 100, 101},
+		randomSorted(r, 2000, 100000),
+		randomSorted(r, 2000, 1<<50),
+	} {
+		ef := New(values)
+		queries := []uint64{0, 1, math.MaxUint64}
+		for _, v := range values {
+			queries = append(queries, v, v+1, v-1)
+		}
+
+		for _, x := range queries {
+			want := sort.Search(len(values), func(i int) bool { return values[i] >= x })
+			i, v, ok := ef.NextGEQ(x)
+			if ok != (want < len(values)) {
+				t.Fatalf("%d: NextGEQ(%d) returned ok=%t, want %t", id, x, ok, want < len(values))
+			}
+			if ok && (i != want || v != values[want]) {
+				t.Fatalf("%d: NextGEQ(%d) returned (%d, %d), want (%d, %d)", id, x, i, v, want, values[want])
+			}
+		}
+	}
+}
+
+func TestSpace(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	tests := []struct {
+		id       int
+		n        int
+		universe uint64
+	}{
+		{0, 100000, 100000},
+		{1, 100000, 1 << 20},
+		{2, 100000, 1 << 32},
+		{3, 50000, 1 << 48},
+	}
+
+	for _, test := range tests {
+		values := randomSorted(r, test.n, test.universe)
+		ef := New(values)
+
+		// lower bound on the number of bits needed to represent a subset of size n of [0, u)
+		bound := float64(test.n) * (2 + math.Ceil(math.Log2(float64(values[len(values)-1]+1)/float64(test.n))))
+		if size := float64(ef.SizeInBits()); size > 1.05*bound {
+			t.Errorf("%d: %d values use %.0f bits, theoretical bound is %.0f", test.id, test.n, size, bound)
+		}
+	}
+}
+
+func TestNewPanicsOnUnsortedValues(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("New did not panic on unsorted values")
+		}
+	}()
+	New([]uint64{1, 3, 2})
+}
@@ -0,0 +1,338 @@
+// Package ewah implements EWAH (Enhanced Word-Aligned Hybrid) compressed bitmaps
+// using the same 64-bit word layout and serialization as git's pack .bitmap files.
+//
+// A compressed bitmap is a sequence of markers (running length words), each one followed by literal words.
+// A marker stores, from the least significant bit:
+//   - 1 bit: the value of the clean words of the run,
+//   - 32 bits: the number of clean words (all 0's or all 1's) of the run,
+//   - 31 bits: the number of literal words stored verbatim after the marker.
+//
+// Bit i of the bitmap is stored in the bit i%64 (starting from the least significant bit) of the word i/64.
+package ewah
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+const (
+	wordSize            = 64
+	runningBits         = 32
+	literalBits         = wordSize - 1 - runningBits
+	largestRunningCount = 1<<runningBits - 1
+	largestLiteralCount = 1<<literalBits - 1
+)
+
+// marker accessors
+
+func runBit(rlw uint64) bool {
+	return rlw&1 != 0
+}
+
+func runningLen(rlw uint64) uint64 {
+	return (rlw >> 1) & largestRunningCount
+}
+
+func literalWords(rlw uint64) uint64 {
+	return rlw >> (1 + runningBits)
+}
+
+func (b *Bitmap) setRunBit(v bool) {
+	if v {
+		b.buffer[b.rlw] |= 1
+	} else {
+		b.buffer[b.rlw] &^= 1
+	}
+}
+
+func (b *Bitmap) setRunningLen(n uint64) {
+	b.buffer[b.rlw] = b.buffer[b.rlw]&^(largestRunningCount<<1) | n<<1
+}
+
+func (b *Bitmap) setLiteralWords(n uint64) {
+	b.buffer[b.rlw] = b.buffer[b.rlw]&(1<<(1+runningBits)-1) | n<<(1+runningBits)
+}
+
+// Bitmap is an EWAH compressed bitmap. Users are supposed to use `New` or `FromBitArray` to instantiate one.
+type Bitmap struct {
+	buffer  []uint64
+	rlw     int // position of the last marker in buffer
+	bitSize int
+}
+
+// New returns a new, empty compressed bitmap.
+func New() *Bitmap {
+	return &Bitmap{buffer: []uint64{0}}
+}
+
+// FromBitArray returns the compressed form of a bit-array, the bit at position i in ba is the bit i of the bitmap.
+func FromBitArray(ba *bitarray.BitArray) *Bitmap {
+	b := New()
+	n := ba.Len()
+	for i := 0; i < n; i += wordSize {
+		if i+wordSize <= n {
+			b.addWord(bits.Reverse64(ba.Extract(i, i+wordSize)))
+		} else {
+			b.addWord(bits.Reverse64(ba.Extract(i, n) << (wordSize - (n - i))))
+		}
+	}
+	b.bitSize = n
+	return b
+}
+
+// Len returns the number of bits of the bitmap.
+func (b *Bitmap) Len() int {
+	return b.bitSize
+}
+
+// SizeInWords returns the number of 64-bit words of the compressed representation.
+func (b *Bitmap) SizeInWords() int {
+	return len(b.buffer)
+}
+
+// Set sets the bit at position `index` to `1`, growing the bitmap to index+1 bits.
+// As with git's implementation, bits must be set in increasing order, otherwise Set will panic.
+func (b *Bitmap) Set(index int) {
+	if index < b.bitSize {
+		panic(fmt.Sprintf("bits must be set in increasing order; given %d with length %d", index, b.bitSize))
+	}
+
+	dist := (index+wordSize)/wordSize - (b.bitSize+wordSize-1)/wordSize
+	b.bitSize = index + 1
+	bit := uint64(1) << (index % wordSize)
+
+	if dist > 0 {
+		if dist > 1 {
+			b.addEmptyWords(false, uint64(dist-1))
+		}
+		b.addLiteral(bit)
+		return
+	}
+
+	if literalWords(b.buffer[b.rlw]) == 0 {
+		b.setRunningLen(runningLen(b.buffer[b.rlw]) - 1)
+		b.addLiteral(bit)
+		return
+	}
+
+	last := len(b.buffer) - 1
+	b.buffer[last] |= bit
+	// check whether the last literal word just became a clean word of 1's
+	if b.buffer[last] == ^uint64(0) {
+		b.buffer = b.buffer[:last]
+		b.setLiteralWords(literalWords(b.buffer[b.rlw]) - 1)
+		b.addEmptyWord(true)
+	}
+}
+
+// pushMarker appends a new, empty marker.
+func (b *Bitmap) pushMarker() {
+	b.buffer = append(b.buffer, 0)
+	b.rlw = len(b.buffer) - 1
+}
+
+// addWord appends a 64-bit word without updating the bit size.
+func (b *Bitmap) addWord(w uint64) {
+	switch w {
+	case 0:
+		b.addEmptyWord(false)
+	case ^uint64(0):
+		b.addEmptyWord(true)
+	default:
+		b.addLiteral(w)
+	}
+}
+
+func (b *Bitmap) addEmptyWord(v bool) {
+	rlw := b.buffer[b.rlw]
+	noLiteral := literalWords(rlw) == 0
+	runLen := runningLen(rlw)
+
+	if noLiteral && runLen == 0 {
+		b.setRunBit(v)
+	}
+	if noLiteral && runBit(b.buffer[b.rlw]) == v && runLen < largestRunningCount {
+		b.setRunningLen(runLen + 1)
+		return
+	}
+
+	b.pushMarker()
+	b.setRunBit(v)
+	b.setRunningLen(1)
+}
+
+func (b *Bitmap) addEmptyWords(v bool, n uint64) {
+	if n == 0 {
+		return
+	}
+
+	rlw := b.buffer[b.rlw]
+	if runBit(rlw) != v && runningLen(rlw) == 0 && literalWords(rlw) == 0 {
+		b.setRunBit(v)
+	} else if literalWords(rlw) != 0 || runBit(rlw) != v {
+		b.pushMarker()
+		b.setRunBit(v)
+	}
+
+	runLen := runningLen(b.buffer[b.rlw])
+	canAdd := n
+	if canAdd > largestRunningCount-runLen {
+		canAdd = largestRunningCount - runLen
+	}
+	b.setRunningLen(runLen + canAdd)
+	n -= canAdd
+
+	for n > 0 {
+		b.pushMarker()
+		b.setRunBit(v)
+		canAdd = n
+		if canAdd > largestRunningCount {
+			canAdd = largestRunningCount
+		}
+		b.setRunningLen(canAdd)
+		n -= canAdd
+	}
+}
+
+func (b *Bitmap) addLiteral(w uint64) {
+	count := literalWords(b.buffer[b.rlw])
+	if count >= largestLiteralCount {
+		b.pushMarker()
+		count = 0
+	}
+	b.setLiteralWords(count + 1)
+	b.buffer = append(b.buffer, w)
+}
+
+// iterator walks over the clean and literal words of a compressed bitmap.
+type iterator struct {
+	buffer   []uint64
+	next     int // position of the next marker
+	runBit   bool
+	runLen   uint64 // remaining clean words
+	literals []uint64
+}
+
+func newIterator(b *Bitmap) *iterator {
+	return &iterator{buffer: b.buffer}
+}
+
+// fill loads the next marker if the current one is exhausted, it returns false at the end of the bitmap.
+func (it *iterator) fill() bool {
+	for it.runLen == 0 && len(it.literals) == 0 {
+		if it.next >= len(it.buffer) {
+			it.runBit = false
+			return false
+		}
+		rlw := it.buffer[it.next]
+		nbLiterals := int(literalWords(rlw))
+		it.runBit, it.runLen = runBit(rlw), runningLen(rlw)
+		it.literals = it.buffer[it.next+1 : it.next+1+nbLiterals]
+		it.next += 1 + nbLiterals
+	}
+	return true
+}
+
+func (it *iterator) runWord() uint64 {
+	if it.runBit {
+		return ^uint64(0)
+	}
+	return 0
+}
+
+// Count returns the number of bits set to `1`.
+func (b *Bitmap) Count() int {
+	count := 0
+	it := newIterator(b)
+	for it.fill() {
+		if it.runBit {
+			count += int(it.runLen) * wordSize
+		}
+		for _, w := range it.literals {
+			count += bits.OnesCount64(w)
+		}
+		it.runLen, it.literals = 0, nil
+	}
+	return count
+}
+
+// GetBit returns the bit at position `index` and will panic if index is out of range.
+func (b *Bitmap) GetBit(index int) byte {
+	if index < 0 || index >= b.bitSize {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", index, b.bitSize))
+	}
+
+	word := uint64(index / wordSize)
+	it := newIterator(b)
+	for it.fill() {
+		if word < it.runLen {
+			if it.runBit {
+				return 1
+			}
+			return 0
+		}
+		word -= it.runLen
+		if word < uint64(len(it.literals)) {
+			return byte(it.literals[word] >> (index % wordSize) & 1)
+		}
+		word -= uint64(len(it.literals))
+		it.runLen, it.literals = 0, nil
+	}
+	return 0
+}
+
+// ForEach calls f with the position of each bit set to `1` in increasing order, it stops as soon as f returns false.
+func (b *Bitmap) ForEach(f func(index int) bool) {
+	pos := 0
+	it := newIterator(b)
+	for it.fill() {
+		if it.runBit {
+			for end := pos + int(it.runLen)*wordSize; pos < end; pos++ {
+				if !f(pos) {
+					return
+				}
+			}
+		} else {
+			pos += int(it.runLen) * wordSize
+		}
+
+		for _, w := range it.literals {
+			for w != 0 {
+				if !f(pos + bits.TrailingZeros64(w)) {
+					return
+				}
+				w &= w - 1
+			}
+			pos += wordSize
+		}
+		it.runLen, it.literals = 0, nil
+	}
+}
+
+// BitArray returns the uncompressed bitmap as a bit-array of length Len().
+func (b *Bitmap) BitArray() *bitarray.BitArray {
+	ba := bitarray.New()
+	appendWord := func(w uint64) {
+		if remaining := b.bitSize - ba.Len(); remaining < wordSize {
+			ba.Append64(bits.Reverse64(w)>>(wordSize-remaining), remaining)
+		} else {
+			ba.Append64(bits.Reverse64(w), wordSize)
+		}
+	}
+
+	it := newIterator(b)
+	for ba.Len() < b.bitSize && it.fill() {
+		for ; it.runLen > 0 && ba.Len() < b.bitSize; it.runLen-- {
+			appendWord(it.runWord())
+		}
+		for ; len(it.literals) > 0 && ba.Len() < b.bitSize; it.literals = it.literals[1:] {
+			appendWord(it.literals[0])
+		}
+	}
+	for ba.Len() < b.bitSize {
+		appendWord(0)
+	}
+	return ba
+}
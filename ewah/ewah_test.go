@@ -0,0 +1,183 @@
+package ewah
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// randomBitArray returns a bit-array of n bits made of long runs of 0's and 1's mixed with random words.
+func randomBitArray(r *rand.Rand, n int) *bitarray.BitArray {
+	ba := bitarray.New()
+	for ba.Len() < n {
+		size := r.Intn(300) + 1
+		if size > n-ba.Len() {
+			size = n - ba.Len()
+		}
+		switch r.Intn(3) {
+		case 0:
+			for k := 0; k < size; k++ {
+				ba.AppendZero()
+			}
+		case 1:
+			for k := 0; k < size; k++ {
+				ba.AppendOne()
+			}
+		default:
+			for k := 0; k < size; k++ {
+				ba.AppendBit(byte(r.Intn(2)))
+			}
+		}
+	}
+	return ba
+}
+
+func sameBits(a, b *bitarray.BitArray) bool {
+	return a.Len() == b.Len() && fmt.Sprintf("%X", a.Bytes()) == fmt.Sprintf("%X", b.Bytes())
+}
+
+func TestFromBitArray(t *testing.T) {
+	tests := []struct {
+		id     int
+		bitSeq string
+		words  []uint64
+	}{
+		{0, "", []uint64{0}},
+		{1, "1", []uint64{1 << 33, 1}},
+		{2, "0000000000000000000000000000000000000000000000000000000000000000", []uint64{1 << 1}},
+		{3, "1111111111111111111111111111111111111111111111111111111111111111", []uint64{1<<1 | 1}},
+		{
+			4,
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+				"01",
+			[]uint64{1<<33 | 2<<1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		ba := bitarray.New()
+		ba.AppendString(test.bitSeq)
+
+		b := FromBitArray(ba)
+		if fmt.Sprint(b.buffer) != fmt.Sprint(test.words) {
+			t.Errorf("%d: FromBitArray returned words %v, want %v", test.id, b.buffer, test.words)
+		}
+		if b.Len() != ba.Len() {
+			t.Errorf("%d: returned bad length %d, want: %d", test.id, b.Len(), ba.Len())
+		}
+		if !sameBits(b.BitArray(), ba) {
+			t.Errorf("%d: BitArray returned %X, want %X", test.id, b.BitArray().Bytes(), ba.Bytes())
+		}
+	}
+}
+
+func TestQuerying(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 20; id++ {
+		ba := randomBitArray(r, r.Intn(5000))
+		b := FromBitArray(ba)
+
+		if !sameBits(b.BitArray(), ba) {
+			t.Errorf("%d: BitArray did not return the original bit-array", id)
+		}
+		if b.Count() != ba.Count() {
+			t.Errorf("%d: Count returned %d, want %d", id, b.Count(), ba.Count())
+		}
+
+		ones := []int{}
+		b.ForEach(func(i int) bool {
+			ones = append(ones, i)
+			return true
+		})
+		want := []int{}
+		for i := ba.NextOne(0); i >= 0; i = ba.NextOne(i + 1) {
+			want = append(want, i)
+		}
+		if fmt.Sprint(ones) != fmt.Sprint(want) {
+			t.Errorf("%d: ForEach did not visit the set bits", id)
+		}
+
+		for k := 0; k < 100 && ba.Len() > 0; k++ {
+			i := r.Intn(ba.Len())
+			if b.GetBit(i) != ba.GetBit(i) {
+				t.Errorf("%d: GetBit(%d) returned %d, want %d", id, i, b.GetBit(i), ba.GetBit(i))
+			}
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id := 0; id < 20; id++ {
+		ba := randomBitArray(r, r.Intn(5000)+1)
+		ba.AppendOne()
+
+		b := New()
+		for i := ba.NextOne(0); i >= 0; i = ba.NextOne(i + 1) {
+			b.Set(i)
+		}
+		if !sameBits(b.BitArray(), ba) {
+			t.Errorf("%d: Set did not produce the original bit-array", id)
+		}
+		if b.SizeInWords() != FromBitArray(ba).SizeInWords() {
+			t.Errorf("%d: Set produced %d words, FromBitArray %d", id, b.SizeInWords(), FromBitArray(ba).SizeInWords())
+		}
+	}
+}
+
+func TestLogicalOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for id := 0; id < 30; id++ {
+		ba1, ba2 := randomBitArray(r, r.Intn(8000)), randomBitArray(r, r.Intn(8000))
+		b1, b2 := FromBitArray(ba1), FromBitArray(ba2)
+
+		// pad the shortest bit-array with 0's
+		for ba1.Len() < ba2.Len() {
+			ba1.AppendZero()
+		}
+		for ba2.Len() < ba1.Len() {
+			ba2.AppendZero()
+		}
+
+		for _, op := range []struct {
+			name string
+			got  *Bitmap
+			f    func(ba, other *bitarray.BitArray)
+		}{
+			{"And", b1.And(b2), (*bitarray.BitArray).And},
+			{"Or", b1.Or(b2), (*bitarray.BitArray).Or},
+			{"Xor", b1.Xor(b2), (*bitarray.BitArray).Xor},
+			{"AndNot", b1.AndNot(b2), (*bitarray.BitArray).AndNot},
+		} {
+			want := ba1.Clone()
+			op.f(want, ba2)
+			if !sameBits(op.got.BitArray(), want) {
+				t.Errorf("%d: %s returned bad data", id, op.name)
+			}
+			if op.got.SizeInWords() > FromBitArray(want).SizeInWords() {
+				t.Errorf("%d: %s is not compressed: %d words, want at most %d", id, op.name, op.got.SizeInWords(), FromBitArray(want).SizeInWords())
+			}
+		}
+	}
+}
+
+func TestLongRuns(t *testing.T) {
+	b := New()
+	b.Set(1 << 30)
+	b.Set(1<<31 + 5)
+
+	if b.SizeInWords() != 4 {
+		t.Errorf("bitmap with 2 bits set uses %d words, want 4", b.SizeInWords())
+	}
+	if b.Count() != 2 || b.GetBit(1<<30) != 1 || b.GetBit(1<<30+1) != 0 || b.GetBit(1<<31+5) != 1 {
+		t.Errorf("bitmap with long runs returned bad bits")
+	}
+
+	or := b.Or(b)
+	and := b.And(New())
+	if or.Count() != 2 || or.SizeInWords() != 4 || and.Count() != 0 || and.SizeInWords() > 2 {
+		t.Errorf("logical operations on long runs returned bad bitmaps")
+	}
+}
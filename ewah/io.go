@@ -0,0 +1,127 @@
+package ewah
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized form, as found in git's pack .bitmap files, is made of big-endian integers:
+//	- uint32: the number of bits,
+//	- uint32: the number of 64-bit words of the compressed representation,
+//	- uint64 * number of words: the compressed representation,
+//	- uint32: the position of the last marker.
+
+// ErrInvalidFormat is returned when reading a malformed serialized bitmap.
+var ErrInvalidFormat = errors.New("ewah: invalid format")
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (b *Bitmap) SerializedSizeInBytes() int {
+	return 4 + 4 + 8*len(b.buffer) + 4
+}
+
+// WriteTo writes the bitmap to w in the serialization format used by git.
+func (b *Bitmap) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, b.SerializedSizeInBytes())
+	binary.BigEndian.PutUint32(buf, uint32(b.bitSize))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(b.buffer)))
+	for k, word := range b.buffer {
+		binary.BigEndian.PutUint64(buf[8+8*k:], word)
+	}
+	binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(b.rlw))
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the serialization format used by git.
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFrom replaces the content of the bitmap by the one read from r in the serialization format used by git.
+// It reads exactly the bytes of one bitmap, so bitmaps stored back to back can be read with successive calls.
+func (b *Bitmap) ReadFrom(r io.Reader) (int64, error) {
+	var header [8]byte
+	n, err := io.ReadFull(r, header[:])
+	read := int64(n)
+	if err != nil {
+		return read, unexpectedEOF(err)
+	}
+
+	bitSize := binary.BigEndian.Uint32(header[:])
+	nbWords := binary.BigEndian.Uint32(header[4:])
+	if nbWords == 0 {
+		return read, fmt.Errorf("%w: empty compressed representation", ErrInvalidFormat)
+	}
+
+	// the number of words comes from an untrusted header: grow the buffer as bytes arrive instead of allocating it upfront
+	var data bytes.Buffer
+	m, err := io.CopyN(&data, r, 8*int64(nbWords)+4)
+	read += m
+	if err != nil {
+		return read, unexpectedEOF(err)
+	}
+	buf := data.Bytes()
+
+	buffer := make([]uint64, nbWords)
+	for k := range buffer {
+		buffer[k] = binary.BigEndian.Uint64(buf[8*k:])
+	}
+	rlw := int(binary.BigEndian.Uint32(buf[len(buf)-4:]))
+
+	if err := validate(buffer, rlw, int(bitSize)); err != nil {
+		return read, err
+	}
+
+	b.buffer, b.rlw, b.bitSize = buffer, rlw, int(bitSize)
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the serialization format used by git.
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	n, err := b.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if n != int64(len(data)) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, int64(len(data))-n)
+	}
+	return nil
+}
+
+// validate checks that the markers exactly cover the buffer, that rlw is the position of the last one
+// and that the markers do not describe more words than needed to store bitSize bits.
+func validate(buffer []uint64, rlw int, bitSize int) error {
+	last, words := 0, uint64(0)
+	for pos := 0; pos < len(buffer); {
+		last = pos
+		nbLiterals := literalWords(buffer[pos])
+		words += runningLen(buffer[pos]) + nbLiterals
+		if nbLiterals > uint64(len(buffer)-pos-1) {
+			return fmt.Errorf("%w: marker at position %d overflows the buffer", ErrInvalidFormat, pos)
+		}
+		pos += 1 + int(nbLiterals)
+	}
+
+	if last != rlw {
+		return fmt.Errorf("%w: last marker at position %d, header says %d", ErrInvalidFormat, last, rlw)
+	}
+	if words > (uint64(bitSize)+wordSize-1)/wordSize {
+		return fmt.Errorf("%w: %d words for %d bits", ErrInvalidFormat, words, bitSize)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
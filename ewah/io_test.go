@@ -0,0 +1,221 @@
+package ewah
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// packBitmap is the content of a git pack .bitmap file (version 1).
+type packBitmap struct {
+	// type bitmaps in the order they appear in the file
+	commits, trees, blobs, tags *Bitmap
+	// raw serialized type bitmaps
+	raw [4][]byte
+	// reachability bitmaps, resolved against the bitmap they are XORed with
+	entries []*Bitmap
+}
+
+func readPackBitmap(t *testing.T, path string) *packBitmap {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:4]) != "BITM" || binary.BigEndian.Uint16(data[4:]) != 1 {
+		t.Fatalf("%s is not a version 1 pack bitmap file", path)
+	}
+	nbEntries := int(binary.BigEndian.Uint32(data[8:]))
+	r := bytes.NewReader(data[32:])
+
+	pb := &packBitmap{}
+	for k, b := range []**Bitmap{&pb.commits, &pb.trees, &pb.blobs, &pb.tags} {
+		start := len(data) - r.Len()
+		*b = New()
+		if _, err := (*b).ReadFrom(r); err != nil {
+			t.Fatalf("%s: reading type bitmap %d: %v", path, k, err)
+		}
+		pb.raw[k] = data[start : len(data)-r.Len()]
+	}
+
+	for k := 0; k < nbEntries; k++ {
+		var header [6]byte
+		if _, err := r.Read(header[:]); err != nil {
+			t.Fatal(err)
+		}
+		b := New()
+		if _, err := b.ReadFrom(r); err != nil {
+			t.Fatalf("%s: reading entry %d: %v", path, k, err)
+		}
+		if xorOffset := int(header[4]); xorOffset > 0 {
+			b = b.Xor(pb.entries[k-xorOffset])
+		}
+		pb.entries = append(pb.entries, b)
+	}
+	return pb
+}
+
+func readTypes(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	types := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		types = append(types, strings.TrimSpace(scanner.Text()))
+	}
+	return types
+}
+
+// The fixtures were generated by `git repack -adb` on two small repositories,
+// the .types files list the type of each object in pack order (`git show-index` sorted by offset).
+func TestGitPackBitmaps(t *testing.T) {
+	tests := []struct {
+		id        int
+		name      string
+		reachable int
+	}{
+		{0, "small", 200},
+		{1, "large", 3153},
+	}
+
+	for _, test := range tests {
+		pb := readPackBitmap(t, "testdata/"+test.name+".bitmap")
+		types := readTypes(t, "testdata/"+test.name+".types")
+
+		for k, tb := range []struct {
+			name string
+			b    *Bitmap
+		}{{"commit", pb.commits}, {"tree", pb.trees}, {"blob", pb.blobs}, {"tag", pb.tags}} {
+			count := 0
+			for i, typ := range types {
+				if typ == tb.name {
+					count++
+					if i >= tb.b.Len() || tb.b.GetBit(i) != 1 {
+						t.Errorf("%d: object %d is a %s but is not in the %s bitmap", test.id, i, typ, tb.name)
+					}
+				}
+			}
+			if tb.b.Count() != count {
+				t.Errorf("%d: %s bitmap has %d bits set, want %d", test.id, tb.name, tb.b.Count(), count)
+			}
+
+			// writing back must reproduce git's bytes, re-encoding bit by bit must as well
+			data, _ := tb.b.MarshalBinary()
+			if !bytes.Equal(data, pb.raw[k]) {
+				t.Errorf("%d: MarshalBinary of the %s bitmap does not reproduce the file content", test.id, tb.name)
+			}
+			reencoded := New()
+			tb.b.ForEach(func(i int) bool {
+				reencoded.Set(i)
+				return true
+			})
+			data, _ = reencoded.MarshalBinary()
+			if !bytes.Equal(data, pb.raw[k]) {
+				t.Errorf("%d: re-encoding the %s bitmap with Set does not reproduce git's encoding", test.id, tb.name)
+			}
+		}
+
+		all := pb.commits.Or(pb.trees).Or(pb.blobs).Or(pb.tags)
+		if all.Count() != len(types) || pb.commits.And(pb.blobs).Count() != 0 || pb.trees.And(pb.tags).Count() != 0 {
+			t.Errorf("%d: type bitmaps do not partition the %d objects", test.id, len(types))
+		}
+
+		max := 0
+		for _, e := range pb.entries {
+			if e.Count() > max {
+				max = e.Count()
+			}
+			if e.AndNot(all).Count() != 0 {
+				t.Errorf("%d: reachability bitmap references objects outside of the pack", test.id)
+			}
+		}
+		if max != test.reachable {
+			t.Errorf("%d: the largest reachability bitmap has %d objects, want %d", test.id, max, test.reachable)
+		}
+	}
+}
+
+func TestSerializationRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	var buf bytes.Buffer
+	bitmaps := []*Bitmap{New()}
+	for id := 0; id < 10; id++ {
+		bitmaps = append(bitmaps, FromBitArray(randomBitArray(r, r.Intn(10000))))
+	}
+	for _, b := range bitmaps {
+		if n, err := b.WriteTo(&buf); err != nil || n != int64(b.SerializedSizeInBytes()) {
+			t.Fatalf("WriteTo returned (%d, %v)", n, err)
+		}
+	}
+
+	for id, want := range bitmaps {
+		b := New()
+		if _, err := b.ReadFrom(&buf); err != nil {
+			t.Fatalf("%d: ReadFrom returned error %v", id, err)
+		}
+		if !sameBits(b.BitArray(), want.BitArray()) || b.SizeInWords() != want.SizeInWords() {
+			t.Errorf("%d: ReadFrom did not read back the written bitmap", id)
+		}
+		// appending after reading must keep working
+		b.Set(b.Len() + 100)
+		if b.GetBit(b.Len()-1) != 1 || b.Count() != want.Count()+1 {
+			t.Errorf("%d: Set after ReadFrom did not work correctly", id)
+		}
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	valid, _ := FromBitArray(randomBitArray(rand.New(rand.NewSource(5)), 1000)).MarshalBinary()
+
+	tests := []struct {
+		id   int
+		data []byte
+	}{
+		{0, []byte{}},
+		{1, valid[:len(valid)-1]},
+		{2, append(append([]byte{}, valid...), 0)},
+		// no words
+		{3, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		// a marker announcing a literal word that is missing
+		{4, []byte{0, 0, 0, 64, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0}},
+		// bad position of the last marker
+		{5, []byte{0, 0, 0, 64, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 1}},
+		// two words for 64 bits
+		{6, []byte{0, 0, 0, 64, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		err := New().UnmarshalBinary(test.data)
+		if err == nil {
+			t.Errorf("%d: UnmarshalBinary did not return an error", test.id)
+		}
+		if test.id >= 2 && !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("%d: UnmarshalBinary returned %v, want ErrInvalidFormat", test.id, err)
+		}
+	}
+}
+
+// A header announcing a huge number of words must not allocate them before their bytes are read.
+func TestReadFromLargeHeader(t *testing.T) {
+	header := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := New().UnmarshalBinary(header); err != io.ErrUnexpectedEOF {
+		t.Errorf("UnmarshalBinary returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("UnmarshalBinary allocated %d bytes for a truncated bitmap", allocated)
+	}
+}
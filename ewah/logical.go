@@ -0,0 +1,95 @@
+package ewah
+
+// And returns the bitwise AND of the two bitmaps.
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	return combine(b, other, func(x, y uint64) uint64 { return x & y })
+}
+
+// Or returns the bitwise OR of the two bitmaps.
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	return combine(b, other, func(x, y uint64) uint64 { return x | y })
+}
+
+// Xor returns the bitwise XOR of the two bitmaps.
+func (b *Bitmap) Xor(other *Bitmap) *Bitmap {
+	return combine(b, other, func(x, y uint64) uint64 { return x ^ y })
+}
+
+// AndNot returns the bitwise AND NOT of the two bitmaps: the bits set in b and not in other.
+func (b *Bitmap) AndNot(other *Bitmap) *Bitmap {
+	return combine(b, other, func(x, y uint64) uint64 { return x &^ y })
+}
+
+// combine applies op word by word on the compressed forms without decompressing them:
+// runs of clean words are combined at once and only literal words are visited one by one.
+// The shorter bitmap is considered to be padded with 0's, the result has the length of the longer one.
+func combine(b1, b2 *Bitmap, op func(x, y uint64) uint64) *Bitmap {
+	res := New()
+	it1, it2 := newIterator(b1), newIterator(b2)
+
+	for {
+		ok1, ok2 := it1.fill(), it2.fill()
+		if !ok1 && !ok2 {
+			break
+		}
+		// an exhausted iterator behaves as an endless run of 0's
+		inRun1 := !ok1 || it1.runLen > 0
+		inRun2 := !ok2 || it2.runLen > 0
+
+		switch {
+		case inRun1 && inRun2:
+			n := it1.runLen
+			if !ok1 || (ok2 && it2.runLen < n) {
+				n = it2.runLen
+			}
+			res.addEmptyWords(op(it1.runWord(), it2.runWord()) != 0, n)
+			it1.consumeRun(n)
+			it2.consumeRun(n)
+		case inRun1:
+			n := len(it2.literals)
+			if ok1 && it1.runLen < uint64(n) {
+				n = int(it1.runLen)
+			}
+			w := it1.runWord()
+			for _, l := range it2.literals[:n] {
+				res.addWord(op(w, l))
+			}
+			it1.consumeRun(uint64(n))
+			it2.literals = it2.literals[n:]
+		case inRun2:
+			n := len(it1.literals)
+			if ok2 && it2.runLen < uint64(n) {
+				n = int(it2.runLen)
+			}
+			w := it2.runWord()
+			for _, l := range it1.literals[:n] {
+				res.addWord(op(l, w))
+			}
+			it1.literals = it1.literals[n:]
+			it2.consumeRun(uint64(n))
+		default:
+			n := len(it1.literals)
+			if len(it2.literals) < n {
+				n = len(it2.literals)
+			}
+			for k := 0; k < n; k++ {
+				res.addWord(op(it1.literals[k], it2.literals[k]))
+			}
+			it1.literals = it1.literals[n:]
+			it2.literals = it2.literals[n:]
+		}
+	}
+
+	res.bitSize = b1.bitSize
+	if b2.bitSize > res.bitSize {
+		res.bitSize = b2.bitSize
+	}
+	return res
+}
+
+// consumeRun skips n clean words of the current run, it is a no-op on an exhausted iterator.
+func (it *iterator) consumeRun(n uint64) {
+	if it.runLen >= n {
+		it.runLen -= n
+	}
+}
@@ -0,0 +1,315 @@
+// Package gcs implements Golomb-coded sets, compact static probabilistic sets meant to be shipped over the network,
+// with the byte layout of the compact block filters of BIP158.
+//
+// Each of the N items of a set is hashed with SipHash-2-4 to a value in [0, N*M), the values are sorted
+// and the differences between consecutive values are Golomb-Rice coded with parameter P:
+// the quotient delta >> P in unary (as many 1's followed by a 0) and the P low bits of delta.
+// The false positive rate is about 1/M. The serialized form is N as a Bitcoin CompactSize
+// followed by the bits of the codes, most significant bit first, padded with 0's to a byte boundary.
+package gcs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+const (
+	// KeySize is the size in bytes of the SipHash key.
+	KeySize = 16
+	// BIP158P is the Golomb-Rice parameter of the basic filters of BIP158.
+	BIP158P = 19
+	// BIP158M is the inverse of the false positive rate of the basic filters of BIP158.
+	BIP158M = 784931
+)
+
+// ErrInvalidFormat is returned when reading a malformed serialized set.
+var ErrInvalidFormat = errors.New("gcs: invalid format")
+
+// Filter is an immutable Golomb-coded set.
+type Filter struct {
+	n      uint64
+	p      int
+	m      uint64
+	k0, k1 uint64
+	data   *bitarray.BitArray
+}
+
+// Build returns the set of items, duplicates being ignored, with a false positive rate of at most falsePositiveRate:
+// P = ceil(-log2(falsePositiveRate)) and M = 2^P.
+// It will panic if falsePositiveRate is not between 2^-32 and 1 (excluded).
+func Build(key [KeySize]byte, items [][]byte, falsePositiveRate float64) *Filter {
+	if !(falsePositiveRate >= 1.0/(1<<32) && falsePositiveRate < 1) {
+		panic(fmt.Sprintf("false positive rate should be between 2^-32 and 1 (excluded); given %g", falsePositiveRate))
+	}
+	p := int(math.Ceil(-math.Log2(falsePositiveRate)))
+	return BuildWithParameters(key, items, p, 1<<uint(p))
+}
+
+// BuildBIP158 returns the set of items with the parameters of the basic filters of BIP158.
+// For a block filter, key is made of the first 16 bytes of the block hash in internal byte order.
+func BuildBIP158(key [KeySize]byte, items [][]byte) *Filter {
+	return BuildWithParameters(key, items, BIP158P, BIP158M)
+}
+
+// BuildWithParameters returns the set of items, duplicates being ignored, with the parameters p and m.
+// It will panic if p is not between 1 and 32 or if m is 0.
+func BuildWithParameters(key [KeySize]byte, items [][]byte, p int, m uint64) *Filter {
+	f := newFilter(key, p, m)
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		seen[string(item)] = true
+	}
+	f.n = uint64(len(seen))
+	if f.n > math.MaxUint32 {
+		panic(fmt.Sprintf("number of items should be at most %d; given %d", uint32(math.MaxUint32), f.n))
+	}
+
+	values := make([]uint64, 0, f.n)
+	for item := range seen {
+		values = append(values, f.hash([]byte(item)))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	f.data = bitarray.New()
+	var last uint64
+	for _, v := range values {
+		delta := v - last
+		for q := delta >> uint(p); q > 0; q-- {
+			f.data.AppendOne()
+		}
+		f.data.AppendZero()
+		f.data.Append64(delta, p)
+		last = v
+	}
+	return f
+}
+
+func newFilter(key [KeySize]byte, p int, m uint64) *Filter {
+	if p < 1 || p > 32 || m == 0 {
+		panic(fmt.Sprintf("p should be between 1 and 32 and m positive; given p=%d and m=%d", p, m))
+	}
+	return &Filter{
+		p:  p,
+		m:  m,
+		k0: binary.LittleEndian.Uint64(key[:8]),
+		k1: binary.LittleEndian.Uint64(key[8:]),
+	}
+}
+
+// FromBytes returns the set serialized in data built with the key and the parameters p and m.
+// It will panic if p is not between 1 and 32 or if m is 0.
+func FromBytes(key [KeySize]byte, p int, m uint64, data []byte) (*Filter, error) {
+	f := newFilter(key, p, m)
+
+	n, size, err := readCompactSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if n > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: %d items", ErrInvalidFormat, n)
+	}
+	f.n = n
+	f.data = bitarray.New()
+	f.data.AppendBytes(data[size:], 0)
+
+	// check that the codes of the n values are complete and only followed by padding bits
+	d := f.decoder()
+	for i := uint64(0); i < n; i++ {
+		if _, ok := d.next(); !ok {
+			return nil, fmt.Errorf("%w: truncated after %d values out of %d", ErrInvalidFormat, i, n)
+		}
+	}
+	if remaining := f.data.Len() - d.pos; remaining >= 8 {
+		return nil, fmt.Errorf("%w: %d trailing bits", ErrInvalidFormat, remaining)
+	} else if remaining > 0 && f.data.Extract(d.pos, f.data.Len()) != 0 {
+		return nil, fmt.Errorf("%w: padding bits are not 0", ErrInvalidFormat)
+	}
+	return f, nil
+}
+
+// Bytes returns the serialized form of the set.
+func (f *Filter) Bytes() []byte {
+	return append(appendCompactSize(nil, f.n), f.data.Bytes()...)
+}
+
+// N returns the number of items of the set.
+func (f *Filter) N() int {
+	return int(f.n)
+}
+
+// P returns the Golomb-Rice parameter of the set.
+func (f *Filter) P() int {
+	return f.p
+}
+
+// M returns the inverse of the false positive rate of the set.
+func (f *Filter) M() uint64 {
+	return f.m
+}
+
+// hash maps an item to [0, N*M).
+func (f *Filter) hash(item []byte) uint64 {
+	hi, _ := bits.Mul64(siphash24(f.k0, f.k1, item), f.n*f.m)
+	return hi
+}
+
+// Match returns whether item may be in the set. A false result means that item is not in the set.
+// The codes are decoded until a value greater than or equal to the hash of item is found.
+func (f *Filter) Match(item []byte) bool {
+	if f.n == 0 {
+		return false
+	}
+
+	target := f.hash(item)
+	d := f.decoder()
+	for v, ok := d.next(); ok; v, ok = d.next() {
+		if v >= target {
+			return v == target
+		}
+	}
+	return false
+}
+
+// MatchAny returns whether at least one of items may be in the set.
+// The set is decoded once, in parallel with the sorted hashes of the items.
+func (f *Filter) MatchAny(items [][]byte) bool {
+	if f.n == 0 || len(items) == 0 {
+		return false
+	}
+
+	targets := make([]uint64, len(items))
+	for i, item := range items {
+		targets[i] = f.hash(item)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+
+	d := f.decoder()
+	v, ok := d.next()
+	for _, target := range targets {
+		for ok && v < target {
+			v, ok = d.next()
+		}
+		if !ok {
+			return false
+		}
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// decoder decodes the values of a set one after the other.
+type decoder struct {
+	f     *Filter
+	pos   int
+	left  uint64 // number of values not decoded yet
+	value uint64
+}
+
+func (f *Filter) decoder() *decoder {
+	return &decoder{f: f, left: f.n}
+}
+
+// next returns the next value, the returned boolean is false when all the values were decoded or the data is truncated.
+func (d *decoder) next() (uint64, bool) {
+	data := d.f.data
+	if d.left == 0 {
+		return 0, false
+	}
+
+	// the quotient is the number of leading 1's, counted 64 bits at a time
+	var q uint64
+	for d.pos < data.Len() {
+		n := data.Len() - d.pos
+		if n > 64 {
+			n = 64
+		}
+		w := data.Extract(d.pos, d.pos+n) << uint(64-n)
+		ones := bits.LeadingZeros64(^w)
+		if ones > n {
+			ones = n
+		}
+		q += uint64(ones)
+		d.pos += ones
+		if ones < n {
+			break
+		}
+	}
+	if d.pos+1+d.f.p > data.Len() {
+		return 0, false
+	}
+	d.pos++ // terminating 0
+	r := data.Extract(d.pos, d.pos+d.f.p)
+	d.pos += d.f.p
+
+	d.value += q<<uint(d.f.p) | r
+	d.left--
+	return d.value, true
+}
+
+// appendCompactSize appends the Bitcoin variable length encoding of n to buf.
+func appendCompactSize(buf []byte, n uint64) []byte {
+	var b [9]byte
+	switch {
+	case n < 0xFD:
+		return append(buf, byte(n))
+	case n <= math.MaxUint16:
+		b[0] = 0xFD
+		binary.LittleEndian.PutUint16(b[1:], uint16(n))
+		return append(buf, b[:3]...)
+	case n <= math.MaxUint32:
+		b[0] = 0xFE
+		binary.LittleEndian.PutUint32(b[1:], uint32(n))
+		return append(buf, b[:5]...)
+	default:
+		b[0] = 0xFF
+		binary.LittleEndian.PutUint64(b[1:], n)
+		return append(buf, b[:]...)
+	}
+}
+
+// readCompactSize returns the integer encoded at the beginning of data and the size of its encoding.
+// Non-canonical encodings are rejected as they are by Bitcoin nodes.
+func readCompactSize(data []byte) (uint64, int, error) {
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("%w: missing number of items", ErrInvalidFormat)
+	}
+
+	var n uint64
+	var size int
+	switch data[0] {
+	case 0xFD:
+		size = 3
+	case 0xFE:
+		size = 5
+	case 0xFF:
+		size = 9
+	default:
+		return uint64(data[0]), 1, nil
+	}
+	if len(data) < size {
+		return 0, 0, fmt.Errorf("%w: truncated number of items", ErrInvalidFormat)
+	}
+
+	var min uint64
+	switch size {
+	case 3:
+		n, min = uint64(binary.LittleEndian.Uint16(data[1:])), 0xFD
+	case 5:
+		n, min = uint64(binary.LittleEndian.Uint32(data[1:])), math.MaxUint16+1
+	default:
+		n, min = binary.LittleEndian.Uint64(data[1:]), math.MaxUint32+1
+	}
+	if n < min {
+		return 0, 0, fmt.Errorf("%w: non-canonical number of items", ErrInvalidFormat)
+	}
+	return n, size, nil
+}
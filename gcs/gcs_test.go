@@ -0,0 +1,228 @@
+package gcs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// blockKey returns the key of a block filter, the first 16 bytes of the block hash in internal byte order.
+func blockKey(t *testing.T, blockHash string) [KeySize]byte {
+	h := decodeHex(t, blockHash)
+	var key [KeySize]byte
+	for i := range key {
+		key[i] = h[len(h)-1-i]
+	}
+	return key
+}
+
+func item(i int) []byte {
+	return []byte(fmt.Sprintf("item-%d", i))
+}
+
+// Vectors of BIP158 (testnet-19.json): the basic filter of the testnet genesis block holds its coinbase output script.
+func TestBIP158(t *testing.T) {
+	tests := []struct {
+		id        int
+		blockHash string
+		items     []string
+		filter    string
+	}{
+		{
+			0,
+			"000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943",
+			[]string{"4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac"},
+			"019dfca8",
+		},
+		{
+			1,
+			"000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943",
+			[]string{},
+			"00",
+		},
+	}
+
+	for _, test := range tests {
+		key := blockKey(t, test.blockHash)
+		items := [][]byte{}
+		for _, s := range test.items {
+			items = append(items, decodeHex(t, s))
+		}
+
+		f := BuildBIP158(key, items)
+		if got := hex.EncodeToString(f.Bytes()); got != test.filter {
+			t.Errorf("%d: Bytes returned bad data %s, want %s", test.id, got, test.filter)
+		}
+
+		g, err := FromBytes(key, BIP158P, BIP158M, decodeHex(t, test.filter))
+		if err != nil {
+			t.Fatalf("%d: FromBytes returned error %v", test.id, err)
+		}
+		if g.N() != len(items) {
+			t.Errorf("%d: N returned %d, want %d", test.id, g.N(), len(items))
+		}
+		for _, it := range items {
+			if !g.Match(it) {
+				t.Errorf("%d: Match returned false for item %x", test.id, it)
+			}
+		}
+		if g.Match([]byte("not an output script")) {
+			t.Errorf("%d: Match returned true for an item not in the set", test.id)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		id                int
+		n                 int
+		falsePositiveRate float64
+		p                 int
+	}{
+		{0, 1, 0.5, 1},
+		{1, 500, 0.01, 7},
+		{2, 1000, 1.0 / (1 << 10), 10},
+		{3, 200, 1e-6, 20},
+	}
+
+	var key [KeySize]byte
+	copy(key[:], "0123456789abcdef")
+	for _, test := range tests {
+		items := [][]byte{}
+		for i := 0; i < test.n; i++ {
+			items = append(items, item(i), item(i))
+		}
+		f := Build(key, items, test.falsePositiveRate)
+		if f.N() != test.n || f.P() != test.p || f.M() != 1<<uint(test.p) {
+			t.Errorf("%d: Build returned a set with N=%d, P=%d, M=%d, want N=%d, P=%d, M=%d",
+				test.id, f.N(), f.P(), f.M(), test.n, test.p, 1<<uint(test.p))
+		}
+
+		for i := 0; i < test.n; i++ {
+			if !f.Match(item(i)) {
+				t.Fatalf("%d: Match returned false for item %d", test.id, i)
+			}
+		}
+
+		const queries = 20000
+		falsePositives := 0
+		for i := test.n; i < test.n+queries; i++ {
+			if f.Match(item(i)) {
+				falsePositives++
+			}
+		}
+		if rate := float64(falsePositives) / queries; rate > 1.5*test.falsePositiveRate+1e-4 {
+			t.Errorf("%d: false positive rate is %g, want at most %g", test.id, rate, test.falsePositiveRate)
+		}
+
+		// about P+2 bits per item
+		if size := 8 * len(f.Bytes()); size > (test.p+2)*test.n+32 {
+			t.Errorf("%d: %d bits for %d items, want about %d", test.id, size, test.n, (test.p+2)*test.n)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	var key [KeySize]byte
+	items := [][]byte{}
+	for i := 0; i < 1000; i++ {
+		items = append(items, item(i))
+	}
+	f := BuildBIP158(key, items)
+
+	tests := []struct {
+		id    int
+		items [][]byte
+		want  bool
+	}{
+		{0, nil, false},
+		{1, [][]byte{item(5000), item(6000)}, false},
+		{2, [][]byte{item(5000), item(999)}, true},
+		{3, [][]byte{item(0)}, true},
+		{4, items[500:], true},
+	}
+
+	for _, test := range tests {
+		if got := f.MatchAny(test.items); got != test.want {
+			t.Errorf("%d: MatchAny returned %t, want %t", test.id, got, test.want)
+		}
+	}
+
+	if BuildBIP158(key, nil).MatchAny(items) {
+		t.Errorf("MatchAny on an empty set returned true")
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	var key [KeySize]byte
+	items := [][]byte{}
+	for i := 0; i < 300; i++ {
+		items = append(items, item(i))
+	}
+	valid := BuildBIP158(key, items).Bytes()
+
+	f, err := FromBytes(key, BIP158P, BIP158M, valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(f.Bytes(), valid) {
+		t.Errorf("Bytes returned different data after FromBytes")
+	}
+
+	tests := []struct {
+		id   int
+		data []byte
+	}{
+		{0, nil},
+		{1, valid[:len(valid)-1]},
+		{2, append(append([]byte{}, valid...), 0)},
+		{3, []byte{0xFD, 0x01}},
+		{4, []byte{0xFD, 0x01, 0x00}},
+		{5, append([]byte{0xFE, 0x2C, 0x01, 0x00, 0x00}, valid[3:]...)},
+		{6, []byte{0x01, 0x9d, 0xfc, 0xa9}},
+		{7, []byte{0xFF, 0, 0, 0, 0, 1, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		if _, err := FromBytes(key, BIP158P, BIP158M, test.data); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("%d: FromBytes returned error %v, want %v", test.id, err, ErrInvalidFormat)
+		}
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	tests := []struct {
+		id   int
+		n    uint64
+		want []byte
+	}{
+		{0, 0, []byte{0x00}},
+		{1, 0xFC, []byte{0xFC}},
+		{2, 0xFD, []byte{0xFD, 0xFD, 0x00}},
+		{3, 0xFFFF, []byte{0xFD, 0xFF, 0xFF}},
+		{4, 0x10000, []byte{0xFE, 0x00, 0x00, 0x01, 0x00}},
+		{5, 0x100000000, []byte{0xFF, 0, 0, 0, 0, 1, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		got := appendCompactSize(nil, test.n)
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%d: appendCompactSize returned bad data %x, want %x", test.id, got, test.want)
+		}
+		n, size, err := readCompactSize(got)
+		if err != nil || n != test.n || size != len(got) {
+			t.Errorf("%d: readCompactSize returned (%d, %d, %v), want (%d, %d, nil)", test.id, n, size, err, test.n, len(got))
+		}
+	}
+}
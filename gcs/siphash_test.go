@@ -0,0 +1,39 @@
+package gcs
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// Test vectors of the SipHash reference implementation: the key is 00 01 .. 0f and the message 00 01 .. n-1.
+func TestSiphash24(t *testing.T) {
+	tests := []struct {
+		id   int
+		n    int
+		want uint64
+	}{
+		{0, 0, 0x726fdb47dd0e0e31},
+		{1, 1, 0x74f839c593dc67fd},
+		{2, 2, 0x0d6c8009d9a94f5a},
+		{3, 7, 0xab0200f58b01d137},
+		{4, 8, 0x93f5f5799a932462},
+		{5, 15, 0xa129ca6149be45e5},
+		{6, 63, 0x958a324ceb064572},
+	}
+
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	k0, k1 := binary.LittleEndian.Uint64(key), binary.LittleEndian.Uint64(key[8:])
+
+	for _, test := range tests {
+		msg := make([]byte, test.n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		if got := siphash24(k0, k1, msg); got != test.want {
+			t.Errorf("%d: siphash24 returned %#x, want %#x", test.id, got, test.want)
+		}
+	}
+}
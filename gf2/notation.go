@@ -0,0 +1,168 @@
+package gf2
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Notation is one of the usual hexadecimal representations of a CRC generator polynomial of degree width.
+type Notation int
+
+const (
+	// Normal notation: the coefficients of x^(width-1) down to x^0 from the most significant bit,
+	// the leading term is implicit. CRC-32 is 0x04C11DB7.
+	Normal Notation = iota
+	// Reversed notation: the coefficients of x^0 up to x^(width-1) from the most significant bit,
+	// the leading term is implicit. CRC-32 is 0xEDB88320.
+	Reversed
+	// Koopman notation: the coefficients of x^width down to x^1 from the most significant bit,
+	// the constant term is implicit. CRC-32 is 0x82608EDB.
+	Koopman
+	// Full notation: all the coefficients, x^width being the most significant bit, so width is at most 63.
+	// CRC-32 is 0x104C11DB7.
+	Full
+)
+
+func (n Notation) String() string {
+	switch n {
+	case Normal:
+		return "normal"
+	case Reversed:
+		return "reversed"
+	case Koopman:
+		return "Koopman"
+	case Full:
+		return "full"
+	}
+	return fmt.Sprintf("Notation(%d)", int(n))
+}
+
+// FromCRC returns the polynomial of degree width represented by v in the notation n.
+// It will panic if width is not between 1 and 64 (63 for the full notation)
+// or if v is not a polynomial of degree width in the notation n.
+func FromCRC(v uint64, width int, n Notation) Poly {
+	checkWidth(width, n)
+	if !validCRC(v, width, n) {
+		panic(fmt.Sprintf("%#x is not a polynomial of degree %d in %s notation", v, width, n))
+	}
+
+	// low holds the coefficients of x^0 to x^(width-1) in its lowest bits, the leading term is added below
+	var low uint64
+	switch n {
+	case Normal:
+		low = v
+	case Reversed:
+		low = bits.Reverse64(v) >> uint(64-width)
+	case Koopman:
+		low = v<<1 | 1
+	case Full:
+		low = v
+	default:
+		panic(fmt.Sprintf("unknown notation %d", int(n)))
+	}
+
+	w := []uint64{low, 0}
+	if width < 64 {
+		w[0] = low&(1<<uint(width)-1) | 1<<uint(width)
+	} else {
+		w[1] = 1
+	}
+	return fromWords(w)
+}
+
+func checkWidth(width int, n Notation) {
+	maxWidth := 64
+	if n == Full {
+		maxWidth = 63
+	}
+	if width < 1 || width > maxWidth {
+		panic(fmt.Sprintf("width should be between 1 and %d in %s notation, given %d", maxWidth, n, width))
+	}
+}
+
+// validCRC returns whether v represents a polynomial of degree width in the notation n.
+func validCRC(v uint64, width int, n Notation) bool {
+	switch n {
+	case Full:
+		return v>>uint(width) == 1
+	case Koopman:
+		return v>>uint(width-1) == 1
+	default:
+		return width == 64 || v>>uint(width) == 0
+	}
+}
+
+// ParseCRC parses a hexadecimal number, with or without a "0x" prefix, representing a polynomial of degree width
+// in the notation n. It will panic if width is not between 1 and 64 (63 for the full notation).
+func ParseCRC(s string, width int, n Notation) (Poly, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"), 16, 64)
+	if err != nil {
+		return Poly{}, fmt.Errorf("gf2: invalid hexadecimal polynomial %q: %w", s, err)
+	}
+
+	checkWidth(width, n)
+	if !validCRC(v, width, n) {
+		return Poly{}, fmt.Errorf("gf2: %q is not a polynomial of degree %d in %s notation", s, width, n)
+	}
+	return FromCRC(v, width, n), nil
+}
+
+// CRC returns the representation of the polynomial in the notation n, the width being its degree.
+// It will panic if the degree is not between 1 and 64 (63 for the full notation).
+func (p Poly) CRC(n Notation) uint64 {
+	width := p.Degree()
+	checkWidth(width, n)
+
+	w := p.words()
+	low := w[0]
+	if width < 64 {
+		low &= 1<<uint(width) - 1
+	}
+	switch n {
+	case Normal:
+		return low
+	case Reversed:
+		return bits.Reverse64(low) >> uint(64-width)
+	case Koopman:
+		return w[0]>>1 | uint64(p.Coeff(64))<<63
+	case Full:
+		return w[0]
+	}
+	panic(fmt.Sprintf("unknown notation %d", int(n)))
+}
+
+// MaxParseDegree is the largest exponent accepted by Parse, it bounds the memory used by untrusted inputs.
+const MaxParseDegree = 1 << 20
+
+// Parse parses a polynomial written as a sum of terms such as "x^8 + x^4 + x^3 + x + 1", the format of String.
+// A term is "1", "x" or "x^e" with e at most MaxParseDegree, spaces are ignored and a term given twice cancels out.
+func Parse(s string) (Poly, error) {
+	s = strings.Replace(s, " ", "", -1)
+	if s == "0" {
+		return Poly{}, nil
+	}
+
+	exponents := []int{}
+	for _, term := range strings.Split(s, "+") {
+		switch {
+		case term == "1":
+			exponents = append(exponents, 0)
+		case term == "x":
+			exponents = append(exponents, 1)
+		case strings.HasPrefix(term, "x^"):
+			e, err := strconv.Atoi(term[2:])
+			if err != nil || e < 0 {
+				return Poly{}, fmt.Errorf("gf2: invalid term %q", term)
+			}
+			if e > MaxParseDegree {
+				return Poly{}, fmt.Errorf("gf2: exponent of %q is larger than %d", term, MaxParseDegree)
+			}
+			exponents = append(exponents, e)
+		default:
+			return Poly{}, fmt.Errorf("gf2: invalid term %q", term)
+		}
+	}
+	return FromExponents(exponents...), nil
+}
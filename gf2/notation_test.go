@@ -0,0 +1,107 @@
+package gf2
+
+import "testing"
+
+func TestCRCNotations(t *testing.T) {
+	tests := []struct {
+		id       int
+		name     string
+		width    int
+		normal   uint64
+		reversed uint64
+		koopman  uint64
+		poly     string
+	}{
+		{0, "CRC-32", 32, 0x04C11DB7, 0xEDB88320, 0x82608EDB,
+			"x^32 + x^26 + x^23 + x^22 + x^16 + x^12 + x^11 + x^10 + x^8 + x^7 + x^5 + x^4 + x^2 + x + 1"},
+		{1, "CRC-32C", 32, 0x1EDC6F41, 0x82F63B78, 0x8F6E37A0,
+			"x^32 + x^28 + x^27 + x^26 + x^25 + x^23 + x^22 + x^20 + x^19 + x^18 + x^14 + x^13 + x^11 + x^10 + x^9 + x^8 + x^6 + 1"},
+		{2, "CRC-16-CCITT", 16, 0x1021, 0x8408, 0x8810, "x^16 + x^12 + x^5 + 1"},
+		{3, "CRC-8", 8, 0x07, 0xE0, 0x83, "x^8 + x^2 + x + 1"},
+		{4, "CRC-5-USB", 5, 0x05, 0x14, 0x12, "x^5 + x^2 + 1"},
+		{5, "CRC-1", 1, 0x1, 0x1, 0x1, "x + 1"},
+		{6, "CRC-64-ECMA", 64, 0x42F0E1EBA9EA3693, 0xC96C5795D7870F42, 0xA17870F5D4F51B49,
+			"x^64 + x^62 + x^57 + x^55 + x^54 + x^53 + x^52 + x^47 + x^46 + x^45 + x^40 + x^39 + x^38 + x^37 + x^35 + x^33 + " +
+				"x^32 + x^31 + x^29 + x^27 + x^24 + x^23 + x^22 + x^21 + x^19 + x^17 + x^13 + x^12 + x^10 + x^9 + x^7 + x^4 + x + 1"},
+	}
+
+	for _, test := range tests {
+		for _, n := range []struct {
+			notation Notation
+			v        uint64
+		}{{Normal, test.normal}, {Reversed, test.reversed}, {Koopman, test.koopman}} {
+			p := FromCRC(n.v, test.width, n.notation)
+			if p.String() != test.poly {
+				t.Errorf("%d: %s in %s notation returned bad data %s, want %s", test.id, test.name, n.notation, p, test.poly)
+			}
+			if got := p.CRC(n.notation); got != n.v {
+				t.Errorf("%d: CRC(%s) returned %#x, want %#x", test.id, n.notation, got, n.v)
+			}
+		}
+
+		if test.width < 64 {
+			full := test.normal | 1<<uint(test.width)
+			if got := FromCRC(full, test.width, Full).CRC(Full); got != full {
+				t.Errorf("%d: CRC(full) returned %#x, want %#x", test.id, got, full)
+			}
+		}
+	}
+}
+
+func TestParseCRC(t *testing.T) {
+	tests := []struct {
+		id       int
+		s        string
+		width    int
+		notation Notation
+		want     string
+		ok       bool
+	}{
+		{0, "0x04C11DB7", 32, Normal, "x^32 + x^26 + x^23 + x^22 + x^16 + x^12 + x^11 + x^10 + x^8 + x^7 + x^5 + x^4 + x^2 + x + 1", true},
+		{1, "104c11db7", 32, Full, "x^32 + x^26 + x^23 + x^22 + x^16 + x^12 + x^11 + x^10 + x^8 + x^7 + x^5 + x^4 + x^2 + x + 1", true},
+		{2, "0X8408", 16, Reversed, "x^16 + x^12 + x^5 + 1", true},
+		{3, "0x8810", 16, Koopman, "x^16 + x^12 + x^5 + 1", true},
+		{4, "0x1021", 16, Koopman, "", false},
+		{5, "0x11021", 16, Normal, "", false},
+		{6, "0x1021", 16, Full, "", false},
+		{7, "0xZZ", 8, Normal, "", false},
+		{8, "", 8, Normal, "", false},
+		{9, "0x10000000000000000", 64, Normal, "", false},
+	}
+
+	for _, test := range tests {
+		p, err := ParseCRC(test.s, test.width, test.notation)
+		if (err == nil) != test.ok {
+			t.Errorf("%d: ParseCRC returned error %v, want ok=%t", test.id, err, test.ok)
+			continue
+		}
+		if test.ok && p.String() != test.want {
+			t.Errorf("%d: ParseCRC returned bad data %s, want %s", test.id, p, test.want)
+		}
+	}
+}
+
+func TestCRCNotationsPanics(t *testing.T) {
+	tests := []struct {
+		id int
+		f  func()
+	}{
+		{0, func() { FromCRC(1, 0, Normal) }},
+		{1, func() { FromCRC(1, 65, Reversed) }},
+		{2, func() { FromCRC(1<<63, 64, Full) }},
+		{3, func() { FromCRC(0x100, 8, Normal) }},
+		{4, func() { FromUint64(1).CRC(Normal) }},
+		{5, func() { FromExponents(70, 0).CRC(Normal) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", test.id)
+				}
+			}()
+			test.f()
+		}()
+	}
+}
@@ -0,0 +1,366 @@
+// Package gf2 implements the arithmetic of polynomials over GF(2), the field of two elements,
+// as used to design CRCs, LFSRs and BCH codes.
+//
+// The coefficient of x^i of a polynomial is the bit at position i of a bit-array, which is as long
+// as needed to hold the coefficient of the leading term. Computations are done on 64 coefficients at a time.
+package gf2
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// Poly is an immutable polynomial over GF(2). The zero value is the zero polynomial.
+type Poly struct {
+	coeffs *bitarray.BitArray
+}
+
+// New returns the polynomial whose coefficient of x^i is the bit at position i of coeffs.
+func New(coeffs *bitarray.BitArray) Poly {
+	return fromWords(toWords(coeffs))
+}
+
+// FromUint64 returns the polynomial whose coefficient of x^i is the bit i of v (starting from the least significant bit).
+func FromUint64(v uint64) Poly {
+	return fromWords([]uint64{v})
+}
+
+// FromExponents returns the sum of the x^e for e in exponents, an exponent given twice cancels out.
+// It will panic if an exponent is negative.
+func FromExponents(exponents ...int) Poly {
+	var w []uint64
+	for _, e := range exponents {
+		if e < 0 {
+			panic(fmt.Sprintf("exponents should not be negative, given %d", e))
+		}
+		for len(w) <= e/64 {
+			w = append(w, 0)
+		}
+		w[e/64] ^= 1 << uint(e%64)
+	}
+	return fromWords(w)
+}
+
+// toWords returns the coefficients of ba, the coefficient of x^i being the bit i%64 of the word i/64.
+func toWords(ba *bitarray.BitArray) []uint64 {
+	if ba == nil {
+		return nil
+	}
+
+	n := ba.Len()
+	w := make([]uint64, (n+63)/64)
+	for i := 0; i < n; i += 64 {
+		end := i + 64
+		if end > n {
+			end = n
+		}
+		w[i/64] = bits.Reverse64(ba.Extract(i, end) << uint(64-(end-i)))
+	}
+	return w
+}
+
+// fromWords is the inverse of toWords, trailing zero coefficients are dropped.
+func fromWords(w []uint64) Poly {
+	d := degree(w)
+	ba := bitarray.New()
+	for i := 0; i <= d; i += 64 {
+		n := d + 1 - i
+		if n > 64 {
+			n = 64
+		}
+		ba.Append64(bits.Reverse64(w[i/64])>>uint(64-n), n)
+	}
+	return Poly{coeffs: ba}
+}
+
+// degree returns the degree of the polynomial of coefficients w, -1 for the zero polynomial.
+func degree(w []uint64) int {
+	for k := len(w) - 1; k >= 0; k-- {
+		if w[k] != 0 {
+			return 64*k + bits.Len64(w[k]) - 1
+		}
+	}
+	return -1
+}
+
+func (p Poly) words() []uint64 {
+	return toWords(p.coeffs)
+}
+
+// Degree returns the degree of the polynomial, -1 for the zero polynomial.
+func (p Poly) Degree() int {
+	if p.coeffs == nil {
+		return -1
+	}
+	return p.coeffs.Len() - 1
+}
+
+// IsZero returns whether p is the zero polynomial.
+func (p Poly) IsZero() bool {
+	return p.Degree() < 0
+}
+
+// Coeff returns the coefficient of x^i, 0 if i is greater than the degree. It will panic if i is negative.
+func (p Poly) Coeff(i int) byte {
+	if i < 0 {
+		panic(fmt.Sprintf("exponents should not be negative, given %d", i))
+	}
+	if i > p.Degree() {
+		return 0
+	}
+	return p.coeffs.GetBit(i)
+}
+
+// BitArray returns a copy of the coefficients, of length Degree() + 1.
+func (p Poly) BitArray() *bitarray.BitArray {
+	if p.coeffs == nil {
+		return bitarray.New()
+	}
+	return p.coeffs.Clone()
+}
+
+// Equal returns whether p and q are the same polynomial.
+func (p Poly) Equal(q Poly) bool {
+	if p.Degree() != q.Degree() {
+		return false
+	}
+	pw, qw := p.words(), q.words()
+	for k := range pw {
+		if pw[k] != qw[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the polynomial with its terms by decreasing degree, such as "x^8 + x^4 + x^3 + x + 1".
+func (p Poly) String() string {
+	if p.IsZero() {
+		return "0"
+	}
+
+	terms := []string{}
+	for i := p.Degree(); i >= 0; i-- {
+		if p.coeffs.GetBit(i) == 0 {
+			continue
+		}
+		switch i {
+		case 0:
+			terms = append(terms, "1")
+		case 1:
+			terms = append(terms, "x")
+		default:
+			terms = append(terms, fmt.Sprintf("x^%d", i))
+		}
+	}
+	return strings.Join(terms, " + ")
+}
+
+// Add returns p + q, which is also p - q.
+func (p Poly) Add(q Poly) Poly {
+	pw, qw := p.words(), q.words()
+	if len(pw) < len(qw) {
+		pw, qw = qw, pw
+	}
+	for k := range qw {
+		pw[k] ^= qw[k]
+	}
+	return fromWords(pw)
+}
+
+// Mul returns p * q, computed with carry-less multiplications.
+func (p Poly) Mul(q Poly) Poly {
+	return fromWords(mul(p.words(), q.words()))
+}
+
+func mul(a, b []uint64) []uint64 {
+	res := make([]uint64, len(a)+len(b))
+	for j, bw := range b {
+		if bw == 0 {
+			continue
+		}
+		t := newMulTable(bw)
+		for i, aw := range a {
+			if aw == 0 {
+				continue
+			}
+			hi, lo := t.mul(aw)
+			res[i+j] ^= lo
+			res[i+j+1] ^= hi
+		}
+	}
+	return res
+}
+
+// mulTable holds the 128-bit products of a word by the 16 polynomials of degree less than 4,
+// to multiply it by another word 4 bits at a time.
+type mulTable struct {
+	lo, hi [16]uint64
+}
+
+func newMulTable(b uint64) (t mulTable) {
+	t.lo[1] = b
+	for k := 2; k < 16; k++ {
+		if k%2 == 0 {
+			t.lo[k], t.hi[k] = t.lo[k/2]<<1, t.hi[k/2]<<1|t.lo[k/2]>>63
+		} else {
+			t.lo[k], t.hi[k] = t.lo[k-1]^b, t.hi[k-1]
+		}
+	}
+	return t
+}
+
+// mul returns the carry-less product of a and the word of the table.
+func (t *mulTable) mul(a uint64) (hi, lo uint64) {
+	for s := uint(0); a != 0; s, a = s+4, a>>4 {
+		k := a & 0xF
+		lo ^= t.lo[k] << s
+		hi ^= t.hi[k] << s
+		if s != 0 {
+			hi ^= t.lo[k] >> (64 - s)
+		}
+	}
+	return hi, lo
+}
+
+// xorShifted adds b * x^shift to dst, which must be long enough.
+func xorShifted(dst, b []uint64, shift int) {
+	k, s := shift/64, uint(shift%64)
+	for j, w := range b {
+		if w == 0 {
+			continue
+		}
+		dst[j+k] ^= w << s
+		if s != 0 {
+			dst[j+k+1] ^= w >> (64 - s)
+		}
+	}
+}
+
+// DivMod returns the quotient and the remainder of the division of p by q.
+// It will panic if q is the zero polynomial.
+func (p Poly) DivMod(q Poly) (quo, rem Poly) {
+	qw, rw := divMod(p.words(), q.words())
+	return fromWords(qw), fromWords(rw)
+}
+
+// Mod returns the remainder of the division of p by q. It will panic if q is the zero polynomial.
+func (p Poly) Mod(q Poly) Poly {
+	_, rem := p.DivMod(q)
+	return rem
+}
+
+func divMod(a, b []uint64) (quo, rem []uint64) {
+	db := degree(b)
+	if db < 0 {
+		panic("division by the zero polynomial")
+	}
+
+	rem = make([]uint64, len(a)+1)
+	copy(rem, a)
+	quo = make([]uint64, len(a)+1)
+	for dr := degree(rem); dr >= db; dr = degree(rem) {
+		shift := dr - db
+		quo[shift/64] |= 1 << uint(shift%64)
+		xorShifted(rem, b[:db/64+1], shift)
+	}
+	return trim(quo), trim(rem)
+}
+
+// trim drops the words above the degree.
+func trim(w []uint64) []uint64 {
+	return w[:(degree(w)+64)/64]
+}
+
+// GCD returns the greatest common divisor of p and q, the zero polynomial if both are zero.
+func (p Poly) GCD(q Poly) Poly {
+	a, b := p.words(), q.words()
+	for degree(b) >= 0 {
+		_, r := divMod(a, b)
+		a, b = b, r
+	}
+	return fromWords(a)
+}
+
+// ModExp returns p^e mod m. It will panic if m is the zero polynomial.
+func (p Poly) ModExp(e uint64, m Poly) Poly {
+	mw := m.words()
+	return fromWords(modExp(p.words(), e, mw))
+}
+
+func mulMod(a, b, m []uint64) []uint64 {
+	_, r := divMod(mul(a, b), m)
+	return r
+}
+
+func modExp(a []uint64, e uint64, m []uint64) []uint64 {
+	_, base := divMod(a, m)
+	res := []uint64{1}
+	if degree(m) == 0 {
+		res = []uint64{0}
+	}
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			res = mulMod(res, base, m)
+		}
+		base = mulMod(base, base, m)
+	}
+	return res
+}
+
+// xPow2k returns x^(2^k) mod m by k successive squarings.
+func xPow2k(k int, m []uint64) []uint64 {
+	_, r := divMod([]uint64{2}, m)
+	for i := 0; i < k; i++ {
+		r = mulMod(r, r, m)
+	}
+	return r
+}
+
+// IsIrreducible returns whether p can not be written as the product of two polynomials of positive degree.
+// It uses Rabin's test: p of degree n is irreducible if and only if x^(2^n) = x mod p
+// and gcd(x^(2^(n/q)) - x, p) = 1 for every prime factor q of n.
+func (p Poly) IsIrreducible() bool {
+	n := p.Degree()
+	if n < 1 {
+		return false
+	}
+
+	m := p.words()
+	x := fromWords([]uint64{2}).Mod(p)
+	if !fromWords(xPow2k(n, m)).Equal(x) {
+		return false
+	}
+	for q := range factor(uint64(n)) {
+		h := fromWords(xPow2k(n/int(q), m)).Add(x)
+		if g := h.GCD(p); g.Degree() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPrimitive returns whether p is irreducible and x generates the multiplicative group of GF(2)[x]/p,
+// that is x has order 2^n - 1 modulo p of degree n. The order is checked by factoring 2^n - 1,
+// so only polynomials of degree at most 64 are supported, IsPrimitive will panic otherwise.
+func (p Poly) IsPrimitive() bool {
+	n := p.Degree()
+	if n > 64 {
+		panic(fmt.Sprintf("primitivity is only tested up to degree 64, given %d", n))
+	}
+	if !p.IsIrreducible() || p.Coeff(0) == 0 {
+		return false
+	}
+
+	order := ^uint64(0) >> uint(64-n)
+	m := p.words()
+	for q := range factor(order) {
+		if degree(modExp([]uint64{2}, order/q, m)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package gf2
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+func mustParse(t *testing.T, s string) Poly {
+	t.Helper()
+	p, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func randomPoly(r *rand.Rand, degree int) Poly {
+	exponents := []int{degree}
+	for i := 0; i < degree; i++ {
+		if r.Intn(2) == 1 {
+			exponents = append(exponents, i)
+		}
+	}
+	return FromExponents(exponents...)
+}
+
+func TestConstructors(t *testing.T) {
+	ba := bitarray.New()
+	ba.AppendString("1101000")
+
+	tests := []struct {
+		id     int
+		p      Poly
+		degree int
+		s      string
+	}{
+		{0, Poly{}, -1, "0"},
+		{1, FromUint64(0), -1, "0"},
+		{2, FromUint64(1), 0, "1"},
+		{3, FromUint64(0x11B), 8, "x^8 + x^4 + x^3 + x + 1"},
+		{4, FromExponents(100, 0, 64, 3, 3), 100, "x^100 + x^64 + 1"},
+		{5, New(ba), 3, "x^3 + x + 1"},
+		{6, New(bitarray.NewZeros(70)), -1, "0"},
+	}
+
+	for _, test := range tests {
+		if test.p.Degree() != test.degree {
+			t.Errorf("%d: Degree returned %d, want %d", test.id, test.p.Degree(), test.degree)
+		}
+		if test.p.String() != test.s {
+			t.Errorf("%d: String returned bad data %q, want %q", test.id, test.p.String(), test.s)
+		}
+		if test.p.BitArray().Len() != test.degree+1 {
+			t.Errorf("%d: BitArray returned %d coefficients, want %d", test.id, test.p.BitArray().Len(), test.degree+1)
+		}
+		if p := mustParse(t, test.s); !p.Equal(test.p) {
+			t.Errorf("%d: Parse(%q) returned bad data %s", test.id, test.s, p)
+		}
+	}
+
+	if FromUint64(0b1011).Coeff(1) != 1 || FromUint64(0b1011).Coeff(2) != 0 || FromUint64(0b1011).Coeff(100) != 0 {
+		t.Errorf("Coeff returned bad data")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for id, s := range []string{"", "x^", "x^-1", "y", "x^2 + + 1", "2x", "x^2000000000", "x^1048577"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%d: Parse(%q) did not return an error", id, s)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		id       int
+		p, q     string
+		sum      string
+		product  string
+		quo, rem string
+		gcd      string
+	}{
+		{0, "x^3 + x + 1", "x + 1", "x^3", "x^4 + x^3 + x^2 + 1", "x^2 + x", "1", "1"},
+		{1, "x^4 + 1", "x^2 + 1", "x^4 + x^2", "x^6 + x^4 + x^2 + 1", "x^2 + 1", "0", "x^2 + 1"},
+		{2, "x^2 + x", "x^3", "x^3 + x^2 + x", "x^5 + x^4", "0", "x^2 + x", "x"},
+		{3, "x^70 + x^65 + 1", "x^64 + 1", "x^70 + x^65 + x^64", "x^134 + x^129 + x^70 + x^65 + x^64 + 1", "x^6 + x", "x^6 + x + 1", "1"},
+	}
+
+	for _, test := range tests {
+		p, q := mustParse(t, test.p), mustParse(t, test.q)
+		if s := p.Add(q); s.String() != test.sum {
+			t.Errorf("%d: Add returned bad data %s, want %s", test.id, s, test.sum)
+		}
+		if m := p.Mul(q); m.String() != test.product {
+			t.Errorf("%d: Mul returned bad data %s, want %s", test.id, m, test.product)
+		}
+		quo, rem := p.DivMod(q)
+		if quo.String() != test.quo || rem.String() != test.rem {
+			t.Errorf("%d: DivMod returned bad data (%s, %s), want (%s, %s)", test.id, quo, rem, test.quo, test.rem)
+		}
+		if g := p.GCD(q); g.String() != test.gcd {
+			t.Errorf("%d: GCD returned bad data %s, want %s", test.id, g, test.gcd)
+		}
+	}
+}
+
+func TestParseMaxDegree(t *testing.T) {
+	if p := mustParse(t, "x^1048576 + 1"); p.Degree() != MaxParseDegree {
+		t.Errorf("Parse returned a polynomial of degree %d, want %d", p.Degree(), MaxParseDegree)
+	}
+}
+
+// mulBitwise is the schoolbook product of a and b, term by term.
+func mulBitwise(a, b Poly) Poly {
+	exponents := []int{}
+	for i := 0; i <= a.Degree(); i++ {
+		for j := 0; j <= b.Degree(); j++ {
+			if a.Coeff(i) == 1 && b.Coeff(j) == 1 {
+				exponents = append(exponents, i+j)
+			}
+		}
+	}
+	return FromExponents(exponents...)
+}
+
+func TestMulRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id := 0; id < 200; id++ {
+		p, q := randomPoly(r, r.Intn(300)), randomPoly(r, r.Intn(300))
+		if got, want := p.Mul(q), mulBitwise(p, q); !got.Equal(want) {
+			t.Fatalf("%d: Mul returned bad data %s, want %s", id, got, want)
+		}
+	}
+
+	// (x^63 + ... + 1)^2 = x^126 + x^124 + ... + 1 as squaring is linear over GF(2)
+	ones := FromUint64(^uint64(0))
+	exponents := []int{}
+	for i := 0; i < 64; i++ {
+		exponents = append(exponents, 2*i)
+	}
+	if got, want := ones.Mul(ones), FromExponents(exponents...); !got.Equal(want) {
+		t.Errorf("Mul returned bad data %s, want %s", got, want)
+	}
+}
+
+func TestDivModRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 200; id++ {
+		p, q := randomPoly(r, r.Intn(300)), randomPoly(r, r.Intn(150))
+		quo, rem := p.DivMod(q)
+		if rem.Degree() >= q.Degree() {
+			t.Fatalf("%d: DivMod returned a remainder of degree %d, want less than %d", id, rem.Degree(), q.Degree())
+		}
+		if !quo.Mul(q).Add(rem).Equal(p) {
+			t.Fatalf("%d: DivMod returned (%s, %s) for %s / %s", id, quo, rem, p, q)
+		}
+
+		g := p.GCD(q)
+		if !p.Mod(g).IsZero() || !q.Mod(g).IsZero() {
+			t.Fatalf("%d: GCD returned %s which does not divide %s and %s", id, g, p, q)
+		}
+		if !p.Mul(q).GCD(q).Equal(q) {
+			t.Fatalf("%d: GCD(p*q, q) is not q", id)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("DivMod by the zero polynomial did not panic")
+		}
+	}()
+	FromUint64(3).DivMod(Poly{})
+}
+
+func TestModExp(t *testing.T) {
+	aes := FromUint64(0x11B)
+	tests := []struct {
+		id   int
+		p    Poly
+		e    uint64
+		m    Poly
+		want Poly
+	}{
+		{0, FromUint64(2), 0, aes, FromUint64(1)},
+		{1, FromUint64(2), 8, aes, FromUint64(0x1B)},
+		// x has order 51 modulo the AES polynomial, 3 (x + 1) generates the multiplicative group of GF(2^8)
+		{2, FromUint64(2), 51, aes, FromUint64(1)},
+		{3, FromUint64(3), 255, aes, FromUint64(1)},
+		{4, FromUint64(3), 85, aes, FromUint64(3).ModExp(85, aes)},
+		{5, FromUint64(0x53), 254, aes, FromUint64(0xCA)},
+		{6, FromUint64(5), 10, FromUint64(1), Poly{}},
+	}
+
+	for _, test := range tests {
+		if got := test.p.ModExp(test.e, test.m); !got.Equal(test.want) {
+			t.Errorf("%d: ModExp returned bad data %s, want %s", test.id, got, test.want)
+		}
+	}
+
+	// 0x53 and 0xCA are inverses in the AES field
+	if got := FromUint64(0x53).Mul(FromUint64(0xCA)).Mod(aes); !got.Equal(FromUint64(1)) {
+		t.Errorf("0x53 * 0xCA returned %s, want 1", got)
+	}
+}
+
+// The numbers of irreducible and of primitive polynomials of degree n over GF(2) are known (OEIS A001037 and A011260).
+func TestIrreducibleCounts(t *testing.T) {
+	irreducible := []int{0, 2, 1, 2, 3, 6, 9, 18, 30, 56, 99, 186, 335}
+	primitive := []int{0, 1, 1, 2, 2, 6, 6, 18, 16, 48, 60, 176, 144}
+
+	for n := 1; n < len(irreducible); n++ {
+		nbIrreducible, nbPrimitive := 0, 0
+		for v := uint64(1) << uint(n); v < 1<<uint(n+1); v++ {
+			p := FromUint64(v)
+			if p.IsIrreducible() {
+				nbIrreducible++
+			}
+			if p.IsPrimitive() {
+				nbPrimitive++
+			}
+		}
+		if nbIrreducible != irreducible[n] || nbPrimitive != primitive[n] {
+			t.Errorf("%d: found %d irreducible and %d primitive polynomials, want %d and %d",
+				n, nbIrreducible, nbPrimitive, irreducible[n], primitive[n])
+		}
+	}
+}
+
+func TestIsPrimitive(t *testing.T) {
+	tests := []struct {
+		id          int
+		p           string
+		irreducible bool
+		primitive   bool
+	}{
+		{0, "x^8 + x^4 + x^3 + x + 1", true, false},
+		{1, "x^8 + x^4 + x^3 + x^2 + 1", true, true},
+		{2, "x^32 + x^26 + x^23 + x^22 + x^16 + x^12 + x^11 + x^10 + x^8 + x^7 + x^5 + x^4 + x^2 + x + 1", true, true},
+		{3, "x^16 + x^15 + x^2 + 1", false, false},
+		{4, "x^63 + x + 1", true, true},
+		{5, "x^64 + x^4 + x^3 + x + 1", true, true},
+		{6, "x^64 + x^63 + x^61 + x^60 + 1", true, true},
+		{7, "x^127 + x + 1", true, true},
+		{8, "x", true, false},
+		{9, "1", false, false},
+		{10, "x^64 + x^32 + 1", false, false},
+	}
+
+	for _, test := range tests {
+		p := mustParse(t, test.p)
+		if p.IsIrreducible() != test.irreducible {
+			t.Errorf("%d: IsIrreducible returned %t, want %t", test.id, p.IsIrreducible(), test.irreducible)
+		}
+		if p.Degree() <= 64 && p.IsPrimitive() != test.primitive {
+			t.Errorf("%d: IsPrimitive returned %t, want %t", test.id, p.IsPrimitive(), test.primitive)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("IsPrimitive of a polynomial of degree 127 did not panic")
+		}
+	}()
+	mustParse(t, "x^127 + x + 1").IsPrimitive()
+}
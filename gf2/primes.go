@@ -0,0 +1,109 @@
+package gf2
+
+import "math/bits"
+
+func mulMod64(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func powMod64(a, e, m uint64) uint64 {
+	res := uint64(1) % m
+	for a %= m; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			res = mulMod64(res, a, m)
+		}
+		a = mulMod64(a, a, m)
+	}
+	return res
+}
+
+// isPrime is a Miller-Rabin test, deterministic for 64-bit integers with the first 12 primes as bases.
+func isPrime(n uint64) bool {
+	bases := []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+	if n < 2 {
+		return false
+	}
+	for _, p := range bases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+
+	d, s := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		s++
+	}
+	for _, a := range bases {
+		x := powMod64(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for r := 1; r < s && composite; r++ {
+			x = mulMod64(x, x, n)
+			composite = x != n-1
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+func gcd64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// pollardRho returns a non-trivial factor of the odd composite n with Brent's variant of Pollard's rho algorithm.
+func pollardRho(n uint64) uint64 {
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return (mulMod64(x, x, n) + c) % n }
+		x, y, d := uint64(2), uint64(2), uint64(1)
+		for power, lam := 1, 1; d == 1; lam++ {
+			if power == lam {
+				x, power, lam = y, 2*power, 0
+			}
+			y = f(y)
+			if x > y {
+				d = gcd64(x-y, n)
+			} else {
+				d = gcd64(y-x, n)
+			}
+		}
+		if d != n {
+			return d
+		}
+	}
+}
+
+// factor returns the prime factors of n with their multiplicity.
+func factor(n uint64) map[uint64]int {
+	factors := map[uint64]int{}
+	var rec func(n uint64)
+	rec = func(n uint64) {
+		if n == 1 {
+			return
+		}
+		if isPrime(n) {
+			factors[n]++
+			return
+		}
+		for _, p := range []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+			if n%p == 0 {
+				factors[p]++
+				rec(n / p)
+				return
+			}
+		}
+		d := pollardRho(n)
+		rec(d)
+		rec(n / d)
+	}
+	rec(n)
+	return factors
+}
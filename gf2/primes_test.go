@@ -0,0 +1,56 @@
+package gf2
+
+import "testing"
+
+func TestFactor(t *testing.T) {
+	tests := []struct {
+		id   int
+		n    uint64
+		want map[uint64]int
+	}{
+		{0, 1, map[uint64]int{}},
+		{1, 2, map[uint64]int{2: 1}},
+		{2, 360, map[uint64]int{2: 3, 3: 2, 5: 1}},
+		{3, 1<<32 - 1, map[uint64]int{3: 1, 5: 1, 17: 1, 257: 1, 65537: 1}},
+		{4, 1<<59 - 1, map[uint64]int{179951: 1, 3203431780337: 1}},
+		{5, 1<<61 - 1, map[uint64]int{1<<61 - 1: 1}},
+		{6, 1<<64 - 1, map[uint64]int{3: 1, 5: 1, 17: 1, 257: 1, 641: 1, 65537: 1, 6700417: 1}},
+		{7, 4294967291 * 4294967279, map[uint64]int{4294967291: 1, 4294967279: 1}},
+	}
+
+	for _, test := range tests {
+		got := factor(test.n)
+		if len(got) != len(test.want) {
+			t.Fatalf("%d: factor returned bad data %v, want %v", test.id, got, test.want)
+		}
+		for p, e := range test.want {
+			if got[p] != e {
+				t.Fatalf("%d: factor returned bad data %v, want %v", test.id, got, test.want)
+			}
+		}
+	}
+}
+
+func TestIsPrime(t *testing.T) {
+	tests := []struct {
+		id    int
+		n     uint64
+		prime bool
+	}{
+		{0, 0, false},
+		{1, 1, false},
+		{2, 2, true},
+		{3, 37, true},
+		{4, 561, false},
+		{5, 1<<31 - 1, true},
+		{6, 3215031751, false},
+		{7, 18446744073709551557, true},
+		{8, 18446744073709551615, false},
+	}
+
+	for _, test := range tests {
+		if isPrime(test.n) != test.prime {
+			t.Errorf("%d: isPrime(%d) returned %t, want %t", test.id, test.n, isPrime(test.n), test.prime)
+		}
+	}
+}
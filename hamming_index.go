@@ -0,0 +1,185 @@
+package bitarray
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Neighbor is a bit-array of a HammingIndex found by a nearest neighbors search.
+type Neighbor struct {
+	ID       int // the identifier returned by Add
+	Distance int // the Hamming distance to the query
+}
+
+// HammingIndex finds the nearest bit-arrays of a fixed length by Hamming distance using multi-index hashing:
+// bit-arrays are split into m contiguous substrings and each substring is indexed in its own hash table.
+// Two bit-arrays at distance d have at least one substring at distance at most d/m, so a search only
+// probes, in each table, the substrings of the query with few flipped bits.
+// A good number of substrings is about length / log2(n) for n indexed bit-arrays.
+type HammingIndex struct {
+	length int
+	bounds []int // substring s is [bounds[s], bounds[s+1])
+	tables []map[uint64][]int
+	items  []*BitArray
+}
+
+// NewHammingIndex returns an empty index of bit-arrays of the given length split into m substrings.
+// It will panic if length is not positive, if m is not in [1, length] or if a substring would be longer than 64 bits.
+func NewHammingIndex(length, m int) *HammingIndex {
+	if length <= 0 {
+		panic(fmt.Sprintf("length should be positive; given %d", length))
+	}
+	if m < 1 || m > length {
+		panic(fmt.Sprintf("the number of substrings should be in [1, %d]; given %d", length, m))
+	}
+	if (length+m-1)/m > 64 {
+		panic(fmt.Sprintf("substrings should not be longer than 64 bits; given length %d and %d substrings", length, m))
+	}
+
+	idx := &HammingIndex{
+		length: length,
+		bounds: make([]int, m+1),
+		tables: make([]map[uint64][]int, m),
+	}
+	for s := 0; s <= m; s++ {
+		idx.bounds[s] = s * length / m
+	}
+	for s := range idx.tables {
+		idx.tables[s] = make(map[uint64][]int)
+	}
+	return idx
+}
+
+// Len returns the number of indexed bit-arrays.
+func (idx *HammingIndex) Len() int {
+	return len(idx.items)
+}
+
+// Add indexes a copy of ba and returns its identifier, identifiers are consecutive integers starting from 0.
+// It will panic if ba does not have the length of the index.
+func (idx *HammingIndex) Add(ba *BitArray) int {
+	idx.checkLen(ba)
+	id := len(idx.items)
+	idx.items = append(idx.items, ba.Clone())
+	for s, table := range idx.tables {
+		key := ba.Extract(idx.bounds[s], idx.bounds[s+1])
+		table[key] = append(table[key], id)
+	}
+	return id
+}
+
+// Get returns the bit-array indexed with identifier id, it must not be modified.
+func (idx *HammingIndex) Get(id int) *BitArray {
+	return idx.items[id]
+}
+
+// Nearest returns the k indexed bit-arrays closest to query, or all of them if there are fewer than k,
+// sorted by increasing distance then by identifier.
+// It will panic if query does not have the length of the index or if k is negative.
+func (idx *HammingIndex) Nearest(query *BitArray, k int) []Neighbor {
+	idx.checkLen(query)
+	if k < 0 {
+		panic(fmt.Sprintf("the number of neighbors should not be negative; given %d", k))
+	}
+	if k > len(idx.items) {
+		k = len(idx.items)
+	}
+	if k == 0 {
+		return []Neighbor{}
+	}
+
+	m := len(idx.tables)
+	keys := make([]uint64, m)
+	for s := range keys {
+		keys[s] = query.Extract(idx.bounds[s], idx.bounds[s+1])
+	}
+
+	seen := make([]bool, len(idx.items))
+	var found []Neighbor
+	visit := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			found = append(found, Neighbor{ID: id, Distance: query.HammingDistance(idx.items[id])})
+		}
+	}
+
+	for r := 0; ; r++ {
+		// once probes cost more than a scan of the remaining bit-arrays, scan them
+		if idx.probes(r) > len(idx.items)-len(found) {
+			for id := range idx.items {
+				visit(id)
+			}
+			break
+		}
+		for s, table := range idx.tables {
+			flip(keys[s], idx.bounds[s+1]-idx.bounds[s], r, func(key uint64) {
+				for _, id := range table[key] {
+					visit(id)
+				}
+			})
+		}
+
+		// all the bit-arrays at distance < m*(r+1) have been found
+		within := 0
+		for _, n := range found {
+			if n.Distance < m*(r+1) {
+				within++
+			}
+		}
+		if within >= k {
+			break
+		}
+	}
+
+	sort.Slice(found, func(a, b int) bool {
+		if found[a].Distance != found[b].Distance {
+			return found[a].Distance < found[b].Distance
+		}
+		return found[a].ID < found[b].ID
+	})
+	return found[:k]
+}
+
+// probes returns the number of table lookups done to probe all the substrings at distance r.
+func (idx *HammingIndex) probes(r int) int {
+	total := 0
+	for s := range idx.tables {
+		total += binomial(idx.bounds[s+1]-idx.bounds[s], r)
+		if total > len(idx.items) {
+			break
+		}
+	}
+	return total
+}
+
+// binomial returns C(n, r), or a value greater than 1<<30 when it is larger.
+func binomial(n, r int) int {
+	if r > n {
+		return 0
+	}
+	c := 1
+	for i := 1; i <= r; i++ {
+		c = c * (n - r + i) / i
+		if c > 1<<30 {
+			return c
+		}
+	}
+	return c
+}
+
+// flip calls f with every value obtained by flipping exactly r of the n lowest bits of key.
+func flip(key uint64, n, r int, f func(uint64)) {
+	if r == 0 {
+		f(key)
+		return
+	}
+	for b := r - 1; b < n; b++ {
+		flip(key^1<<uint(b), b, r-1, f)
+	}
+}
+
+func (idx *HammingIndex) checkLen(ba *BitArray) {
+	if ba.Len() != idx.length {
+		panic(fmt.Sprintf("bit-arrays should have the same length; given %d and %d", ba.Len(), idx.length))
+	}
+}
@@ -0,0 +1,109 @@
+package bitarray
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func bruteNearest(items []*BitArray, query *BitArray, k int) []Neighbor {
+	neighbors := make([]Neighbor, len(items))
+	for id, ba := range items {
+		neighbors[id] = Neighbor{ID: id, Distance: query.HammingDistance(ba)}
+	}
+	sort.Slice(neighbors, func(a, b int) bool {
+		if neighbors[a].Distance != neighbors[b].Distance {
+			return neighbors[a].Distance < neighbors[b].Distance
+		}
+		return neighbors[a].ID < neighbors[b].ID
+	})
+	if k > len(neighbors) {
+		k = len(neighbors)
+	}
+	return neighbors[:k]
+}
+
+func TestHammingIndex(t *testing.T) {
+	tests := []struct {
+		id     int
+		length int
+		m      int
+		n      int
+	}{
+		{0, 1, 1, 3},
+		{1, 64, 1, 100},
+		{2, 64, 4, 500},
+		{3, 100, 7, 1000},
+		{4, 256, 16, 2000},
+		{5, 128, 8, 0},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, test := range tests {
+		idx := NewHammingIndex(test.length, test.m)
+		var items []*BitArray
+		for i := 0; i < test.n; i++ {
+			ba := randomBitArray(r, test.length)
+			if i%3 == 1 {
+				// a close copy of a previous bit-array
+				ba = items[r.Intn(len(items))].Clone()
+				ba.SetBit(r.Intn(test.length))
+			}
+			if id := idx.Add(ba); id != i {
+				t.Fatalf("%d: Add returned bad data %d, want %d", test.id, id, i)
+			}
+			items = append(items, ba)
+		}
+		if idx.Len() != test.n {
+			t.Errorf("%d: Len returned bad data %d, want %d", test.id, idx.Len(), test.n)
+		}
+
+		for q := 0; q < 20; q++ {
+			query := randomBitArray(r, test.length)
+			if q%2 == 0 && test.n > 0 {
+				query = items[r.Intn(test.n)].Clone()
+				query.ClearBit(r.Intn(test.length))
+			}
+			for _, k := range []int{0, 1, 5, 20, test.n + 1} {
+				got, want := idx.Nearest(query, k), bruteNearest(items, query, k)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%d: Nearest(k=%d) returned bad data %v, want %v", test.id, k, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHammingIndexCopies(t *testing.T) {
+	idx := NewHammingIndex(8, 2)
+	ba := New()
+	ba.AppendString("10101010")
+	idx.Add(ba)
+	ba.SetBit(1)
+
+	if got := idx.Get(0).Extract(0, 8); got != 0xAA {
+		t.Errorf("Get returned bad data %#x, want 0xaa", got)
+	}
+}
+
+func TestHammingIndexPanics(t *testing.T) {
+	for id, f := range []func(){
+		func() { NewHammingIndex(0, 1) },
+		func() { NewHammingIndex(10, 0) },
+		func() { NewHammingIndex(10, 11) },
+		func() { NewHammingIndex(130, 2) },
+		func() { NewHammingIndex(10, 2).Add(NewZeros(9)) },
+		func() { NewHammingIndex(10, 2).Nearest(NewZeros(11), 1) },
+		func() { NewHammingIndex(10, 2).Nearest(NewZeros(10), -1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,124 @@
+package bitarray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized form of a bit-array is made of:
+//	- 4 bytes: the magic "BITA",
+//	- uint64 (big-endian): the length n of the bit-array,
+//	- ceil(n/8) bytes: the bits as returned by Bytes, padding bits of the last byte being 0's.
+
+const (
+	bitArrayMagic      = "BITA"
+	bitArrayHeaderSize = 4 + 8
+)
+
+// ErrInvalidFormat is returned when reading a malformed serialized bit-array.
+var ErrInvalidFormat = errors.New("bitarray: invalid format")
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (ba *BitArray) SerializedSizeInBytes() int {
+	return bitArrayHeaderSize + (ba.Len()+7)/8
+}
+
+// WriteTo writes the bit-array to w.
+func (ba *BitArray) WriteTo(w io.Writer) (int64, error) {
+	var header [bitArrayHeaderSize]byte
+	copy(header[:], bitArrayMagic)
+	binary.BigEndian.PutUint64(header[4:], uint64(ba.Len()))
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+
+	m, err := w.Write(ba.data[:(ba.Len()+7)/8])
+	return int64(n + m), err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (ba *BitArray) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(ba.SerializedSizeInBytes())
+	if _, err := ba.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFrom replaces the bit-array by the one read from r.
+// It reads exactly the bytes of one bit-array, so bit-arrays stored back to back can be read with successive calls.
+func (ba *BitArray) ReadFrom(r io.Reader) (int64, error) {
+	var header [bitArrayHeaderSize]byte
+	n, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return int64(n), unexpectedEOF(err)
+	}
+	length, err := parseHeader(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+
+	// the length comes from an untrusted header: grow the buffer as bytes arrive instead of allocating it upfront
+	nbBytes := int((uint64(length) + 7) / 8)
+	var buf bytes.Buffer
+	m, err := io.CopyN(&buf, r, int64(nbBytes))
+	if err != nil {
+		return int64(n) + m, unexpectedEOF(err)
+	}
+	data := buf.Bytes()
+	if err := checkPadding(data, length); err != nil {
+		return int64(n) + m, err
+	}
+
+	if length == 0 {
+		*ba = BitArray{data: []byte{0}, padding: 8}
+	} else {
+		*ba = BitArray{data: data, padding: nbBytes*8 - length}
+	}
+	return int64(n) + m, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (ba *BitArray) UnmarshalBinary(data []byte) error {
+	n, err := ba.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if n != int64(len(data)) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, int64(len(data))-n)
+	}
+	return nil
+}
+
+// parseHeader checks the header of a serialized bit-array and returns its length.
+func parseHeader(header []byte) (int, error) {
+	if string(header[:4]) != bitArrayMagic {
+		return 0, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, header[:4])
+	}
+	length := binary.BigEndian.Uint64(header[4:])
+	if length > uint64(maxInt) {
+		return 0, fmt.Errorf("%w: length %d is too large", ErrInvalidFormat, length)
+	}
+	return int(length), nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func checkPadding(data []byte, length int) error {
+	if length%8 != 0 && data[len(data)-1]&(0xff>>uint(length%8)) != 0 {
+		return fmt.Errorf("%w: padding bits are not 0", ErrInvalidFormat)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package bitarray
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestSerialization(t *testing.T) {
+	tests := []struct {
+		id         int
+		bits       string
+		serialized []byte
+	}{
+		{0, "", []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{1, "1", []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x01\x80")},
+		{2, "1101111010101101", []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x10\xde\xad")},
+		{3, "11011110101011011", []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x11\xde\xad\x80")},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bits)
+
+		data, err := ba.MarshalBinary()
+		if err != nil || !bytes.Equal(data, test.serialized) {
+			t.Errorf("%d: MarshalBinary returned bad data (%x, %v), want %x", test.id, data, err, test.serialized)
+		}
+		if ba.SerializedSizeInBytes() != len(test.serialized) {
+			t.Errorf("%d: SerializedSizeInBytes returned bad data %d, want %d", test.id, ba.SerializedSizeInBytes(), len(test.serialized))
+		}
+
+		got := New()
+		got.AppendString("101")
+		if err := got.UnmarshalBinary(test.serialized); err != nil || !sameBits(got, ba) {
+			t.Errorf("%d: UnmarshalBinary returned bad data (%08b, %v), want %08b", test.id, got.Bytes(), err, ba.Bytes())
+		}
+		// the unmarshaled bit-array must be usable as any other one
+		got.AppendOne()
+		ba.AppendOne()
+		if !sameBits(got, ba) {
+			t.Errorf("%d: AppendOne after UnmarshalBinary returned bad data %08b, want %08b", test.id, got.Bytes(), ba.Bytes())
+		}
+	}
+}
+
+func TestReadFromBackToBack(t *testing.T) {
+	var buf bytes.Buffer
+	var arrays []*BitArray
+	for _, bits := range []string{"101", "", "1111000011110000111", "0"} {
+		ba := New()
+		ba.AppendString(bits)
+		arrays = append(arrays, ba)
+		if n, err := ba.WriteTo(&buf); err != nil || n != int64(ba.SerializedSizeInBytes()) {
+			t.Fatalf("WriteTo returned (%d, %v), want (%d, nil)", n, err, ba.SerializedSizeInBytes())
+		}
+	}
+
+	for id, want := range arrays {
+		got := New()
+		if _, err := got.ReadFrom(&buf); err != nil || !sameBits(got, want) {
+			t.Errorf("%d: ReadFrom returned bad data (%08b, %v), want %08b", id, got.Bytes(), err, want.Bytes())
+		}
+	}
+	if _, err := New().ReadFrom(&buf); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadFrom at the end returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		id   int
+		data []byte
+		err  error
+	}{
+		{0, []byte(""), io.ErrUnexpectedEOF},
+		{1, []byte("BITA\x00\x00"), io.ErrUnexpectedEOF},
+		{2, []byte("ABCD\x00\x00\x00\x00\x00\x00\x00\x00"), ErrInvalidFormat},
+		{3, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x09\xff"), io.ErrUnexpectedEOF},
+		{4, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x01\xc0"), ErrInvalidFormat},
+		{5, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x08\xff\x00"), ErrInvalidFormat},
+		{6, []byte("BITA\xff\xff\xff\xff\xff\xff\xff\xff"), ErrInvalidFormat},
+		{7, []byte("BITA\x7f\xff\xff\xff\xff\xff\xff\xff"), io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		if err := New().UnmarshalBinary(test.data); !errors.Is(err, test.err) {
+			t.Errorf("%d: UnmarshalBinary returned %v, want %v", test.id, err, test.err)
+		}
+	}
+}
+
+// A header announcing a huge bit-array must not allocate it before its bytes are read.
+func TestReadFromLargeHeader(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := New().UnmarshalBinary([]byte("BITA\x00\x00\x00\x04\x00\x00\x00\x00")); err != io.ErrUnexpectedEOF {
+		t.Errorf("UnmarshalBinary returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("UnmarshalBinary allocated %d bytes for a truncated bit-array", allocated)
+	}
+}
@@ -0,0 +1,145 @@
+package bitarray
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+)
+
+// ErrMmapUnsupported is returned when opening a memory-mapped bit-array on a platform without mmap support.
+var ErrMmapUnsupported = errors.New("bitarray: memory mapping is not supported on this platform")
+
+// MappedBitArray is a read-only bit-array whose bits are those of a memory-mapped file:
+// the file is not read into memory, its pages are loaded by the operating system when the bits are accessed.
+// Contrary to a BitArray, the padding bits of the last byte may be garbage, they are never returned.
+// The bit-array must not be used after Close.
+type MappedBitArray struct {
+	ba      BitArray
+	mapping []byte
+}
+
+// OpenMapped maps the file at path, which holds a bit-array serialized with WriteTo or MarshalBinary.
+func OpenMapped(path string) (*MappedBitArray, error) {
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	length, err := func() (int, error) {
+		if len(data) < bitArrayHeaderSize {
+			return 0, fmt.Errorf("%w: file of %d bytes is too short", ErrInvalidFormat, len(data))
+		}
+		length, err := parseHeader(data[:bitArrayHeaderSize])
+		if err != nil {
+			return 0, err
+		}
+		if len(data)-bitArrayHeaderSize != (length+7)/8 {
+			return 0, fmt.Errorf("%w: file of %d bytes for a bit-array of length %d", ErrInvalidFormat, len(data), length)
+		}
+		return length, checkPadding(data[bitArrayHeaderSize:], length)
+	}()
+	if err != nil {
+		munmap(data)
+		return nil, err
+	}
+	return newMapped(data, bitArrayHeaderSize, length), nil
+}
+
+// OpenMappedRaw maps the file at path whose bytes are the bits of a bit-array of the given length,
+// as returned by Bytes. The file must hold at least ceil(length/8) bytes, trailing bytes are ignored.
+// It will panic if length is negative.
+func OpenMappedRaw(path string, length int) (*MappedBitArray, error) {
+	if length < 0 {
+		panic(fmt.Sprintf("length should not be negative, given %d", length))
+	}
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < (length+7)/8 {
+		munmap(data)
+		return nil, fmt.Errorf("%w: file of %d bytes for a bit-array of length %d", ErrInvalidFormat, len(data), length)
+	}
+	return newMapped(data, 0, length), nil
+}
+
+// mapFile maps the whole file at path in memory, an empty file is not mapped and returns nil.
+func mapFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > int64(maxInt) {
+		return nil, fmt.Errorf("bitarray: file of %d bytes is too large to be mapped", info.Size())
+	}
+	if info.Size() == 0 {
+		return nil, nil
+	}
+	return mmap(f, int(info.Size()))
+}
+
+// newMapped returns the bit-array of the given length whose bits start at byte offset of mapping.
+func newMapped(mapping []byte, offset, length int) *MappedBitArray {
+	m := &MappedBitArray{mapping: mapping}
+	if length == 0 {
+		m.ba = BitArray{data: []byte{0}, padding: 8}
+		return m
+	}
+	nbBytes := (length + 7) / 8
+	m.ba = BitArray{data: mapping[offset : offset+nbBytes : offset+nbBytes], padding: nbBytes*8 - length}
+	return m
+}
+
+// Close unmaps the file.
+func (m *MappedBitArray) Close() error {
+	m.ba = BitArray{}
+	if m.mapping == nil {
+		return nil
+	}
+	err := munmap(m.mapping)
+	m.mapping = nil
+	return err
+}
+
+// Len returns the length (number of bits) of the bit-array.
+func (m *MappedBitArray) Len() int {
+	return m.ba.Len()
+}
+
+// GetBit returns the bit at position `index` and will panic if index is out of range.
+func (m *MappedBitArray) GetBit(index int) byte {
+	return m.ba.GetBit(index)
+}
+
+// Extract extracts the bits in the range [i, j) into a uint64 with the same semantics as BitArray.Extract.
+func (m *MappedBitArray) Extract(i, j int) uint64 {
+	return m.ba.Extract(i, j)
+}
+
+// ExtractBitArray extracts the bits in the range [i, j) into a new, in memory, bit-array
+// with the same semantics as BitArray.ExtractBitArray.
+func (m *MappedBitArray) ExtractBitArray(i, j int) *BitArray {
+	return m.ba.ExtractBitArray(i, j)
+}
+
+// Count returns the number of bits set to `1` in the bit-array.
+func (m *MappedBitArray) Count() int {
+	return m.ba.Count() - bits.OnesCount8(m.ba.data[len(m.ba.data)-1]&(1<<uint(m.ba.padding)-1))
+}
+
+// NextOne returns the position of the first bit set to `1` at position `index` or after it,
+// or -1 if there is no such bit. It will panic if index is negative.
+func (m *MappedBitArray) NextOne(index int) int {
+	return m.ba.NextOne(index)
+}
+
+// BitArray returns a copy, in memory, of the bit-array.
+func (m *MappedBitArray) BitArray() *BitArray {
+	return m.ba.ExtractBitArray(0, m.ba.Len())
+}
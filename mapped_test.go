@@ -0,0 +1,119 @@
+package bitarray
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestMapped(t *testing.T, content []byte, open func(path string) (*MappedBitArray, error)) (*MappedBitArray, error) {
+	path := filepath.Join(t.TempDir(), "bits")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := open(path)
+	if errors.Is(err, ErrMmapUnsupported) {
+		t.Skip(err)
+	}
+	return m, err
+}
+
+func checkMapped(t *testing.T, id int, m *MappedBitArray, want *BitArray) {
+	if m.Len() != want.Len() {
+		t.Errorf("%d: Len returned bad data %d, want %d", id, m.Len(), want.Len())
+	}
+	if m.Count() != want.Count() {
+		t.Errorf("%d: Count returned bad data %d, want %d", id, m.Count(), want.Count())
+	}
+	if got := m.BitArray(); !sameBits(got, want) {
+		t.Errorf("%d: BitArray returned bad data %08b, want %08b", id, got.Bytes(), want.Bytes())
+	}
+	for i := 0; i < want.Len(); i++ {
+		if m.GetBit(i) != want.GetBit(i) {
+			t.Fatalf("%d: GetBit(%d) returned bad data %d, want %d", id, i, m.GetBit(i), want.GetBit(i))
+		}
+	}
+	for i, j := m.NextOne(0), want.NextOne(0); i >= 0 || j >= 0; i, j = m.NextOne(i+1), want.NextOne(j+1) {
+		if i != j {
+			t.Fatalf("%d: NextOne returned bad data %d, want %d", id, i, j)
+		}
+	}
+	for i := 0; i+13 <= want.Len(); i += 7 {
+		if got, want := m.Extract(i, i+13), want.Extract(i, i+13); got != want {
+			t.Errorf("%d: Extract(%d, %d) returned bad data %x, want %x", id, i, i+13, got, want)
+		}
+	}
+	for i := 0; i <= want.Len(); i += 331 {
+		if got, want := m.ExtractBitArray(i, want.Len()), want.ExtractBitArray(i, want.Len()); !sameBits(got, want) {
+			t.Errorf("%d: ExtractBitArray(%d, %d) returned bad data", id, i, want.Len())
+		}
+	}
+}
+
+func TestOpenMapped(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id, n := range []int{0, 1, 8, 13, 1000, 70001} {
+		want := randomBitArray(r, n)
+		data, _ := want.MarshalBinary()
+		m, err := openTestMapped(t, data, OpenMapped)
+		if err != nil {
+			t.Fatalf("%d: OpenMapped returned %v", id, err)
+		}
+		checkMapped(t, id, m, want)
+		if err := m.Close(); err != nil {
+			t.Errorf("%d: Close returned %v", id, err)
+		}
+	}
+}
+
+func TestOpenMappedRaw(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for id, n := range []int{0, 1, 8, 13, 1000, 70001} {
+		want := randomBitArray(r, n)
+		// padding bits and trailing bytes of a raw file are ignored
+		data := append(want.Bytes(), 0xff, 0xff)
+		if want.Padding() != 0 {
+			data[len(want.Bytes())-1] |= 1<<uint(want.Padding()) - 1
+		}
+		m, err := openTestMapped(t, data, func(path string) (*MappedBitArray, error) { return OpenMappedRaw(path, n) })
+		if err != nil {
+			t.Fatalf("%d: OpenMappedRaw returned %v", id, err)
+		}
+		checkMapped(t, id, m, want)
+		m.Close()
+	}
+}
+
+func TestOpenMappedErrors(t *testing.T) {
+	tests := []struct {
+		id      int
+		content []byte
+		length  int // -1 to open the content as a serialized bit-array
+	}{
+		{0, []byte(""), -1},
+		{1, []byte("BITA\x00\x00"), -1},
+		{2, []byte("ABCD\x00\x00\x00\x00\x00\x00\x00\x00"), -1},
+		{3, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x09\xff"), -1},
+		{4, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x01\xc0"), -1},
+		{5, []byte("BITA\x00\x00\x00\x00\x00\x00\x00\x01\x80\x00"), -1},
+		{6, []byte("\xff"), 9},
+		{7, []byte(""), 1},
+	}
+
+	for _, test := range tests {
+		open := OpenMapped
+		if test.length >= 0 {
+			length := test.length
+			open = func(path string) (*MappedBitArray, error) { return OpenMappedRaw(path, length) }
+		}
+		if _, err := openTestMapped(t, test.content, open); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("%d: returned %v, want %v", test.id, err, ErrInvalidFormat)
+		}
+	}
+
+	if _, err := OpenMapped(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("OpenMapped of a missing file did not return an error")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package bitarray
+
+import "os"
+
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, ErrMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return nil
+}
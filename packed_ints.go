@@ -0,0 +1,111 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// PackedInts is a vector of unsigned integers all stored on the same number of bits, between 1 and 64.
+// Values are packed contiguously in a bit-array, the i-th value occupying the bits [i*width, (i+1)*width).
+type PackedInts struct {
+	ba    *BitArray
+	width int
+	n     int
+}
+
+// NewPackedInts returns a vector of n zeros stored on width bits.
+// It will panic if width is not between 1 and 64 or if n is negative.
+func NewPackedInts(width, n int) *PackedInts {
+	if width < 1 || width > 64 {
+		panic(fmt.Sprintf("width should be between 1 and 64, given %d", width))
+	}
+
+	return &PackedInts{ba: NewZeros(width * n), width: width, n: n}
+}
+
+// EncodePackedInts returns a vector holding values stored on width bits.
+// If width is 0 the smallest width able to store the maximum of the values is used (see WidthFor).
+// It will panic if width is not between 0 and 64 or if a value does not fit on width bits.
+func EncodePackedInts(values []uint64, width int) *PackedInts {
+	if width == 0 {
+		var max uint64
+		for _, v := range values {
+			if v > max {
+				max = v
+			}
+		}
+		width = WidthFor(max)
+	}
+
+	p := NewPackedInts(width, len(values))
+	for i, v := range values {
+		p.checkFits(v)
+		p.ba.setBitsAt(i*width, width, v)
+	}
+	return p
+}
+
+// WidthFor returns the number of bits needed to store v, at least 1.
+func WidthFor(v uint64) int {
+	if v == 0 {
+		return 1
+	}
+	return bits.Len64(v)
+}
+
+// Len returns the number of values.
+func (p *PackedInts) Len() int {
+	return p.n
+}
+
+// Width returns the number of bits each value is stored on.
+func (p *PackedInts) Width() int {
+	return p.width
+}
+
+// Get returns the i-th value and will panic if i is out of range.
+func (p *PackedInts) Get(i int) uint64 {
+	p.checkIndex(i)
+	return p.ba.bitsAt(i*p.width, p.width)
+}
+
+// Set sets the i-th value to v. It will panic if i is out of range or if v does not fit on Width() bits.
+func (p *PackedInts) Set(i int, v uint64) {
+	p.checkIndex(i)
+	p.checkFits(v)
+	p.ba.setBitsAt(i*p.width, p.width, v)
+}
+
+// Append appends v to the vector and will panic if v does not fit on Width() bits.
+func (p *PackedInts) Append(v uint64) {
+	p.checkFits(v)
+	p.ba.appendZeros(p.width)
+	p.ba.setBitsAt(p.n*p.width, p.width, v)
+	p.n++
+}
+
+// Decode returns all the values of the vector.
+func (p *PackedInts) Decode() []uint64 {
+	values := make([]uint64, p.n)
+	for i := range values {
+		values[i] = p.ba.bitsAt(i*p.width, p.width)
+	}
+	return values
+}
+
+// SizeInBits returns the number of bits used to store the values.
+func (p *PackedInts) SizeInBits() int {
+	return p.ba.Len()
+}
+
+func (p *PackedInts) checkIndex(i int) {
+	if i < 0 || i >= p.n {
+		panic(fmt.Sprintf("index out of range [%d] with length %d", i, p.n))
+	}
+}
+
+func (p *PackedInts) checkFits(v uint64) {
+	if p.width < 64 && v>>p.width != 0 {
+		panic(fmt.Sprintf("value %d does not fit on %d bits", v, p.width))
+	}
+}
@@ -0,0 +1,97 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPackedInts(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for width := 1; width <= 64; width++ {
+		values := make([]uint64, r.Intn(200))
+		for i := range values {
+			values[i] = r.Uint64() >> (64 - width)
+		}
+
+		p := NewPackedInts(width, 0)
+		for _, v := range values {
+			p.Append(v)
+		}
+		if p.Len() != len(values) || p.SizeInBits() != width*len(values) {
+			t.Fatalf("width %d: bad length %d or size %d", width, p.Len(), p.SizeInBits())
+		}
+		for i, v := range values {
+			if p.Get(i) != v {
+				t.Fatalf("width %d: Get(%d) returned %d, want %d", width, i, p.Get(i), v)
+			}
+		}
+
+		// overwrite every other value and check neighbours are left untouched
+		for i := 0; i < len(values); i += 2 {
+			values[i] = r.Uint64() >> (64 - width)
+			p.Set(i, values[i])
+		}
+		if fmt.Sprint(p.Decode()) != fmt.Sprint(values) {
+			t.Fatalf("width %d: Decode returned %v, want %v", width, p.Decode(), values)
+		}
+
+		// the packed layout is the one obtained by appending the values to a bit-array
+		ba := New()
+		for _, v := range values {
+			ba.Append64(v, width)
+		}
+		if fmt.Sprintf("%X", p.ba.Bytes()) != fmt.Sprintf("%X", ba.Bytes()) {
+			t.Fatalf("width %d: packed data %X, want %X", width, p.ba.Bytes(), ba.Bytes())
+		}
+
+		if e := EncodePackedInts(values, width); fmt.Sprint(e.Decode()) != fmt.Sprint(values) {
+			t.Fatalf("width %d: EncodePackedInts did not encode the values", width)
+		}
+	}
+}
+
+func TestEncodePackedIntsWidthSelection(t *testing.T) {
+	tests := []struct {
+		id     int
+		values []uint64
+		width  int
+	}{
+		{0, []uint64{}, 1},
+		{1, []uint64{0, 0}, 1},
+		{2, []uint64{1, 0, 1}, 1},
+		{3, []uint64{3, 31, 7}, 5},
+		{4, []uint64{8191, 4096}, 13},
+		{5, []uint64{1 << 63}, 64},
+	}
+
+	for _, test := range tests {
+		p := EncodePackedInts(test.values, 0)
+		if p.Width() != test.width {
+			t.Errorf("%d: EncodePackedInts selected width %d, want %d", test.id, p.Width(), test.width)
+		}
+		if fmt.Sprint(p.Decode()) != fmt.Sprint(test.values) {
+			t.Errorf("%d: Decode returned %v, want %v", test.id, p.Decode(), test.values)
+		}
+	}
+}
+
+func TestPackedIntsPanics(t *testing.T) {
+	for id, f := range []func(){
+		func() { NewPackedInts(0, 1) },
+		func() { NewPackedInts(65, 1) },
+		func() { NewPackedInts(5, 2).Set(0, 32) },
+		func() { NewPackedInts(5, 2).Get(2) },
+		func() { NewPackedInts(3, 0).Append(8) },
+		func() { EncodePackedInts([]uint64{4}, 2) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,245 @@
+package bitarray
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PageStats are the statistics of the page cache of a PagedBitArray.
+type PageStats struct {
+	Hits      uint64 // accesses to a page in the cache
+	Misses    uint64 // accesses to a page read through ReadAt
+	Evictions uint64 // pages removed from the cache to make room for another one
+}
+
+// PagedBitArray is a read-only bit-array whose bits are read on demand, by pages of a fixed number of bytes,
+// through an io.ReaderAt. The least recently used pages are kept in a cache of a fixed number of pages.
+// As with a MappedBitArray, the padding bits of the last byte may be garbage, they are never returned.
+// It is safe for concurrent use if the underlying io.ReaderAt is, and reading a page does not block
+// the accesses to the other pages.
+type PagedBitArray struct {
+	r        io.ReaderAt
+	offset   int64 // position of the first byte of the bits in r
+	length   int
+	pageSize int
+	maxPages int
+
+	mu      sync.Mutex
+	pages   map[int]*list.Element // page number -> element of lru holding a *page
+	lru     *list.List            // most recently used first
+	loading map[int]*pageLoad     // pages being read through ReadAt
+	free    [][]byte              // buffers of evicted pages, or of failed reads
+	stats   PageStats
+}
+
+type page struct {
+	number int
+	data   []byte
+}
+
+// pageLoad is a page being read, done is closed once it is in the cache or its read failed with err.
+type pageLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// NewPagedBitArray returns the bit-array of the given length whose bytes, as returned by Bytes, start at offset in r.
+// Pages are pageSize bytes long and at most cachePages of them are cached.
+// It will panic if offset or length is negative, or if pageSize or cachePages is not positive.
+func NewPagedBitArray(r io.ReaderAt, offset int64, length, pageSize, cachePages int) *PagedBitArray {
+	if offset < 0 || length < 0 {
+		panic(fmt.Sprintf("offset and length should not be negative; given %d and %d", offset, length))
+	}
+	if pageSize <= 0 || cachePages <= 0 {
+		panic(fmt.Sprintf("page size and number of cached pages should be positive; given %d and %d", pageSize, cachePages))
+	}
+	return &PagedBitArray{
+		r:        r,
+		offset:   offset,
+		length:   length,
+		pageSize: pageSize,
+		maxPages: cachePages,
+		pages:    make(map[int]*list.Element),
+		lru:      list.New(),
+		loading:  make(map[int]*pageLoad),
+	}
+}
+
+// OpenPaged returns the bit-array serialized with WriteTo or MarshalBinary at the beginning of r,
+// with the same pages and cache as NewPagedBitArray. Only the header is read and checked.
+func OpenPaged(r io.ReaderAt, pageSize, cachePages int) (*PagedBitArray, error) {
+	var header [bitArrayHeaderSize]byte
+	n, err := r.ReadAt(header[:], 0)
+	if n < len(header) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	length, err := parseHeader(header[:])
+	if err != nil {
+		return nil, err
+	}
+	return NewPagedBitArray(r, bitArrayHeaderSize, length, pageSize, cachePages), nil
+}
+
+// Len returns the length (number of bits) of the bit-array.
+func (p *PagedBitArray) Len() int {
+	return p.length
+}
+
+// Stats returns the statistics of the page cache.
+func (p *PagedBitArray) Stats() PageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// GetBit returns the bit at position `index` and will panic if index is out of range.
+func (p *PagedBitArray) GetBit(index int) (byte, error) {
+	if index < 0 || index >= p.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", index, p.length))
+	}
+	var b [1]byte
+	if err := p.read(b[:], index>>3); err != nil {
+		return 0, err
+	}
+	return b[0] >> (7 - index&0x7) & 1, nil
+}
+
+// Extract extracts the bits in the range [i, j) into a uint64 with the same semantics as BitArray.Extract.
+func (p *PagedBitArray) Extract(i, j int) (uint64, error) {
+	if i < 0 || j < 0 {
+		panic(fmt.Sprintf("negative indexes are invalid; given (i=%d, j=%d)", i, j))
+	}
+	if i >= j {
+		panic(fmt.Sprintf("invalid indexes %d >= %d", i, j))
+	}
+	if j > p.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", j, p.length))
+	}
+	if j-i > 64 {
+		panic(fmt.Sprintf("the number of queried bits should not be greater than 64 bits; j - i = %d", j-i))
+	}
+
+	var buf [9]byte
+	from := i >> 3
+	n := (j+7)>>3 - from
+	if err := p.read(buf[:n], from); err != nil {
+		return 0, err
+	}
+	return (&BitArray{data: buf[:n]}).bitsAt(i&0x7, j-i), nil
+}
+
+// ExtractBitArray extracts the bits in the range [i, j) into a new, in memory, bit-array
+// with the same semantics as BitArray.ExtractBitArray.
+func (p *PagedBitArray) ExtractBitArray(i, j int) (*BitArray, error) {
+	if i < 0 || j < 0 {
+		panic(fmt.Sprintf("negative indexes are invalid; given (i=%d, j=%d)", i, j))
+	}
+	if i > j {
+		panic(fmt.Sprintf("invalid indexes %d > %d", i, j))
+	}
+	if j > p.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", j, p.length))
+	}
+	if i == j {
+		return New(), nil
+	}
+
+	from := i >> 3
+	buf := make([]byte, (j+7)>>3-from)
+	if err := p.read(buf, from); err != nil {
+		return nil, err
+	}
+	return (&BitArray{data: buf}).ExtractBitArray(i-from<<3, j-from<<3), nil
+}
+
+// read copies the bytes of the bit-array starting from byte `from` into dst.
+func (p *PagedBitArray) read(dst []byte, from int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(dst) > 0 {
+		data, err := p.page(from / p.pageSize)
+		if err != nil {
+			return err
+		}
+		n := copy(dst, data[from%p.pageSize:])
+		dst, from = dst[n:], from+n
+	}
+	return nil
+}
+
+// page returns the bytes of the given page, from the cache or read through ReadAt.
+// p.mu must be held, it is released while reading so that other pages can be accessed meanwhile,
+// and the returned bytes are only valid until it is released.
+func (p *PagedBitArray) page(number int) ([]byte, error) {
+	for {
+		if e, ok := p.pages[number]; ok {
+			p.stats.Hits++
+			p.lru.MoveToFront(e)
+			return e.Value.(*page).data, nil
+		}
+		ld, ok := p.loading[number]
+		if !ok {
+			break
+		}
+		// another goroutine is reading the page, wait for it and look again as the page may be evicted already
+		p.mu.Unlock()
+		<-ld.done
+		p.mu.Lock()
+		if ld.err != nil {
+			return nil, ld.err
+		}
+	}
+	p.stats.Misses++
+
+	start := number * p.pageSize
+	end := start + p.pageSize
+	if nbBytes := (p.length + 7) >> 3; end > nbBytes {
+		end = nbBytes
+	}
+	var data []byte
+	if len(p.free) > 0 {
+		data = p.free[len(p.free)-1][:end-start]
+		p.free = p.free[:len(p.free)-1]
+	} else {
+		data = make([]byte, end-start, p.pageSize)
+	}
+	ld := &pageLoad{done: make(chan struct{})}
+	p.loading[number] = ld
+
+	p.mu.Unlock()
+	n, err := p.r.ReadAt(data, p.offset+int64(start))
+	p.mu.Lock()
+	delete(p.loading, number)
+	defer close(ld.done)
+
+	if n < len(data) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		p.release(data)
+		ld.err = err
+		return nil, err
+	}
+
+	if p.lru.Len() >= p.maxPages {
+		// make room for the page, the buffer of the least recently used one is kept for the next read
+		pg := p.lru.Remove(p.lru.Back()).(*page)
+		delete(p.pages, pg.number)
+		p.stats.Evictions++
+		p.release(pg.data)
+	}
+	p.pages[number] = p.lru.PushFront(&page{number: number, data: data})
+	return data, nil
+}
+
+// release keeps the buffer of a page for a later read. p.mu must be held.
+func (p *PagedBitArray) release(data []byte) {
+	if len(p.free) < p.maxPages {
+		p.free = append(p.free, data)
+	}
+}
@@ -0,0 +1,273 @@
+package bitarray
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// countingReader counts the calls to ReadAt.
+type countingReader struct {
+	r     io.ReaderAt
+	mu    sync.Mutex
+	calls int
+}
+
+func (cr *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	cr.mu.Lock()
+	cr.calls++
+	cr.mu.Unlock()
+	return cr.r.ReadAt(p, off)
+}
+
+func TestPagedBitArray(t *testing.T) {
+	tests := []struct {
+		id         int
+		length     int
+		pageSize   int
+		cachePages int
+	}{
+		{0, 1, 1, 1},
+		{1, 100, 1, 3},
+		{2, 1000, 7, 2},
+		{3, 5000, 64, 4},
+		{4, 5003, 4096, 1},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, test := range tests {
+		want := randomBitArray(r, test.length)
+		// garbage before the bits and in the padding bits
+		data := append([]byte{0xff, 0xff, 0xff}, want.Bytes()...)
+		data[len(data)-1] |= 1<<uint(want.Padding()) - 1
+		p := NewPagedBitArray(bytes.NewReader(data), 3, test.length, test.pageSize, test.cachePages)
+
+		if p.Len() != test.length {
+			t.Errorf("%d: Len returned bad data %d, want %d", test.id, p.Len(), test.length)
+		}
+		for q := 0; q < 200; q++ {
+			i := r.Intn(test.length)
+			if got, err := p.GetBit(i); err != nil || got != want.GetBit(i) {
+				t.Fatalf("%d: GetBit(%d) returned bad data (%d, %v), want %d", test.id, i, got, err, want.GetBit(i))
+			}
+
+			j := i + 1 + r.Intn(test.length-i)
+			if j-i > 64 {
+				j = i + 1 + r.Intn(64)
+			}
+			if got, err := p.Extract(i, j); err != nil || got != want.Extract(i, j) {
+				t.Fatalf("%d: Extract(%d, %d) returned bad data (%x, %v), want %x", test.id, i, j, got, err, want.Extract(i, j))
+			}
+
+			j = i + r.Intn(test.length-i+1)
+			if got, err := p.ExtractBitArray(i, j); err != nil || !sameBits(got, want.ExtractBitArray(i, j)) {
+				t.Fatalf("%d: ExtractBitArray(%d, %d) returned bad data (%v)", test.id, i, j, err)
+			}
+		}
+
+		if got, err := p.ExtractBitArray(0, test.length); err != nil || !sameBits(got, want) {
+			t.Errorf("%d: ExtractBitArray of the whole bit-array returned bad data (%v)", test.id, err)
+		}
+	}
+}
+
+func TestPagedStats(t *testing.T) {
+	cr := &countingReader{r: bytes.NewReader(make([]byte, 100))}
+	p := NewPagedBitArray(cr, 0, 800, 10, 2)
+
+	// pages: 0 (miss), 0 (hit), 1 (miss), 0 (hit), 2 (miss, evicts 1), 1 (miss, evicts 0), 2 (hit)
+	for _, i := range []int{0, 79, 80, 5, 160, 80, 161} {
+		if _, err := p.GetBit(i); err != nil {
+			t.Fatalf("GetBit(%d) returned %v", i, err)
+		}
+	}
+
+	want := PageStats{Hits: 3, Misses: 4, Evictions: 2}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats returned bad data %+v, want %+v", got, want)
+	}
+	if cr.calls != 4 {
+		t.Errorf("ReadAt was called %d times, want 4", cr.calls)
+	}
+
+	// an extraction over the pages 0 (miss, evicts 1) and 1 (miss, evicts 2)
+	if _, err := p.Extract(75, 85); err != nil {
+		t.Fatalf("Extract returned %v", err)
+	}
+	want = PageStats{Hits: 3, Misses: 6, Evictions: 4}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats returned bad data %+v after Extract, want %+v", got, want)
+	}
+}
+
+func TestOpenPaged(t *testing.T) {
+	want := randomBitArray(rand.New(rand.NewSource(2)), 1234)
+	data, _ := want.MarshalBinary()
+
+	p, err := OpenPaged(bytes.NewReader(data), 16, 4)
+	if err != nil {
+		t.Fatalf("OpenPaged returned %v", err)
+	}
+	if got, err := p.ExtractBitArray(0, p.Len()); err != nil || !sameBits(got, want) {
+		t.Errorf("OpenPaged returned bad data (%v)", err)
+	}
+
+	if _, err := OpenPaged(bytes.NewReader(data[:5]), 16, 4); err != io.ErrUnexpectedEOF {
+		t.Errorf("OpenPaged of a truncated header returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := OpenPaged(bytes.NewReader([]byte("ABCD\x00\x00\x00\x00\x00\x00\x00\x00")), 16, 4); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("OpenPaged of a bad magic returned %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+type failingReader struct{}
+
+var errRead = errors.New("read failed")
+
+func (failingReader) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errRead
+}
+
+func TestPagedErrors(t *testing.T) {
+	p := NewPagedBitArray(failingReader{}, 0, 100, 8, 1)
+	if _, err := p.GetBit(3); err != errRead {
+		t.Errorf("GetBit returned %v, want %v", err, errRead)
+	}
+	if _, err := p.Extract(3, 20); err != errRead {
+		t.Errorf("Extract returned %v, want %v", err, errRead)
+	}
+	if _, err := p.ExtractBitArray(3, 20); err != errRead {
+		t.Errorf("ExtractBitArray returned %v, want %v", err, errRead)
+	}
+
+	// the data is shorter than the bit-array
+	p = NewPagedBitArray(bytes.NewReader(make([]byte, 10)), 0, 100, 8, 1)
+	if _, err := p.GetBit(99); err != io.ErrUnexpectedEOF {
+		t.Errorf("GetBit past the end of the data returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := p.GetBit(0); err != nil {
+		t.Errorf("GetBit returned %v", err)
+	}
+}
+
+func TestPagedConcurrent(t *testing.T) {
+	want := randomBitArray(rand.New(rand.NewSource(3)), 10000)
+	p := NewPagedBitArray(bytes.NewReader(want.Bytes()), 0, want.Len(), 32, 3)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for q := 0; q < 1000; q++ {
+				i := r.Intn(want.Len())
+				if got, err := p.GetBit(i); err != nil || got != want.GetBit(i) {
+					t.Errorf("GetBit(%d) returned bad data (%d, %v), want %d", i, got, err, want.GetBit(i))
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// blockingReader blocks the reads at offset block until unblock is closed, and fails the reads at offset fail.
+type blockingReader struct {
+	r       io.ReaderAt
+	block   int64
+	started chan struct{}
+	unblock chan struct{}
+	fail    int64
+}
+
+func (br *blockingReader) ReadAt(p []byte, off int64) (int, error) {
+	switch off {
+	case br.block:
+		close(br.started)
+		<-br.unblock
+	case br.fail:
+		return 0, errRead
+	}
+	return br.r.ReadAt(p, off)
+}
+
+func TestPagedConcurrentMiss(t *testing.T) {
+	br := &blockingReader{r: bytes.NewReader(make([]byte, 100)), block: 50, started: make(chan struct{}), unblock: make(chan struct{}), fail: -1}
+	p := NewPagedBitArray(br, 0, 800, 10, 2)
+	if _, err := p.GetBit(0); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 2)
+	for g := 0; g < 2; g++ {
+		go func() {
+			_, err := p.GetBit(400)
+			errs <- err
+		}()
+	}
+	<-br.started
+
+	// a hit on another page does not wait for the blocked read
+	if _, err := p.GetBit(1); err != nil {
+		t.Fatal(err)
+	}
+	close(br.unblock)
+	for g := 0; g < 2; g++ {
+		if err := <-errs; err != nil {
+			t.Errorf("GetBit of a blocked page returned %v", err)
+		}
+	}
+
+	// the page was read once, the second goroutine waited for it
+	want := PageStats{Hits: 2, Misses: 2}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats returned bad data %+v, want %+v", got, want)
+	}
+}
+
+func TestPagedFailedReadKeepsCache(t *testing.T) {
+	br := &blockingReader{r: bytes.NewReader(make([]byte, 100)), block: -1, fail: 50}
+	p := NewPagedBitArray(br, 0, 800, 10, 2)
+	for _, i := range []int{0, 80, 400, 0, 80} {
+		if _, err := p.GetBit(i); err != nil && i != 400 {
+			t.Fatalf("GetBit(%d) returned %v", i, err)
+		}
+	}
+
+	// the failed read of page 5 evicted nothing
+	want := PageStats{Hits: 2, Misses: 3}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats returned bad data %+v, want %+v", got, want)
+	}
+	if p.lru.Len() != 2 || len(p.free) != 1 {
+		t.Errorf("the cache holds %d pages and %d free buffers, want 2 and 1", p.lru.Len(), len(p.free))
+	}
+}
+
+func TestPagedPanics(t *testing.T) {
+	p := NewPagedBitArray(bytes.NewReader(make([]byte, 2)), 0, 10, 1, 1)
+	for id, f := range []func(){
+		func() { NewPagedBitArray(bytes.NewReader(nil), -1, 10, 1, 1) },
+		func() { NewPagedBitArray(bytes.NewReader(nil), 0, -1, 1, 1) },
+		func() { NewPagedBitArray(bytes.NewReader(nil), 0, 10, 0, 1) },
+		func() { NewPagedBitArray(bytes.NewReader(nil), 0, 10, 1, 0) },
+		func() { p.GetBit(10) },
+		func() { p.Extract(3, 3) },
+		func() { p.Extract(3, 11) },
+		func() { p.ExtractBitArray(4, 3) },
+		func() { p.ExtractBitArray(0, 11) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,177 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+const (
+	blockBits      = 512
+	superBlockBits = 4096
+	blocksPerSuper = superBlockBits / blockBits
+)
+
+// RankSelect is a directory over a bit-array answering rank and select queries.
+// Ranks are precomputed for blocks of 512 bits (relative to their 4096-bit super block, on 16 bits)
+// and for super blocks of 4096 bits (on 64 bits), which adds less than 5% to the size of the bit-array.
+// The directory is not updated when the bit-array changes, it must be rebuilt after any modification.
+type RankSelect struct {
+	ba     *BitArray
+	length int
+	ones   int
+	supers []uint64
+	blocks []uint16
+}
+
+// NewRankSelect builds the rank/select directory of ba.
+func NewRankSelect(ba *BitArray) *RankSelect {
+	length := ba.Len()
+	nbBlocks := (length + blockBits - 1) / blockBits
+	rs := &RankSelect{
+		ba:     ba,
+		length: length,
+		supers: make([]uint64, (length+superBlockBits-1)/superBlockBits),
+		blocks: make([]uint16, nbBlocks),
+	}
+
+	ones, inSuper := 0, 0
+	for b := 0; b < nbBlocks; b++ {
+		if b%blocksPerSuper == 0 {
+			rs.supers[b/blocksPerSuper] = uint64(ones)
+			inSuper = 0
+		}
+		rs.blocks[b] = uint16(inSuper)
+
+		count := 0
+		for w := b * (blockBits / 64); w < (b+1)*(blockBits/64); w++ {
+			count += bits.OnesCount64(ba.word(w))
+		}
+		ones += count
+		inSuper += count
+	}
+	rs.ones = ones
+	return rs
+}
+
+// word returns the bits [64k, 64k+64) of the bit-array, bits past the end of the data are 0's.
+func (ba *BitArray) word(k int) uint64 {
+	return load64(ba.data, k<<3)
+}
+
+// Len returns the length of the indexed bit-array.
+func (rs *RankSelect) Len() int {
+	return rs.length
+}
+
+// Ones returns the number of bits set to `1`.
+func (rs *RankSelect) Ones() int {
+	return rs.ones
+}
+
+// Zeros returns the number of bits set to `0`.
+func (rs *RankSelect) Zeros() int {
+	return rs.length - rs.ones
+}
+
+// SizeInBits returns the size of the directory in bits, excluding the bit-array itself.
+func (rs *RankSelect) SizeInBits() int {
+	return 64*len(rs.supers) + 16*len(rs.blocks)
+}
+
+// Rank1 returns the number of bits set to `1` in the range [0, i).
+// It will panic if i is negative or greater than the length of the bit-array.
+func (rs *RankSelect) Rank1(i int) int {
+	if i < 0 || i > rs.length {
+		panic(fmt.Sprintf("rank index out of range [%d] with length %d", i, rs.length))
+	}
+	if i == rs.length {
+		return rs.ones
+	}
+
+	b := i / blockBits
+	rank := int(rs.supers[b/blocksPerSuper]) + int(rs.blocks[b])
+	w := b * (blockBits / 64)
+	for ; w < i>>6; w++ {
+		rank += bits.OnesCount64(rs.ba.word(w))
+	}
+	if r := i & 63; r != 0 {
+		rank += bits.OnesCount64(rs.ba.word(w) >> (64 - r))
+	}
+	return rank
+}
+
+// Rank0 returns the number of bits set to `0` in the range [0, i).
+// It will panic if i is negative or greater than the length of the bit-array.
+func (rs *RankSelect) Rank0(i int) int {
+	return i - rs.Rank1(i)
+}
+
+// Select1 returns the position of the k-th bit set to `1`, k starting from 0.
+// It will panic if k is negative or not smaller than the number of bits set to `1`.
+func (rs *RankSelect) Select1(k int) int {
+	if k < 0 || k >= rs.ones {
+		panic(fmt.Sprintf("select index out of range [%d] with %d ones", k, rs.ones))
+	}
+
+	s := sort.Search(len(rs.supers), func(s int) bool { return int(rs.supers[s]) > k }) - 1
+	k -= int(rs.supers[s])
+
+	b := s * blocksPerSuper
+	for b+1 < len(rs.blocks) && b+1 < (s+1)*blocksPerSuper && int(rs.blocks[b+1]) <= k {
+		b++
+	}
+	k -= int(rs.blocks[b])
+
+	for w := b * (blockBits / 64); ; w++ {
+		word := rs.ba.word(w)
+		if c := bits.OnesCount64(word); k >= c {
+			k -= c
+			continue
+		}
+		return w<<6 + selectInWord(word, k)
+	}
+}
+
+// Select0 returns the position of the k-th bit set to `0`, k starting from 0.
+// It will panic if k is negative or not smaller than the number of bits set to `0`.
+func (rs *RankSelect) Select0(k int) int {
+	if k < 0 || k >= rs.Zeros() {
+		panic(fmt.Sprintf("select index out of range [%d] with %d zeros", k, rs.Zeros()))
+	}
+
+	zerosBeforeSuper := func(s int) int { return s*superBlockBits - int(rs.supers[s]) }
+	zerosInSuperBeforeBlock := func(b int) int { return (b%blocksPerSuper)*blockBits - int(rs.blocks[b]) }
+
+	s := sort.Search(len(rs.supers), func(s int) bool { return zerosBeforeSuper(s) > k }) - 1
+	k -= zerosBeforeSuper(s)
+
+	b := s * blocksPerSuper
+	for b+1 < len(rs.blocks) && b+1 < (s+1)*blocksPerSuper && zerosInSuperBeforeBlock(b+1) <= k {
+		b++
+	}
+	k -= zerosInSuperBeforeBlock(b)
+
+	for w := b * (blockBits / 64); ; w++ {
+		word := ^rs.ba.word(w)
+		if c := bits.OnesCount64(word); k >= c {
+			k -= c
+			continue
+		}
+		return w<<6 + selectInWord(word, k)
+	}
+}
+
+// selectInWord returns the position, starting from the most significant bit, of the k-th bit set to `1` in w.
+// w must have more than k bits set.
+func selectInWord(w uint64, k int) int {
+	pos := 0
+	for width := 32; width > 0; width >>= 1 {
+		if c := bits.OnesCount64(w >> (64 - width)); k >= c {
+			k -= c
+			pos += width
+			w <<= width
+		}
+	}
+	return pos
+}
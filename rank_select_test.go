@@ -0,0 +1,90 @@
+package bitarray
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRankSelect(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	tests := []struct {
+		id      int
+		length  int
+		density float64
+	}{
+		{0, 0, 0.5},
+		{1, 1, 1},
+		{2, 63, 0.5},
+		{3, 512, 0.5},
+		{4, 4096, 0.01},
+		{5, 10000, 0.5},
+		{6, 20000, 0.99},
+		{7, 33333, 0.001},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		for i := 0; i < test.length; i++ {
+			if r.Float64() < test.density {
+				ba.AppendOne()
+			} else {
+				ba.AppendZero()
+			}
+		}
+		rs := NewRankSelect(ba)
+
+		rank := 0
+		ones, zeros := []int{}, []int{}
+		for i := 0; i < test.length; i++ {
+			if rs.Rank1(i) != rank || rs.Rank0(i) != i-rank {
+				t.Fatalf("%d: Rank1(%d) returned %d, want %d", test.id, i, rs.Rank1(i), rank)
+			}
+			if ba.GetBit(i) == 1 {
+				rank++
+				ones = append(ones, i)
+			} else {
+				zeros = append(zeros, i)
+			}
+		}
+		if rs.Rank1(test.length) != rank || rs.Ones() != len(ones) || rs.Zeros() != len(zeros) {
+			t.Errorf("%d: bad number of ones %d, want %d", test.id, rs.Ones(), len(ones))
+		}
+
+		for k, i := range ones {
+			if rs.Select1(k) != i {
+				t.Fatalf("%d: Select1(%d) returned %d, want %d", test.id, k, rs.Select1(k), i)
+			}
+		}
+		for k, i := range zeros {
+			if rs.Select0(k) != i {
+				t.Fatalf("%d: Select0(%d) returned %d, want %d", test.id, k, rs.Select0(k), i)
+			}
+		}
+
+		if test.length > 0 && float64(rs.SizeInBits()) > 0.05*float64(test.length)+80 {
+			t.Errorf("%d: directory uses %d bits for %d bits", test.id, rs.SizeInBits(), test.length)
+		}
+	}
+}
+
+func TestSelectOutOfRange(t *testing.T) {
+	ba := New()
+	ba.AppendString("0110")
+	rs := NewRankSelect(ba)
+
+	for _, f := range []func(){
+		func() { rs.Select1(2) },
+		func() { rs.Select0(2) },
+		func() { rs.Select1(-1) },
+		func() { rs.Rank1(5) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("out of range query did not panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,130 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Reverse reverses in place the order of the bits of the bit-array.
+func (ba *BitArray) Reverse() {
+	ba.ReverseRange(0, ba.Len())
+}
+
+// ReverseRange reverses in place the order of the bits in the range [i, j): the bit at position i+k moves to position j-1-k.
+// Indexes must not be negative, j must be greater or equal to i and j must not be out of range, otherwise ReverseRange will panic.
+func (ba *BitArray) ReverseRange(i, j int) {
+	checkViewRange(i, j, ba.Len())
+
+	// swap reversed words from both ends
+	for ; j-i >= 128; i, j = i+64, j-64 {
+		a, b := ba.bitsAt(i, 64), ba.bitsAt(j-64, 64)
+		ba.setBitsAt(i, 64, bits.Reverse64(b))
+		ba.setBitsAt(j-64, 64, bits.Reverse64(a))
+	}
+
+	n := j - i
+	if n <= 64 {
+		ba.setBitsAt(i, n, reverseLow(ba.bitsAt(i, n), n))
+		return
+	}
+	h := n / 2
+	a, b := ba.bitsAt(i, h), ba.bitsAt(i+h, n-h)
+	ba.setBitsAt(i, n-h, reverseLow(b, n-h))
+	ba.setBitsAt(i+n-h, h, reverseLow(a, h))
+}
+
+// reverseLow reverses the order of the n lowest bits of v.
+func reverseLow(v uint64, n int) uint64 {
+	if n == 0 {
+		return 0
+	}
+	return bits.Reverse64(v) >> uint(64-n)
+}
+
+// ReverseBitsInEachByte reverses in place the order of the bits of each byte of the bit-array,
+// converting between the MSB-first and the LSB-first order of the bits in bytes.
+// The length of the bit-array must be a multiple of 8, otherwise ReverseBitsInEachByte will panic.
+func (ba *BitArray) ReverseBitsInEachByte() {
+	ba.checkByteAligned()
+	ba.beforeWrite(0, len(ba.data))
+	reverseBitsInBytes(ba.data, ba.data)
+}
+
+// SwapBytes reverses in place the order of the bytes in each group of size bytes of the bit-array,
+// converting integers of size bytes between big-endian and little-endian.
+// It will panic if size is not positive or if the length of the bit-array is not a multiple of 8*size.
+func (ba *BitArray) SwapBytes(size int) {
+	if size <= 0 {
+		panic(fmt.Sprintf("size should be positive; given %d", size))
+	}
+	if ba.Len()%(8*size) != 0 {
+		panic(fmt.Sprintf("bit-array length should be a multiple of %d; given %d", 8*size, ba.Len()))
+	}
+	data := ba.data[:ba.Len()/8]
+	ba.beforeWrite(0, len(data))
+
+	switch size {
+	case 1:
+	case 2:
+		for k := 0; k < len(data); k += 2 {
+			binary.BigEndian.PutUint16(data[k:], bits.ReverseBytes16(binary.BigEndian.Uint16(data[k:])))
+		}
+	case 4:
+		for k := 0; k < len(data); k += 4 {
+			binary.BigEndian.PutUint32(data[k:], bits.ReverseBytes32(binary.BigEndian.Uint32(data[k:])))
+		}
+	case 8:
+		for k := 0; k < len(data); k += 8 {
+			binary.BigEndian.PutUint64(data[k:], bits.ReverseBytes64(binary.BigEndian.Uint64(data[k:])))
+		}
+	default:
+		for k := 0; k < len(data); k += size {
+			for a, b := k, k+size-1; a < b; a, b = a+1, b-1 {
+				data[a], data[b] = data[b], data[a]
+			}
+		}
+	}
+}
+
+// FromLSBFirst returns a new bit-array of the given length whose bits are packed LSB-first in data:
+// the bit at position i is the bit i%8, starting from the least significant bit, of the byte i/8.
+// Bits of data past length are ignored. It will panic if length is negative or if data holds fewer than length bits.
+func FromLSBFirst(data []byte, length int) *BitArray {
+	if length < 0 || length > 8*len(data) {
+		panic(fmt.Sprintf("length should be between 0 and %d; given %d", 8*len(data), length))
+	}
+	ba := NewZeros(length)
+	if length > 0 {
+		reverseBitsInBytes(ba.data, data[:len(ba.data)])
+		ba.clearPadding()
+	}
+	return ba
+}
+
+// BytesLSBFirst returns the bits of the bit-array packed LSB-first, the inverse of FromLSBFirst:
+// the bit at position i is the bit i%8, starting from the least significant bit, of the byte i/8.
+// Padding bits, the highest ones of the last byte, are 0's.
+func (ba *BitArray) BytesLSBFirst() []byte {
+	data := make([]byte, (ba.Len()+7)/8)
+	reverseBitsInBytes(data, ba.data[:len(data)])
+	return data
+}
+
+// reverseBitsInBytes writes into dst the bytes of src with their bits reversed, dst and src have the same length.
+func reverseBitsInBytes(dst, src []byte) {
+	for len(src) >= 8 {
+		// Reverse64 reverses the bits and the bytes, ReverseBytes64 puts the bytes back in order
+		binary.BigEndian.PutUint64(dst, bits.ReverseBytes64(bits.Reverse64(binary.BigEndian.Uint64(src))))
+		dst, src = dst[8:], src[8:]
+	}
+	for k := range src {
+		dst[k] = bits.Reverse8(src[k])
+	}
+}
+
+func (ba *BitArray) checkByteAligned() {
+	if ba.Len()%8 != 0 {
+		panic(fmt.Sprintf("bit-array length should be a multiple of 8; given %d", ba.Len()))
+	}
+}
@@ -0,0 +1,163 @@
+package bitarray
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		id       int
+		bits     string
+		reversed string
+	}{
+		{0, "", ""},
+		{1, "1", "1"},
+		{2, "10", "01"},
+		{3, "1101000", "0001011"},
+		{4, "110111101010110110111110111011110000", "000011110111011111011011010101111011"},
+	}
+
+	for _, test := range tests {
+		ba := bitArrayOfString(test.bits)
+		ba.Reverse()
+		if got := bitString(ba); got != test.reversed {
+			t.Errorf("%d: Reverse returned bad data %s, want %s", test.id, got, test.reversed)
+		}
+	}
+}
+
+func TestReverseRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 500; id++ {
+		ba := randomBitArray(r, r.Intn(400))
+		s := bitString(ba)
+		i := r.Intn(ba.Len() + 1)
+		j := i + r.Intn(ba.Len()-i+1)
+
+		ba.ReverseRange(i, j)
+		want := s[:i] + reverseString(s[i:j]) + s[j:]
+		if got := bitString(ba); got != want {
+			t.Fatalf("%d: ReverseRange(%d, %d) of %s returned bad data %s, want %s", id, i, j, s, got, want)
+		}
+		if ba.Count() != bitArrayOfString(want).Count() {
+			t.Fatalf("%d: ReverseRange(%d, %d) left padding bits set", id, i, j)
+		}
+	}
+}
+
+func TestReverseBitsInEachByte(t *testing.T) {
+	tests := []struct {
+		id       int
+		data     []byte
+		reversed []byte
+	}{
+		{0, []byte{}, []byte{}},
+		{1, []byte{0x01}, []byte{0x80}},
+		{2, []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x0f}, []byte{0x48, 0x2c, 0x6a, 0x1e, 0x59, 0x3d, 0x7b, 0x0f, 0xf0}},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendBytes(test.data, 0)
+		ba.ReverseBitsInEachByte()
+		if got := ba.Bytes(); !bytes.Equal(got[:len(test.reversed)], test.reversed) || ba.Len() != 8*len(test.data) {
+			t.Errorf("%d: ReverseBitsInEachByte returned bad data %x, want %x", test.id, got, test.reversed)
+		}
+	}
+}
+
+func TestSwapBytes(t *testing.T) {
+	tests := []struct {
+		id      int
+		data    []byte
+		size    int
+		swapped []byte
+	}{
+		{0, []byte{}, 2, []byte{}},
+		{1, []byte{1, 2, 3, 4}, 1, []byte{1, 2, 3, 4}},
+		{2, []byte{1, 2, 3, 4}, 2, []byte{2, 1, 4, 3}},
+		{3, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 4, []byte{4, 3, 2, 1, 8, 7, 6, 5}},
+		{4, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 8, []byte{8, 7, 6, 5, 4, 3, 2, 1}},
+		{5, []byte{1, 2, 3, 4, 5, 6}, 3, []byte{3, 2, 1, 6, 5, 4}},
+		{6, []byte{1, 2, 3, 4, 5, 6}, 6, []byte{6, 5, 4, 3, 2, 1}},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendBytes(test.data, 0)
+		ba.SwapBytes(test.size)
+		if got := ba.Bytes(); !bytes.Equal(got[:len(test.swapped)], test.swapped) {
+			t.Errorf("%d: SwapBytes(%d) returned bad data %x, want %x", test.id, test.size, got, test.swapped)
+		}
+	}
+}
+
+func TestLSBFirst(t *testing.T) {
+	tests := []struct {
+		id     int
+		data   []byte
+		length int
+		bits   string
+		packed []byte
+	}{
+		{0, []byte{}, 0, "", []byte{}},
+		{1, []byte{0x01}, 1, "1", []byte{0x01}},
+		{2, []byte{0xfd}, 3, "101", []byte{0x05}},
+		{3, []byte{0x01, 0x80}, 16, "1000000000000001", []byte{0x01, 0x80}},
+		{4, []byte{0x0b, 0xff, 0xff}, 10, "1101000011", []byte{0x0b, 0x03}},
+		{
+			5,
+			[]byte{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x03},
+			72,
+			"100000000100000000100000000100000000100000000100000000100000000111000000",
+			[]byte{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x03},
+		},
+	}
+
+	for _, test := range tests {
+		ba := FromLSBFirst(test.data, test.length)
+		if got := bitString(ba); got != test.bits {
+			t.Errorf("%d: FromLSBFirst returned bad data %s, want %s", test.id, got, test.bits)
+		}
+		if ba.Count() != bitArrayOfString(test.bits).Count() {
+			t.Errorf("%d: FromLSBFirst left padding bits set", test.id)
+		}
+		if got := ba.BytesLSBFirst(); !bytes.Equal(got, test.packed) {
+			t.Errorf("%d: BytesLSBFirst returned bad data %x, want %x", test.id, got, test.packed)
+		}
+	}
+}
+
+func TestReversePanics(t *testing.T) {
+	ba := NewZeros(12)
+	for id, f := range []func(){
+		func() { ba.ReverseRange(-1, 3) },
+		func() { ba.ReverseRange(4, 3) },
+		func() { ba.ReverseRange(0, 13) },
+		func() { ba.ReverseBitsInEachByte() },
+		func() { ba.SwapBytes(1) },
+		func() { NewZeros(16).SwapBytes(0) },
+		func() { NewZeros(16).SwapBytes(4) },
+		func() { FromLSBFirst([]byte{0}, 9) },
+		func() { FromLSBFirst([]byte{0}, -1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
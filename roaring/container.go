@@ -0,0 +1,449 @@
+package roaring
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// arrayMaxSize is the largest cardinality stored in an array container,
+// above it a bitmap container is more compact.
+const arrayMaxSize = 4096
+
+// container holds the 16 lowest bits of the values sharing the same 16 highest bits.
+// Mutating methods return the container that should replace the receiver
+// as they may switch to another representation.
+type container interface {
+	add(x uint16) container
+	remove(x uint16) container
+	contains(x uint16) bool
+	cardinality() int
+	// forEach calls f on each value in increasing order and stops as soon as f returns false.
+	// It returns false if the iteration was stopped.
+	forEach(f func(x uint16) bool) bool
+	clone() container
+	// serializedSize is the number of bytes of the container in the portable format.
+	serializedSize() int
+}
+
+// arrayContainer stores the values in a sorted slice.
+type arrayContainer struct {
+	values []uint16
+}
+
+func (ac *arrayContainer) search(x uint16) int {
+	return sort.Search(len(ac.values), func(i int) bool { return ac.values[i] >= x })
+}
+
+func (ac *arrayContainer) add(x uint16) container {
+	i := ac.search(x)
+	if i < len(ac.values) && ac.values[i] == x {
+		return ac
+	}
+	if len(ac.values) == arrayMaxSize {
+		return ac.toBitmap().add(x)
+	}
+
+	ac.values = append(ac.values, 0)
+	copy(ac.values[i+1:], ac.values[i:])
+	ac.values[i] = x
+	return ac
+}
+
+func (ac *arrayContainer) remove(x uint16) container {
+	i := ac.search(x)
+	if i < len(ac.values) && ac.values[i] == x {
+		ac.values = append(ac.values[:i], ac.values[i+1:]...)
+	}
+	return ac
+}
+
+func (ac *arrayContainer) contains(x uint16) bool {
+	i := ac.search(x)
+	return i < len(ac.values) && ac.values[i] == x
+}
+
+func (ac *arrayContainer) cardinality() int {
+	return len(ac.values)
+}
+
+func (ac *arrayContainer) forEach(f func(x uint16) bool) bool {
+	for _, x := range ac.values {
+		if !f(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ac *arrayContainer) clone() container {
+	values := make([]uint16, len(ac.values))
+	copy(values, ac.values)
+	return &arrayContainer{values: values}
+}
+
+func (ac *arrayContainer) serializedSize() int {
+	return 2 * len(ac.values)
+}
+
+func (ac *arrayContainer) toBitmap() *bitmapContainer {
+	bc := newBitmapContainer()
+	for _, x := range ac.values {
+		bc.bits.SetBit(int(x))
+	}
+	bc.card = len(ac.values)
+	return bc
+}
+
+// bitmapContainer stores the values as a bit-array of 2^16 bits,
+// where the bit at position x is set if x belongs to the container.
+type bitmapContainer struct {
+	bits *bitarray.BitArray
+	card int
+}
+
+func newBitmapContainer() *bitmapContainer {
+	return &bitmapContainer{bits: bitarray.NewZeros(1 << 16)}
+}
+
+func (bc *bitmapContainer) add(x uint16) container {
+	if bc.bits.GetBit(int(x)) == 0 {
+		bc.bits.SetBit(int(x))
+		bc.card++
+	}
+	return bc
+}
+
+func (bc *bitmapContainer) remove(x uint16) container {
+	if bc.bits.GetBit(int(x)) == 1 {
+		bc.bits.ClearBit(int(x))
+		bc.card--
+	}
+	if bc.card <= arrayMaxSize {
+		return bc.toArray()
+	}
+	return bc
+}
+
+func (bc *bitmapContainer) contains(x uint16) bool {
+	return bc.bits.GetBit(int(x)) == 1
+}
+
+func (bc *bitmapContainer) cardinality() int {
+	return bc.card
+}
+
+func (bc *bitmapContainer) forEach(f func(x uint16) bool) bool {
+	for i := bc.bits.NextOne(0); i >= 0; i = bc.bits.NextOne(i + 1) {
+		if !f(uint16(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (bc *bitmapContainer) clone() container {
+	return &bitmapContainer{bits: bc.bits.Clone(), card: bc.card}
+}
+
+func (bc *bitmapContainer) serializedSize() int {
+	return 8 * 1024
+}
+
+func (bc *bitmapContainer) toArray() *arrayContainer {
+	values := make([]uint16, 0, bc.card)
+	bc.forEach(func(x uint16) bool {
+		values = append(values, x)
+		return true
+	})
+	return &arrayContainer{values: values}
+}
+
+// numberOfRuns returns the number of runs of consecutive values in the container.
+func (bc *bitmapContainer) numberOfRuns() int {
+	runs := 0
+	var previous uint64
+	for w := 0; w < 1024; w++ {
+		word := bc.bits.Extract(w<<6, (w+1)<<6)
+		// a run starts on every set bit whose preceding bit is not set
+		runs += bits.OnesCount64(word &^ (word>>1 | previous<<63))
+		previous = word & 1
+	}
+	return runs
+}
+
+// normalize returns the smallest of the array and bitmap representations of the container.
+func (bc *bitmapContainer) normalize() container {
+	if bc.card <= arrayMaxSize {
+		return bc.toArray()
+	}
+	return bc
+}
+
+// interval is a run of consecutive values [start, start + length].
+type interval struct {
+	start  uint16
+	length uint16
+}
+
+func (iv interval) last() int {
+	return int(iv.start) + int(iv.length)
+}
+
+// runContainer stores the values as sorted, non overlapping and non adjacent runs.
+// Run containers are only created by Bitmap.RunOptimize and when deserializing,
+// any mutation switches back to an array or a bitmap container.
+type runContainer struct {
+	runs []interval
+}
+
+func (rc *runContainer) add(x uint16) container {
+	if rc.contains(x) {
+		return rc
+	}
+	return rc.toEfficientContainer().add(x)
+}
+
+func (rc *runContainer) remove(x uint16) container {
+	if !rc.contains(x) {
+		return rc
+	}
+	return rc.toEfficientContainer().remove(x)
+}
+
+func (rc *runContainer) contains(x uint16) bool {
+	i := sort.Search(len(rc.runs), func(i int) bool { return rc.runs[i].last() >= int(x) })
+	return i < len(rc.runs) && rc.runs[i].start <= x
+}
+
+func (rc *runContainer) cardinality() int {
+	card := 0
+	for _, iv := range rc.runs {
+		card += int(iv.length) + 1
+	}
+	return card
+}
+
+func (rc *runContainer) forEach(f func(x uint16) bool) bool {
+	for _, iv := range rc.runs {
+		for x := int(iv.start); x <= iv.last(); x++ {
+			if !f(uint16(x)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (rc *runContainer) clone() container {
+	runs := make([]interval, len(rc.runs))
+	copy(runs, rc.runs)
+	return &runContainer{runs: runs}
+}
+
+func (rc *runContainer) serializedSize() int {
+	return 2 + 4*len(rc.runs)
+}
+
+func (rc *runContainer) toBitmap() *bitmapContainer {
+	bc := newBitmapContainer()
+	for _, iv := range rc.runs {
+		for x := int(iv.start); x <= iv.last(); x++ {
+			bc.bits.SetBit(x)
+		}
+		bc.card += int(iv.length) + 1
+	}
+	return bc
+}
+
+// toEfficientContainer converts the run container to an array or a bitmap container.
+func (rc *runContainer) toEfficientContainer() container {
+	return rc.toBitmap().normalize()
+}
+
+func newRunContainer(c container) *runContainer {
+	rc := &runContainer{}
+	c.forEach(func(x uint16) bool {
+		if n := len(rc.runs); n > 0 && rc.runs[n-1].last()+1 == int(x) {
+			rc.runs[n-1].length++
+		} else {
+			rc.runs = append(rc.runs, interval{start: x})
+		}
+		return true
+	})
+	return rc
+}
+
+// numberOfRuns returns the number of runs needed to represent the container.
+func numberOfRuns(c container) int {
+	switch c := c.(type) {
+	case *runContainer:
+		return len(c.runs)
+	case *bitmapContainer:
+		return c.numberOfRuns()
+	case *arrayContainer:
+		runs := 0
+		for i, x := range c.values {
+			if i == 0 || c.values[i-1]+1 != x {
+				runs++
+			}
+		}
+		return runs
+	}
+	panic("unknown container type")
+}
+
+// asBitmap returns the container as a bitmap container, the result may share storage with c.
+func asBitmap(c container) *bitmapContainer {
+	switch c := c.(type) {
+	case *bitmapContainer:
+		return c
+	case *arrayContainer:
+		return c.toBitmap()
+	case *runContainer:
+		return c.toBitmap()
+	}
+	panic("unknown container type")
+}
+
+// asBitmapCopy returns the container as a bitmap container that does not share storage with c.
+func asBitmapCopy(c container) *bitmapContainer {
+	if bc, ok := c.(*bitmapContainer); ok {
+		return bc.clone().(*bitmapContainer)
+	}
+	return asBitmap(c)
+}
+
+func and(c1, c2 container) container {
+	a1, ok1 := c1.(*arrayContainer)
+	a2, ok2 := c2.(*arrayContainer)
+	switch {
+	case ok1 && ok2:
+		values := make([]uint16, 0)
+		i, j := 0, 0
+		for i < len(a1.values) && j < len(a2.values) {
+			switch {
+			case a1.values[i] < a2.values[j]:
+				i++
+			case a1.values[i] > a2.values[j]:
+				j++
+			default:
+				values = append(values, a1.values[i])
+				i++
+				j++
+			}
+		}
+		return &arrayContainer{values: values}
+	case ok1:
+		return filter(a1, c2, true)
+	case ok2:
+		return filter(a2, c1, true)
+	}
+
+	bc := asBitmapCopy(c1)
+	bc.bits.And(asBitmap(c2).bits)
+	bc.card = bc.bits.Count()
+	return bc.normalize()
+}
+
+func or(c1, c2 container) container {
+	a1, ok1 := c1.(*arrayContainer)
+	a2, ok2 := c2.(*arrayContainer)
+	if ok1 && ok2 && len(a1.values)+len(a2.values) <= arrayMaxSize {
+		values := make([]uint16, 0, len(a1.values)+len(a2.values))
+		i, j := 0, 0
+		for i < len(a1.values) && j < len(a2.values) {
+			switch {
+			case a1.values[i] < a2.values[j]:
+				values = append(values, a1.values[i])
+				i++
+			case a1.values[i] > a2.values[j]:
+				values = append(values, a2.values[j])
+				j++
+			default:
+				values = append(values, a1.values[i])
+				i++
+				j++
+			}
+		}
+		values = append(values, a1.values[i:]...)
+		values = append(values, a2.values[j:]...)
+		return &arrayContainer{values: values}
+	}
+
+	bc := asBitmapCopy(c1)
+	bc.bits.Or(asBitmap(c2).bits)
+	bc.card = bc.bits.Count()
+	return bc.normalize()
+}
+
+func xor(c1, c2 container) container {
+	a1, ok1 := c1.(*arrayContainer)
+	a2, ok2 := c2.(*arrayContainer)
+	if ok1 && ok2 && len(a1.values)+len(a2.values) <= arrayMaxSize {
+		values := make([]uint16, 0, len(a1.values)+len(a2.values))
+		i, j := 0, 0
+		for i < len(a1.values) && j < len(a2.values) {
+			switch {
+			case a1.values[i] < a2.values[j]:
+				values = append(values, a1.values[i])
+				i++
+			case a1.values[i] > a2.values[j]:
+				values = append(values, a2.values[j])
+				j++
+			default:
+				i++
+				j++
+			}
+		}
+		values = append(values, a1.values[i:]...)
+		values = append(values, a2.values[j:]...)
+		return &arrayContainer{values: values}
+	}
+
+	bc := asBitmapCopy(c1)
+	bc.bits.Xor(asBitmap(c2).bits)
+	bc.card = bc.bits.Count()
+	return bc.normalize()
+}
+
+func andNot(c1, c2 container) container {
+	if a1, ok := c1.(*arrayContainer); ok {
+		return filter(a1, c2, false)
+	}
+
+	bc := asBitmapCopy(c1)
+	bc.bits.AndNot(asBitmap(c2).bits)
+	bc.card = bc.bits.Count()
+	return bc.normalize()
+}
+
+// filter returns the values of ac that are (keep = true) or are not (keep = false) contained in c.
+func filter(ac *arrayContainer, c container, keep bool) container {
+	values := make([]uint16, 0)
+	for _, x := range ac.values {
+		if c.contains(x) == keep {
+			values = append(values, x)
+		}
+	}
+	return &arrayContainer{values: values}
+}
+
+// writeWords writes the container into buf as 1024 little-endian 64-bit words
+// as laid out by the portable serialization format: value x is the bit x%64 of the word x/64.
+func (bc *bitmapContainer) writeWords(buf []byte) {
+	for w := 0; w < 1024; w++ {
+		binary.LittleEndian.PutUint64(buf[w<<3:], bits.Reverse64(bc.bits.Extract(w<<6, (w+1)<<6)))
+	}
+}
+
+// readBitmapWords is the inverse of writeWords.
+func readBitmapWords(buf []byte) *bitmapContainer {
+	ba := bitarray.New()
+	for w := 0; w < 1024; w++ {
+		ba.Append64(bits.Reverse64(binary.LittleEndian.Uint64(buf[w<<3:])), 64)
+	}
+	return &bitmapContainer{bits: ba, card: ba.Count()}
+}
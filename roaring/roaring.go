@@ -0,0 +1,268 @@
+// Package roaring implements compressed bitmaps of 32-bit unsigned integers.
+// The 32-bit space is partitioned into 2^16 chunks sharing the same 16 highest bits,
+// each non-empty chunk is stored in a container which is either a sorted array,
+// a dense bit-array of 2^16 bits or a list of runs, depending on what is the most compact.
+// Bitmaps can be exchanged with other implementations through the Roaring portable serialization format
+// (https://github.com/RoaringBitmap/RoaringFormatSpec).
+package roaring
+
+import (
+	"sort"
+)
+
+// Bitmap is a compressed bitmap of uint32 values. The zero value is an empty bitmap ready to be used.
+type Bitmap struct {
+	keys       []uint16
+	containers []container
+}
+
+// New returns a new, empty bitmap.
+func New() *Bitmap {
+	return &Bitmap{}
+}
+
+// BitmapOf returns a new bitmap containing the given values.
+func BitmapOf(values ...uint32) *Bitmap {
+	b := New()
+	for _, x := range values {
+		b.Add(x)
+	}
+	return b
+}
+
+func highbits(x uint32) uint16 {
+	return uint16(x >> 16)
+}
+
+func lowbits(x uint32) uint16 {
+	return uint16(x)
+}
+
+// index returns the position of the container with the given key
+// or the position where it should be inserted if there is none.
+func (b *Bitmap) index(key uint16) (int, bool) {
+	i := sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= key })
+	return i, i < len(b.keys) && b.keys[i] == key
+}
+
+// Add adds x to the bitmap.
+func (b *Bitmap) Add(x uint32) {
+	hb := highbits(x)
+	i, found := b.index(hb)
+	if found {
+		b.containers[i] = b.containers[i].add(lowbits(x))
+		return
+	}
+
+	b.keys = append(b.keys, 0)
+	copy(b.keys[i+1:], b.keys[i:])
+	b.keys[i] = hb
+
+	b.containers = append(b.containers, nil)
+	copy(b.containers[i+1:], b.containers[i:])
+	b.containers[i] = &arrayContainer{values: []uint16{lowbits(x)}}
+}
+
+// Remove removes x from the bitmap.
+func (b *Bitmap) Remove(x uint32) {
+	i, found := b.index(highbits(x))
+	if !found {
+		return
+	}
+
+	b.containers[i] = b.containers[i].remove(lowbits(x))
+	if b.containers[i].cardinality() == 0 {
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+		b.containers = append(b.containers[:i], b.containers[i+1:]...)
+	}
+}
+
+// Contains returns whether x belongs to the bitmap.
+func (b *Bitmap) Contains(x uint32) bool {
+	i, found := b.index(highbits(x))
+	return found && b.containers[i].contains(lowbits(x))
+}
+
+// Cardinality returns the number of values in the bitmap.
+func (b *Bitmap) Cardinality() uint64 {
+	var card uint64
+	for _, c := range b.containers {
+		card += uint64(c.cardinality())
+	}
+	return card
+}
+
+// IsEmpty returns whether the bitmap contains no value.
+func (b *Bitmap) IsEmpty() bool {
+	return len(b.containers) == 0
+}
+
+// ForEach calls f on each value of the bitmap in increasing order, it stops as soon as f returns false.
+func (b *Bitmap) ForEach(f func(x uint32) bool) {
+	for i, c := range b.containers {
+		hb := uint32(b.keys[i]) << 16
+		if !c.forEach(func(x uint16) bool { return f(hb | uint32(x)) }) {
+			return
+		}
+	}
+}
+
+// ToArray returns the values of the bitmap in increasing order.
+func (b *Bitmap) ToArray() []uint32 {
+	values := make([]uint32, 0, b.Cardinality())
+	b.ForEach(func(x uint32) bool {
+		values = append(values, x)
+		return true
+	})
+	return values
+}
+
+// Clone returns a deep copy of the bitmap.
+func (b *Bitmap) Clone() *Bitmap {
+	res := &Bitmap{
+		keys:       make([]uint16, len(b.keys)),
+		containers: make([]container, len(b.containers)),
+	}
+	copy(res.keys, b.keys)
+	for i, c := range b.containers {
+		res.containers[i] = c.clone()
+	}
+	return res
+}
+
+// Equals returns whether both bitmaps contain the same values.
+func (b *Bitmap) Equals(other *Bitmap) bool {
+	if len(b.keys) != len(other.keys) {
+		return false
+	}
+	for i := range b.keys {
+		if b.keys[i] != other.keys[i] || b.containers[i].cardinality() != other.containers[i].cardinality() {
+			return false
+		}
+		if xor(b.containers[i], other.containers[i]).cardinality() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RunOptimize converts every container to a run container when it is more compact.
+// It returns whether at least one container is stored as runs afterwards.
+func (b *Bitmap) RunOptimize() bool {
+	hasRuns := false
+	for i, c := range b.containers {
+		runs := numberOfRuns(c)
+		size := c.serializedSize()
+		if _, ok := c.(*runContainer); ok {
+			size = minSize(c.cardinality())
+		}
+
+		if 2+4*runs < size {
+			if _, ok := c.(*runContainer); !ok {
+				b.containers[i] = newRunContainer(c)
+			}
+			hasRuns = true
+		} else if rc, ok := c.(*runContainer); ok {
+			b.containers[i] = rc.toEfficientContainer()
+		}
+	}
+	return hasRuns
+}
+
+// minSize returns the serialized size of the smallest of the array and bitmap containers for card values.
+func minSize(card int) int {
+	if card <= arrayMaxSize {
+		return 2 * card
+	}
+	return 8 * 1024
+}
+
+// And returns the intersection of the two bitmaps.
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	res := New()
+	i, j := 0, 0
+	for i < len(b.keys) && j < len(other.keys) {
+		switch {
+		case b.keys[i] < other.keys[j]:
+			i++
+		case b.keys[i] > other.keys[j]:
+			j++
+		default:
+			res.appendContainer(b.keys[i], and(b.containers[i], other.containers[j]))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// Or returns the union of the two bitmaps.
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	res := New()
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || (i < len(b.keys) && b.keys[i] < other.keys[j]):
+			res.appendContainer(b.keys[i], b.containers[i].clone())
+			i++
+		case i == len(b.keys) || b.keys[i] > other.keys[j]:
+			res.appendContainer(other.keys[j], other.containers[j].clone())
+			j++
+		default:
+			res.appendContainer(b.keys[i], or(b.containers[i], other.containers[j]))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// Xor returns the symmetric difference of the two bitmaps.
+func (b *Bitmap) Xor(other *Bitmap) *Bitmap {
+	res := New()
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || (i < len(b.keys) && b.keys[i] < other.keys[j]):
+			res.appendContainer(b.keys[i], b.containers[i].clone())
+			i++
+		case i == len(b.keys) || b.keys[i] > other.keys[j]:
+			res.appendContainer(other.keys[j], other.containers[j].clone())
+			j++
+		default:
+			res.appendContainer(b.keys[i], xor(b.containers[i], other.containers[j]))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// AndNot returns the values of the bitmap that do not belong to other.
+func (b *Bitmap) AndNot(other *Bitmap) *Bitmap {
+	res := New()
+	i, j := 0, 0
+	for i < len(b.keys) {
+		switch {
+		case j == len(other.keys) || b.keys[i] < other.keys[j]:
+			res.appendContainer(b.keys[i], b.containers[i].clone())
+			i++
+		case b.keys[i] > other.keys[j]:
+			j++
+		default:
+			res.appendContainer(b.keys[i], andNot(b.containers[i], other.containers[j]))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// appendContainer appends a container with a key greater than all the existing ones, empty containers are dropped.
+func (b *Bitmap) appendContainer(key uint16, c container) {
+	if c.cardinality() == 0 {
+		return
+	}
+	b.keys = append(b.keys, key)
+	b.containers = append(b.containers, c)
+}
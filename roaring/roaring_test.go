@@ -0,0 +1,183 @@
+package roaring
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// randomValues returns n values drawn from a few dense and sparse regions
+// so that the bitmaps mix array, bitmap and run containers.
+func randomValues(r *rand.Rand, n int) []uint32 {
+	values := make([]uint32, 0, n)
+	for len(values) < n {
+		switch r.Intn(3) {
+		case 0:
+			values = append(values, uint32(r.Intn(1<<16)))
+		case 1:
+			values = append(values, 1<<16+uint32(r.Intn(1<<12)))
+		default:
+			values = append(values, r.Uint32())
+		}
+	}
+	return values
+}
+
+func set(values []uint32) map[uint32]bool {
+	m := make(map[uint32]bool)
+	for _, x := range values {
+		m[x] = true
+	}
+	return m
+}
+
+func sorted(m map[uint32]bool) []uint32 {
+	values := make([]uint32, 0, len(m))
+	for x := range m {
+		values = append(values, x)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+func TestAddRemoveContains(t *testing.T) {
+	tests := []struct {
+		id     int
+		add    []uint32
+		remove []uint32
+		want   []uint32
+	}{
+		{0, []uint32{}, []uint32{}, []uint32{}},
+		{1, []uint32{5, 1, 3, 1}, []uint32{}, []uint32{1, 3, 5}},
+		{2, []uint32{5, 1, 3}, []uint32{3, 7}, []uint32{1, 5}},
+		{3, []uint32{1 << 31, 0xFFFFFFFF, 0, 1 << 16}, []uint32{1 << 16}, []uint32{0, 1 << 31, 0xFFFFFFFF}},
+		{4, []uint32{70000, 70001}, []uint32{70000, 70001}, []uint32{}},
+	}
+
+	for _, test := range tests {
+		b := New()
+		for _, x := range test.add {
+			b.Add(x)
+		}
+		for _, x := range test.remove {
+			b.Remove(x)
+		}
+
+		if got := b.ToArray(); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%d: bitmap contains %v, want %v", test.id, got, test.want)
+		}
+		if b.Cardinality() != uint64(len(test.want)) {
+			t.Errorf("%d: Cardinality returned %d, want %d", test.id, b.Cardinality(), len(test.want))
+		}
+		for _, x := range test.want {
+			if !b.Contains(x) {
+				t.Errorf("%d: Contains(%d) returned false", test.id, x)
+			}
+		}
+		for _, x := range test.remove {
+			if b.Contains(x) {
+				t.Errorf("%d: Contains(%d) returned true after removal", test.id, x)
+			}
+		}
+	}
+}
+
+func TestContainerConversions(t *testing.T) {
+	b := New()
+	for x := uint32(0); x <= 2*arrayMaxSize; x += 2 {
+		b.Add(x)
+	}
+	if _, ok := b.containers[0].(*bitmapContainer); !ok {
+		t.Errorf("container with %d values is not a bitmap container", b.Cardinality())
+	}
+
+	for x := uint32(0); x < 2*arrayMaxSize; x += 4 {
+		b.Remove(x)
+	}
+	if _, ok := b.containers[0].(*arrayContainer); !ok {
+		t.Errorf("container with %d values is not an array container", b.Cardinality())
+	}
+
+	b = New()
+	for x := uint32(1000); x < 60000; x++ {
+		b.Add(x)
+	}
+	if !b.RunOptimize() {
+		t.Errorf("RunOptimize did not convert a single run to a run container")
+	}
+	if _, ok := b.containers[0].(*runContainer); !ok || b.Cardinality() != 59000 {
+		t.Errorf("RunOptimize did not produce a run container of 59000 values")
+	}
+
+	b.Remove(30000)
+	if b.Contains(30000) || !b.Contains(29999) || !b.Contains(30001) || b.Cardinality() != 58999 {
+		t.Errorf("Remove on a run container did not work correctly")
+	}
+}
+
+func TestLogicalOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for id := 0; id < 20; id++ {
+		v1, v2 := randomValues(r, r.Intn(20000)), randomValues(r, r.Intn(20000))
+		b1, b2 := BitmapOf(v1...), BitmapOf(v2...)
+		if id%2 == 0 {
+			b1.RunOptimize()
+		}
+		s1, s2 := set(v1), set(v2)
+
+		and, or, xor, andNot := map[uint32]bool{}, map[uint32]bool{}, map[uint32]bool{}, map[uint32]bool{}
+		for x := range s1 {
+			or[x] = true
+			if s2[x] {
+				and[x] = true
+			} else {
+				xor[x] = true
+				andNot[x] = true
+			}
+		}
+		for x := range s2 {
+			or[x] = true
+			if !s1[x] {
+				xor[x] = true
+			}
+		}
+
+		for _, op := range []struct {
+			name string
+			got  *Bitmap
+			want map[uint32]bool
+		}{
+			{"And", b1.And(b2), and},
+			{"Or", b1.Or(b2), or},
+			{"Xor", b1.Xor(b2), xor},
+			{"AndNot", b1.AndNot(b2), andNot},
+		} {
+			if fmt.Sprint(op.got.ToArray()) != fmt.Sprint(sorted(op.want)) {
+				t.Errorf("%d: %s returned bad values", id, op.name)
+			}
+			if op.got.Cardinality() != uint64(len(op.want)) {
+				t.Errorf("%d: %s returned bad cardinality %d, want %d", id, op.name, op.got.Cardinality(), len(op.want))
+			}
+		}
+
+		if !b1.Equals(BitmapOf(v1...)) || (len(s1) != len(s2) && b1.Equals(b2)) {
+			t.Errorf("%d: Equals did not work correctly", id)
+		}
+		if !b1.Clone().Equals(b1) {
+			t.Errorf("%d: Clone is not equal to the original bitmap", id)
+		}
+	}
+}
+
+func TestForEachStops(t *testing.T) {
+	b := BitmapOf(1, 2, 3, 1<<20, 1<<21)
+	visited := []uint32{}
+	b.ForEach(func(x uint32) bool {
+		visited = append(visited, x)
+		return x < 3
+	})
+	if fmt.Sprint(visited) != "[1 2 3]" {
+		t.Errorf("ForEach visited %v, want [1 2 3]", visited)
+	}
+}
@@ -0,0 +1,296 @@
+package roaring
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// serialCookieNoRunContainer identifies a serialized bitmap without run containers.
+	serialCookieNoRunContainer = 12346
+	// serialCookie identifies a serialized bitmap with at least one run container,
+	// the 16 highest bits of the cookie store the number of containers minus one.
+	serialCookie = 12347
+	// noOffsetThreshold is the number of containers below which bitmaps with run containers
+	// are serialized without the offset header.
+	noOffsetThreshold = 4
+)
+
+// hasRunContainer returns whether at least one of the containers is a run container.
+func (b *Bitmap) hasRunContainer() bool {
+	for _, c := range b.containers {
+		if _, ok := c.(*runContainer); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// headerSize returns the number of bytes preceding the containers in the portable format.
+func (b *Bitmap) headerSize() int {
+	n := len(b.containers)
+	if b.hasRunContainer() {
+		size := 4 + (n+7)/8 + 4*n
+		if n >= noOffsetThreshold {
+			size += 4 * n
+		}
+		return size
+	}
+	return 4 + 4 + 8*n
+}
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (b *Bitmap) SerializedSizeInBytes() int {
+	size := b.headerSize()
+	for _, c := range b.containers {
+		size += c.serializedSize()
+	}
+	return size
+}
+
+// WriteTo writes the bitmap to w using the Roaring portable serialization format.
+// Call RunOptimize beforehand to store runs compactly.
+func (b *Bitmap) WriteTo(w io.Writer) (int64, error) {
+	n := len(b.containers)
+	hasRuns := b.hasRunContainer()
+
+	header := make([]byte, 0, b.headerSize())
+	if hasRuns {
+		header = appendUint32(header, uint32(serialCookie|(n-1)<<16))
+		runBitset := make([]byte, (n+7)/8)
+		for i, c := range b.containers {
+			if _, ok := c.(*runContainer); ok {
+				runBitset[i/8] |= 1 << (i % 8)
+			}
+		}
+		header = append(header, runBitset...)
+	} else {
+		header = appendUint32(header, serialCookieNoRunContainer)
+		header = appendUint32(header, uint32(n))
+	}
+
+	for i, c := range b.containers {
+		header = appendUint16(header, b.keys[i])
+		header = appendUint16(header, uint16(c.cardinality()-1))
+	}
+
+	if !hasRuns || n >= noOffsetThreshold {
+		offset := b.headerSize()
+		for _, c := range b.containers {
+			header = appendUint32(header, uint32(offset))
+			offset += c.serializedSize()
+		}
+	}
+
+	written := int64(0)
+	k, err := w.Write(header)
+	written += int64(k)
+	if err != nil {
+		return written, err
+	}
+
+	var buf []byte
+	for _, c := range b.containers {
+		buf = buf[:0]
+		switch c := c.(type) {
+		case *arrayContainer:
+			for _, x := range c.values {
+				buf = appendUint16(buf, x)
+			}
+		case *bitmapContainer:
+			buf = append(buf, make([]byte, c.serializedSize())...)
+			c.writeWords(buf)
+		case *runContainer:
+			buf = appendUint16(buf, uint16(len(c.runs)))
+			for _, iv := range c.runs {
+				buf = appendUint16(buf, iv.start)
+				buf = appendUint16(buf, iv.length)
+			}
+		}
+
+		k, err = w.Write(buf)
+		written += int64(k)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the Roaring portable serialization format.
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(b.SerializedSizeInBytes())
+	if _, err := b.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFrom replaces the content of the bitmap by the one read from r in the Roaring portable serialization format.
+// Containers are read sequentially hence the offset header, if present, is skipped.
+func (b *Bitmap) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := b.readFrom(cr)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return cr.n, err
+}
+
+func (b *Bitmap) readFrom(r *countingReader) error {
+	cookie, err := r.uint32()
+	if err != nil {
+		return err
+	}
+
+	var n int
+	var runBitset []byte
+	switch {
+	case cookie&0xFFFF == serialCookie:
+		n = int(cookie>>16) + 1
+		runBitset = make([]byte, (n+7)/8)
+		if _, err := io.ReadFull(r, runBitset); err != nil {
+			return err
+		}
+	case cookie == serialCookieNoRunContainer:
+		size, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		if size > 1<<16 {
+			return fmt.Errorf("roaring: invalid number of containers %d", size)
+		}
+		n = int(size)
+	default:
+		return fmt.Errorf("roaring: invalid cookie %#x", cookie)
+	}
+
+	isRun := func(i int) bool {
+		return runBitset != nil && runBitset[i/8]&(1<<(i%8)) != 0
+	}
+
+	keys := make([]uint16, n)
+	cards := make([]int, n)
+	for i := 0; i < n; i++ {
+		if keys[i], err = r.uint16(); err != nil {
+			return err
+		}
+		card, err := r.uint16()
+		if err != nil {
+			return err
+		}
+		cards[i] = int(card) + 1
+		if i > 0 && keys[i] <= keys[i-1] {
+			return fmt.Errorf("roaring: container keys are not strictly increasing")
+		}
+	}
+
+	if runBitset == nil || n >= noOffsetThreshold {
+		if _, err := io.CopyN(io.Discard, r, int64(4*n)); err != nil {
+			return err
+		}
+	}
+
+	containers := make([]container, n)
+	for i := 0; i < n; i++ {
+		switch {
+		case isRun(i):
+			nbRuns, err := r.uint16()
+			if err != nil {
+				return err
+			}
+			rc := &runContainer{runs: make([]interval, nbRuns)}
+			for k := range rc.runs {
+				if rc.runs[k].start, err = r.uint16(); err != nil {
+					return err
+				}
+				if rc.runs[k].length, err = r.uint16(); err != nil {
+					return err
+				}
+				if rc.runs[k].last() > 0xFFFF || (k > 0 && int(rc.runs[k].start) <= rc.runs[k-1].last()+1) {
+					return fmt.Errorf("roaring: invalid runs in container %d", i)
+				}
+			}
+			if rc.cardinality() != cards[i] {
+				return fmt.Errorf("roaring: cardinality mismatch in run container %d", i)
+			}
+			containers[i] = rc
+		case cards[i] <= arrayMaxSize:
+			buf := make([]byte, 2*cards[i])
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return err
+			}
+			ac := &arrayContainer{values: make([]uint16, cards[i])}
+			for k := range ac.values {
+				ac.values[k] = binary.LittleEndian.Uint16(buf[2*k:])
+				if k > 0 && ac.values[k] <= ac.values[k-1] {
+					return fmt.Errorf("roaring: values are not strictly increasing in container %d", i)
+				}
+			}
+			containers[i] = ac
+		default:
+			buf := make([]byte, 8*1024)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return err
+			}
+			bc := readBitmapWords(buf)
+			if bc.card != cards[i] {
+				return fmt.Errorf("roaring: cardinality mismatch in bitmap container %d", i)
+			}
+			containers[i] = bc
+		}
+	}
+
+	b.keys, b.containers = keys, containers
+	return nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the Roaring portable serialization format.
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	n, err := b.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if n != int64(len(data)) {
+		return fmt.Errorf("roaring: %d trailing bytes", int64(len(data))-n)
+	}
+	return nil
+}
+
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	buf [4]byte
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	k, err := cr.r.Read(p)
+	cr.n += int64(k)
+	return k, err
+}
+
+func (cr *countingReader) uint16() (uint16, error) {
+	if _, err := io.ReadFull(cr, cr.buf[:2]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(cr.buf[:2]), nil
+}
+
+func (cr *countingReader) uint32() (uint32, error) {
+	if _, err := io.ReadFull(cr, cr.buf[:4]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(cr.buf[:4]), nil
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v), byte(v>>8))
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
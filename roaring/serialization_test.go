@@ -0,0 +1,140 @@
+package roaring
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	runs := New()
+	for x := uint32(1); x <= 100; x++ {
+		runs.Add(x)
+	}
+	runs.RunOptimize()
+
+	tests := []struct {
+		id   int
+		b    *Bitmap
+		want []byte
+	}{
+		{
+			0,
+			New(),
+			[]byte{0x3A, 0x30, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			1,
+			BitmapOf(1, 2, 3),
+			[]byte{
+				0x3A, 0x30, 0x00, 0x00, // cookie
+				0x01, 0x00, 0x00, 0x00, // one container
+				0x00, 0x00, 0x02, 0x00, // key 0, cardinality 3
+				0x10, 0x00, 0x00, 0x00, // offset 16
+				0x01, 0x00, 0x02, 0x00, 0x03, 0x00,
+			},
+		},
+		{
+			2,
+			runs,
+			[]byte{
+				0x3B, 0x30, 0x00, 0x00, // cookie, one container
+				0x01,                   // run bitset
+				0x00, 0x00, 0x63, 0x00, // key 0, cardinality 100
+				0x01, 0x00, // one run
+				0x01, 0x00, 0x63, 0x00, // [1, 1 + 99]
+			},
+		},
+		{
+			3,
+			BitmapOf(0x10000, 0xFFFFFFFF),
+			[]byte{
+				0x3A, 0x30, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00,
+				0x18, 0x00, 0x00, 0x00, 0x1A, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0xFF, 0xFF,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data, err := test.b.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%d: MarshalBinary returned error %v", test.id, err)
+		}
+		if !bytes.Equal(data, test.want) {
+			t.Errorf("%d: MarshalBinary returned bad data %X, want %X", test.id, data, test.want)
+		}
+		if len(data) != test.b.SerializedSizeInBytes() {
+			t.Errorf("%d: SerializedSizeInBytes returned %d, want %d", test.id, test.b.SerializedSizeInBytes(), len(data))
+		}
+
+		b := New()
+		if err := b.UnmarshalBinary(test.want); err != nil {
+			t.Fatalf("%d: UnmarshalBinary returned error %v", test.id, err)
+		}
+		if !b.Equals(test.b) {
+			t.Errorf("%d: UnmarshalBinary returned %v, want %v", test.id, b.ToArray(), test.b.ToArray())
+		}
+	}
+}
+
+func TestSerializationRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for id := 0; id < 20; id++ {
+		b := BitmapOf(randomValues(r, r.Intn(30000))...)
+		for k := 0; k < id; k++ {
+			start := r.Uint32()
+			for x := start; x < start+uint32(r.Intn(100000)) && x >= start; x++ {
+				b.Add(x)
+			}
+		}
+		if id%2 == 1 {
+			b.RunOptimize()
+		}
+
+		var buf bytes.Buffer
+		n, err := b.WriteTo(&buf)
+		if err != nil || n != int64(buf.Len()) || n != int64(b.SerializedSizeInBytes()) {
+			t.Fatalf("%d: WriteTo returned (%d, %v) for %d bytes", id, n, err, buf.Len())
+		}
+		buf.WriteString("trailing")
+
+		res := New()
+		m, err := res.ReadFrom(&buf)
+		if err != nil || m != n {
+			t.Fatalf("%d: ReadFrom returned (%d, %v), want (%d, nil)", id, m, err, n)
+		}
+		if !res.Equals(b) {
+			t.Errorf("%d: ReadFrom did not read back the written bitmap", id)
+		}
+		if buf.String() != "trailing" {
+			t.Errorf("%d: ReadFrom consumed bytes beyond the bitmap", id)
+		}
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	valid, _ := BitmapOf(1, 2, 3).MarshalBinary()
+
+	tests := []struct {
+		id   int
+		data []byte
+	}{
+		{0, []byte{}},
+		{1, []byte{0x00, 0x00, 0x00, 0x00}},
+		{2, valid[:len(valid)-1]},
+		{3, append(append([]byte{}, valid...), 0x00)},
+		{4, []byte{0x3A, 0x30, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00}},
+		{5, []byte{0x3B, 0x30, 0x00, 0x00, 0x01, 0x00, 0x00, 0x05, 0x00, 0x01, 0x00, 0x01, 0x00, 0x03, 0x00}},
+	}
+
+	for _, test := range tests {
+		b := New()
+		if err := b.UnmarshalBinary(test.data); err == nil {
+			t.Errorf("%d: UnmarshalBinary(%s) did not return an error", test.id, fmt.Sprintf("%X", test.data))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// The search functions look for the occurrences of a pattern at any bit position, aligned on bytes or not.
+// Occurrences are found 64 candidate positions at a time: a candidate is dropped as soon as one bit of the pattern
+// differs from the bit-array, which is computed for the 64 candidates at once with a XOR of words.
+// Searching a pattern of m bits in n bits costs at most n*m/64 word operations, much less in practice.
+// As with the strings package, the empty pattern occurs at every position, including Len().
+
+// Index returns the position of the first occurrence of pattern in the bit-array, or -1 if there is none.
+func (ba *BitArray) Index(pattern *BitArray) int {
+	return ba.IndexFrom(pattern, 0)
+}
+
+// IndexFrom returns the position of the first occurrence of pattern starting at position `from` or after it,
+// or -1 if there is none. It will panic if from is negative.
+func (ba *BitArray) IndexFrom(pattern *BitArray, from int) int {
+	if from < 0 {
+		panic(fmt.Sprintf("negative index is invalid; given %d", from))
+	}
+	for base := from; base <= ba.Len()-pattern.Len(); base += 64 {
+		if w := ba.matches(pattern, base); w != 0 {
+			return base + bits.LeadingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// LastIndex returns the position of the last occurrence of pattern in the bit-array, or -1 if there is none.
+func (ba *BitArray) LastIndex(pattern *BitArray) int {
+	last := ba.Len() - pattern.Len()
+	if last < 0 {
+		return -1
+	}
+	for base := last &^ 63; base >= 0; base -= 64 {
+		if w := ba.matches(pattern, base); w != 0 {
+			return base + 63 - bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// IndexAll returns the positions, in increasing order, of all the occurrences of pattern in the bit-array,
+// overlapping ones included: "111" occurs at positions 0 and 1 of "1111".
+func (ba *BitArray) IndexAll(pattern *BitArray) []int {
+	var positions []int
+	for base := 0; base <= ba.Len()-pattern.Len(); base += 64 {
+		w := ba.matches(pattern, base)
+		for w != 0 {
+			t := bits.LeadingZeros64(w)
+			positions = append(positions, base+t)
+			w &^= 1 << uint(63-t)
+		}
+	}
+	return positions
+}
+
+// CountPattern returns the number of non-overlapping occurrences of pattern in the bit-array, as strings.Count does:
+// "111" occurs once in "1111". The empty pattern occurs Len()+1 times.
+func (ba *BitArray) CountPattern(pattern *BitArray) int {
+	if pattern.Len() == 0 {
+		return ba.Len() + 1
+	}
+	count := 0
+	for i := ba.Index(pattern); i >= 0; i = ba.IndexFrom(pattern, i+pattern.Len()) {
+		count++
+	}
+	return count
+}
+
+// matches returns a word whose bit 63-t is set if pattern occurs at position base+t, for the 64 positions from base.
+func (ba *BitArray) matches(pattern *BitArray, base int) uint64 {
+	m := pattern.Len()
+	// candidates past Len() - m are mismatches
+	var mismatch uint64
+	if last := ba.Len() - m - base; last < 63 {
+		mismatch = ^uint64(0) >> uint(last+1)
+	}
+
+	for k := 0; k < m && mismatch != ^uint64(0); k++ {
+		w := loadBits(ba.data, base+k)
+		if pattern.data[k>>3]>>(7-uint(k&0x7))&1 == 1 {
+			mismatch |= ^w
+		} else {
+			mismatch |= w
+		}
+	}
+	return ^mismatch
+}
+
+// Match is an occurrence of a pattern found by IndexApprox.
+type Match struct {
+	Offset   int  // position of the occurrence in the bit-array
+	Distance int  // number of bits differing from the pattern, or from its inverse if Inverted
+	Inverted bool // whether the occurrence matches the pattern with all its bits inverted
+}
+
+// IndexApprox returns, in increasing order of offset, all the positions where pattern occurs in the bit-array
+// with at most maxDistance differing bits (Hamming distance), overlapping ones included.
+// If inverted is true, occurrences of the pattern with all its bits inverted are reported too, with Inverted set;
+// when an occurrence matches both ways, the closest one is reported, the non inverted one on ties.
+// The distance at each position is computed with XOR and popcount of the pattern and the words of the bit-array
+// starting at that position, skipping the end of the pattern as soon as the distance is too large.
+// It will panic if maxDistance is negative.
+func (ba *BitArray) IndexApprox(pattern *BitArray, maxDistance int, inverted bool) []Match {
+	if maxDistance < 0 {
+		panic(fmt.Sprintf("maximum distance should not be negative; given %d", maxDistance))
+	}
+
+	m := pattern.Len()
+	words := make([]uint64, (m+63)/64)
+	for k := range words {
+		words[k] = pattern.word(k)
+	}
+
+	var matches []Match
+	for p := 0; p <= ba.Len()-m; p++ {
+		d, b := 0, 0 // distance and number of bits of the pattern compared so far
+		for k := 0; k < len(words); k++ {
+			w := loadBits(ba.data, p+k<<6) ^ words[k]
+			n := m - b
+			if n > 64 {
+				n = 64
+			}
+			d += bits.OnesCount64(w >> uint(64-n))
+			b += n
+			// the pattern can neither match (d too large) nor match inverted (too many equal bits)
+			if d > maxDistance && (!inverted || b-d > maxDistance) {
+				break
+			}
+		}
+
+		switch {
+		case d <= maxDistance && (!inverted || d <= m-d):
+			matches = append(matches, Match{Offset: p, Distance: d})
+		case inverted && m-d <= maxDistance && b == m:
+			matches = append(matches, Match{Offset: p, Distance: m - d, Inverted: true})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,222 @@
+package bitarray
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		id       int
+		bits     string
+		pattern  string
+		index    int
+		last     int
+		all      []int
+		countPat int
+	}{
+		{0, "", "", 0, 0, []int{0}, 1},
+		{1, "", "1", -1, -1, nil, 0},
+		{2, "101", "", 0, 3, []int{0, 1, 2, 3}, 4},
+		{3, "1111", "111", 0, 1, []int{0, 1}, 1},
+		{4, "0010110", "011", 3, 3, []int{3}, 1},
+		{5, "0010110", "0100", -1, -1, nil, 0},
+		{6, "01", "011", -1, -1, nil, 0},
+		{7, "1010101", "101", 0, 4, []int{0, 2, 4}, 2},
+	}
+
+	for _, test := range tests {
+		ba, pattern := bitArrayOfString(test.bits), bitArrayOfString(test.pattern)
+		if got := ba.Index(pattern); got != test.index {
+			t.Errorf("%d: Index returned bad data %d, want %d", test.id, got, test.index)
+		}
+		if got := ba.LastIndex(pattern); got != test.last {
+			t.Errorf("%d: LastIndex returned bad data %d, want %d", test.id, got, test.last)
+		}
+		if got := ba.IndexAll(pattern); !reflect.DeepEqual(got, test.all) {
+			t.Errorf("%d: IndexAll returned bad data %v, want %v", test.id, got, test.all)
+		}
+		if got := ba.CountPattern(pattern); got != test.countPat {
+			t.Errorf("%d: CountPattern returned bad data %d, want %d", test.id, got, test.countPat)
+		}
+	}
+}
+
+// indexAll returns the positions of the overlapping occurrences of pattern in s.
+func indexAll(s, pattern string) []int {
+	var positions []int
+	for i := 0; i+len(pattern) <= len(s); i++ {
+		if s[i:i+len(pattern)] == pattern {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func TestIndexRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 500; id++ {
+		ba := randomBitArray(r, r.Intn(500))
+		s := bitString(ba)
+
+		// a pattern taken from the bit-array, possibly modified, or a random one
+		var pattern string
+		if len(s) > 0 && r.Intn(4) > 0 {
+			i := r.Intn(len(s))
+			pattern = s[i : i+r.Intn(len(s)-i+1)]
+			if len(pattern) > 100 {
+				pattern = pattern[:r.Intn(100)]
+			}
+			if len(pattern) > 0 && r.Intn(3) == 0 {
+				pattern = pattern[:len(pattern)-1] + string('0'+'1'-pattern[len(pattern)-1])
+			}
+		} else {
+			pattern = bitString(randomBitArray(r, r.Intn(8)))
+		}
+		p := bitArrayOfString(pattern)
+
+		if got, want := ba.Index(p), strings.Index(s, pattern); got != want {
+			t.Fatalf("%d: Index of %s in %s returned bad data %d, want %d", id, pattern, s, got, want)
+		}
+		if got, want := ba.LastIndex(p), strings.LastIndex(s, pattern); got != want {
+			t.Fatalf("%d: LastIndex of %s in %s returned bad data %d, want %d", id, pattern, s, got, want)
+		}
+		if got, want := ba.CountPattern(p), strings.Count(s, pattern); got != want {
+			t.Fatalf("%d: CountPattern of %s in %s returned bad data %d, want %d", id, pattern, s, got, want)
+		}
+		if got, want := ba.IndexAll(p), indexAll(s, pattern); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: IndexAll of %s in %s returned bad data %v, want %v", id, pattern, s, got, want)
+		}
+		from := r.Intn(len(s) + 2)
+		want := -1
+		if from <= len(s) {
+			if i := strings.Index(s[from:], pattern); i >= 0 {
+				want = from + i
+			}
+		}
+		if got := ba.IndexFrom(p, from); got != want {
+			t.Fatalf("%d: IndexFrom(%d) of %s in %s returned bad data %d, want %d", id, from, pattern, s, got, want)
+		}
+	}
+}
+
+func TestIndexSyncWord(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	sync := New()
+	sync.Append32(0x1acffc1d, 32)
+
+	// a capture with sync words at unaligned positions, the random bits do not contain it by chance
+	capture := New()
+	var want []int
+	for k := 0; k < 100; k++ {
+		capture.AppendBitArray(randomBitArray(r, 100+r.Intn(1000)))
+		want = append(want, capture.Len())
+		capture.AppendBitArray(sync)
+	}
+	if got := capture.IndexAll(sync); !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexAll returned bad data %v, want %v", got, want)
+	}
+}
+
+func TestIndexPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("IndexFrom with a negative index did not panic")
+		}
+	}()
+	NewZeros(10).IndexFrom(New(), -1)
+}
+
+func TestIndexApproxPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("IndexApprox with a negative distance did not panic")
+		}
+	}()
+	NewZeros(10).IndexApprox(New(), -1, false)
+}
+
+func TestIndexApprox(t *testing.T) {
+	tests := []struct {
+		id          int
+		bits        string
+		pattern     string
+		maxDistance int
+		inverted    bool
+		matches     []Match
+	}{
+		{0, "", "", 0, false, []Match{{0, 0, false}}},
+		{1, "01", "011", 3, true, nil},
+		{2, "0010110", "011", 0, false, []Match{{3, 0, false}}},
+		{3, "0010110", "011", 1, false, []Match{{0, 1, false}, {1, 1, false}, {3, 0, false}}},
+		{4, "1100", "0011", 0, false, nil},
+		{5, "1100", "0011", 0, true, []Match{{0, 0, true}}},
+		{6, "1100", "0011", 1, true, []Match{{0, 0, true}}},
+		// at distance 2 of both the pattern and its inverse, the pattern wins
+		{7, "1100", "1010", 2, true, []Match{{0, 2, false}}},
+	}
+
+	for _, test := range tests {
+		ba, pattern := bitArrayOfString(test.bits), bitArrayOfString(test.pattern)
+		if got := ba.IndexApprox(pattern, test.maxDistance, test.inverted); !reflect.DeepEqual(got, test.matches) {
+			t.Errorf("%d: IndexApprox returned bad data %v, want %v", test.id, got, test.matches)
+		}
+	}
+}
+
+func TestIndexApproxRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for id := 0; id < 200; id++ {
+		ba := randomBitArray(r, r.Intn(400))
+		pattern := randomBitArray(r, r.Intn(150))
+		maxDistance := r.Intn(pattern.Len()/2 + 2)
+		inverted := r.Intn(2) == 0
+
+		var want []Match
+		for p := 0; p+pattern.Len() <= ba.Len(); p++ {
+			d := ba.ExtractBitArray(p, p+pattern.Len()).HammingDistance(pattern)
+			switch {
+			case d <= maxDistance && (!inverted || d <= pattern.Len()-d):
+				want = append(want, Match{p, d, false})
+			case inverted && pattern.Len()-d <= maxDistance:
+				want = append(want, Match{p, pattern.Len() - d, true})
+			}
+		}
+		if got := ba.IndexApprox(pattern, maxDistance, inverted); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d: IndexApprox(%d, %t) returned bad data %v, want %v", id, maxDistance, inverted, got, want)
+		}
+	}
+}
+
+func TestIndexApproxNoisySyncWord(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	sync := New()
+	sync.Append32(0x1acffc1d, 32)
+
+	// frames start with the sync word, inverted in one frame out of two, with up to 3 bit errors
+	capture := New()
+	var want []Match
+	for k := 0; k < 50; k++ {
+		capture.AppendBitArray(randomBitArray(r, 100+r.Intn(500)))
+		word := sync.Clone()
+		if k%2 == 1 {
+			word.Xor(bitArrayOfString(strings.Repeat("1", 32)))
+		}
+		errors := k % 4
+		for _, i := range r.Perm(32)[:errors] {
+			if word.GetBit(i) == 1 {
+				word.ClearBit(i)
+			} else {
+				word.SetBit(i)
+			}
+		}
+		want = append(want, Match{capture.Len(), errors, k%2 == 1})
+		capture.AppendBitArray(word)
+	}
+
+	if got := capture.IndexApprox(sync, 3, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexApprox returned bad data %v, want %v", got, want)
+	}
+}
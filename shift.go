@@ -0,0 +1,149 @@
+package bitarray
+
+import "fmt"
+
+// Shifts and rotations move the bits along their positions: shifting to the left moves the bit at position i+n
+// to position i, as << does on the integer whose most significant bit is the bit at position 0.
+// Shifts preserve the length of the bit-array and fill the vacated positions with 0's.
+
+// ShiftLeft shifts in place the bits of the bit-array by n positions to the left (towards position 0).
+// It will panic if n is negative.
+func (ba *BitArray) ShiftLeft(n int) {
+	checkShift(n)
+	ba.beforeWrite(0, len(ba.data))
+	shiftBits(ba.data, ba.data, ba.clampShift(n))
+	ba.clearPadding()
+}
+
+// ShiftRight shifts in place the bits of the bit-array by n positions to the right (towards the end).
+// It will panic if n is negative.
+func (ba *BitArray) ShiftRight(n int) {
+	checkShift(n)
+	ba.beforeWrite(0, len(ba.data))
+	shiftBits(ba.data, ba.data, -ba.clampShift(n))
+	ba.clearPadding()
+}
+
+// RotateLeft rotates in place the bits of the bit-array by n positions to the left:
+// the bit at position (i+n) % Len() moves to position i. It will panic if n is negative.
+func (ba *BitArray) RotateLeft(n int) {
+	checkShift(n)
+	if ba.Len() == 0 {
+		return
+	}
+	src := make([]byte, len(ba.data))
+	copy(src, ba.data)
+	ba.beforeWrite(0, len(ba.data))
+	rotateBits(ba.data, src, n%ba.Len(), ba.Len())
+	ba.clearPadding()
+}
+
+// RotateRight rotates in place the bits of the bit-array by n positions to the right:
+// the bit at position i moves to position (i+n) % Len(). It will panic if n is negative.
+func (ba *BitArray) RotateRight(n int) {
+	checkShift(n)
+	if ba.Len() == 0 {
+		return
+	}
+	ba.RotateLeft(ba.Len() - n%ba.Len())
+}
+
+// ShiftedLeft returns a new bit-array with the bits of the bit-array shifted by n positions to the left.
+// It will panic if n is negative.
+func (ba *BitArray) ShiftedLeft(n int) *BitArray {
+	checkShift(n)
+	res := NewZeros(ba.Len())
+	shiftBits(res.data, ba.data, ba.clampShift(n))
+	res.clearPadding()
+	return res
+}
+
+// ShiftedRight returns a new bit-array with the bits of the bit-array shifted by n positions to the right.
+// It will panic if n is negative.
+func (ba *BitArray) ShiftedRight(n int) *BitArray {
+	checkShift(n)
+	res := NewZeros(ba.Len())
+	shiftBits(res.data, ba.data, -ba.clampShift(n))
+	res.clearPadding()
+	return res
+}
+
+// RotatedLeft returns a new bit-array with the bits of the bit-array rotated by n positions to the left.
+// It will panic if n is negative.
+func (ba *BitArray) RotatedLeft(n int) *BitArray {
+	checkShift(n)
+	res := NewZeros(ba.Len())
+	if ba.Len() > 0 {
+		rotateBits(res.data, ba.data, n%ba.Len(), ba.Len())
+		res.clearPadding()
+	}
+	return res
+}
+
+// RotatedRight returns a new bit-array with the bits of the bit-array rotated by n positions to the right.
+// It will panic if n is negative.
+func (ba *BitArray) RotatedRight(n int) *BitArray {
+	checkShift(n)
+	if ba.Len() == 0 {
+		return New()
+	}
+	return ba.RotatedLeft(ba.Len() - n%ba.Len())
+}
+
+func checkShift(n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("the number of positions should not be negative; given %d", n))
+	}
+}
+
+// clampShift returns n, or Len() if n is larger: shifting by Len() positions or more clears all the bits.
+func (ba *BitArray) clampShift(n int) int {
+	if n > ba.Len() {
+		return ba.Len()
+	}
+	return n
+}
+
+// clearPadding sets the padding bits of the last byte to 0.
+func (ba *BitArray) clearPadding() {
+	ba.data[len(ba.data)-1] &^= byte(0xff) >> uint(8-ba.padding)
+}
+
+// loadBits returns the 64 bits of data starting at bit position pos, which may be negative,
+// bits outside of data are read as 0's.
+func loadBits(data []byte, pos int) uint64 {
+	if pos < 0 {
+		if pos <= -64 {
+			return 0
+		}
+		return loadBits(data, 0) >> uint(-pos)
+	}
+
+	b, r := pos>>3, uint(pos&0x7)
+	w := load64(data, b) << r
+	if r != 0 && b+8 < len(data) {
+		w |= uint64(data[b+8]) >> (8 - r)
+	}
+	return w
+}
+
+// shiftBits writes into dst the bits of src shifted to the left by n positions, or to the right if n is negative.
+// dst and src may be the same slice, the words being written in an order that does not overwrite bits still to be read.
+func shiftBits(dst, src []byte, n int) {
+	if n >= 0 {
+		for k := 0; k<<3 < len(dst); k++ {
+			store64(dst, k<<3, loadBits(src, k<<6+n))
+		}
+		return
+	}
+	for k := (len(dst) - 1) >> 3; k >= 0; k-- {
+		store64(dst, k<<3, loadBits(src, k<<6+n))
+	}
+}
+
+// rotateBits writes into dst the length bits of src rotated to the left by n < length positions, dst and src must differ.
+func rotateBits(dst, src []byte, n, length int) {
+	for k := 0; k<<3 < len(dst); k++ {
+		store64(dst, k<<3, loadBits(src, k<<6+n)|loadBits(src, k<<6+n-length))
+	}
+}
@@ -0,0 +1,157 @@
+package bitarray
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		id          int
+		bits        string
+		n           int
+		shiftLeft   string
+		shiftRight  string
+		rotateLeft  string
+		rotateRight string
+	}{
+		{0, "", 3, "", "", "", ""},
+		{1, "1", 0, "1", "1", "1", "1"},
+		{2, "1", 1, "0", "0", "1", "1"},
+		{3, "1101", 1, "1010", "0110", "1011", "1110"},
+		{4, "1101", 3, "1000", "0001", "1110", "1011"},
+		{5, "1101", 4, "0000", "0000", "1101", "1101"},
+		{6, "1101", 6, "0000", "0000", "0111", "0111"},
+		{7, "110100111", 8, "100000000", "000000001", "111010011", "101001111"},
+		{8, "110100111", 1 << 40, "000000000", "000000000", "111101001", "010011111"}, // 1<<40 % 9 == 7
+	}
+
+	for _, test := range tests {
+		for _, op := range []struct {
+			name     string
+			inPlace  func(ba *BitArray, n int)
+			copying  func(ba *BitArray, n int) *BitArray
+			expected string
+		}{
+			{"ShiftLeft", (*BitArray).ShiftLeft, (*BitArray).ShiftedLeft, test.shiftLeft},
+			{"ShiftRight", (*BitArray).ShiftRight, (*BitArray).ShiftedRight, test.shiftRight},
+			{"RotateLeft", (*BitArray).RotateLeft, (*BitArray).RotatedLeft, test.rotateLeft},
+			{"RotateRight", (*BitArray).RotateRight, (*BitArray).RotatedRight, test.rotateRight},
+		} {
+			want := New()
+			want.AppendString(op.expected)
+
+			ba := New()
+			ba.AppendString(test.bits)
+			if got := op.copying(ba, test.n); !sameBits(got, want) {
+				t.Errorf("%d: copying %s returned bad data %08b, want %08b", test.id, op.name, got.Bytes(), want.Bytes())
+			}
+			op.inPlace(ba, test.n)
+			if !sameBits(ba, want) {
+				t.Errorf("%d: %s returned bad data %08b, want %08b", test.id, op.name, ba.Bytes(), want.Bytes())
+			}
+		}
+	}
+}
+
+// bitArrayOfString appends the bits of s one by one.
+func bitArrayOfString(s string) *BitArray {
+	ba := New()
+	for _, c := range s {
+		ba.AppendBit(byte(c - '0'))
+	}
+	return ba
+}
+
+func bitString(ba *BitArray) string {
+	var sb strings.Builder
+	for i := 0; i < ba.Len(); i++ {
+		sb.WriteByte('0' + ba.GetBit(i))
+	}
+	return sb.String()
+}
+
+func TestShiftRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for id := 0; id < 500; id++ {
+		length := r.Intn(300)
+		ba := randomBitArray(r, length)
+		s := bitString(ba)
+		n := r.Intn(length + 70)
+
+		var left, right, rotLeft, rotRight string
+		if n >= length {
+			left = strings.Repeat("0", length)
+			right = left
+		} else {
+			left = s[n:] + strings.Repeat("0", n)
+			right = strings.Repeat("0", n) + s[:length-n]
+		}
+		if length > 0 {
+			k := n % length
+			rotLeft = s[k:] + s[:k]
+			rotRight = s[length-k:] + s[:length-k]
+		}
+
+		for _, test := range []struct {
+			name string
+			got  *BitArray
+			want string
+		}{
+			{"ShiftedLeft", ba.ShiftedLeft(n), left},
+			{"ShiftedRight", ba.ShiftedRight(n), right},
+			{"RotatedLeft", ba.RotatedLeft(n), rotLeft},
+			{"RotatedRight", ba.RotatedRight(n), rotRight},
+		} {
+			want := bitArrayOfString(test.want)
+			if !sameBits(test.got, want) {
+				t.Fatalf("%d: %s(%d) of %s returned bad data %s, want %s", id, test.name, n, s, bitString(test.got), test.want)
+			}
+		}
+
+		// in place, with padding bits that must stay 0
+		ba.ShiftRight(n)
+		want := bitArrayOfString(right)
+		if !sameBits(ba, want) {
+			t.Fatalf("%d: ShiftRight(%d) of %s returned bad data %s, want %s", id, n, s, bitString(ba), right)
+		}
+		if ba.Count() != want.Count() {
+			t.Fatalf("%d: ShiftRight(%d) left padding bits set", id, n)
+		}
+	}
+}
+
+func TestShiftSnapshot(t *testing.T) {
+	ba := New()
+	ba.AppendString("1101")
+	s := ba.Snapshot()
+	ba.RotateLeft(1)
+	ba.ShiftLeft(1)
+	if got := s.Extract(0, 4); got != 0b1101 {
+		t.Errorf("snapshot changed to %04b, want 1101", got)
+	}
+}
+
+func TestShiftPanics(t *testing.T) {
+	ba := NewZeros(10)
+	for id, f := range []func(){
+		func() { ba.ShiftLeft(-1) },
+		func() { ba.ShiftRight(-1) },
+		func() { ba.RotateLeft(-1) },
+		func() { ba.RotateRight(-1) },
+		func() { ba.ShiftedLeft(-1) },
+		func() { ba.ShiftedRight(-1) },
+		func() { ba.RotatedLeft(-1) },
+		func() { ba.RotatedRight(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,92 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// countOp is a bitwise operation whose result is counted by countWith.
+type countOp int
+
+const (
+	countXor countOp = iota
+	countAnd
+	countOr
+)
+
+// countWith returns the number of bits set to `1` in op(ba, other) without materializing the result.
+// Padding bits being 0's, they are not counted.
+func (ba *BitArray) countWith(other *BitArray, op countOp) int {
+	ba.checkSameLen(other)
+
+	// x & y and x ^ y having no bit in common, op(x, y) is the sum of the ones selected by the masks:
+	// x ^ y for XOR, x & y for AND and both for OR
+	var andMask, xorMask uint64
+	switch op {
+	case countXor:
+		xorMask = ^uint64(0)
+	case countAnd:
+		andMask = ^uint64(0)
+	case countOr:
+		andMask, xorMask = ^uint64(0), ^uint64(0)
+	}
+
+	nbBytes := (ba.Len() + 7) >> 3
+	a, b := ba.data[:nbBytes], other.data[:nbBytes]
+	n := nbBytes &^ 7
+	count := 0
+	for i := 0; i < n; i += 8 {
+		x, y := binary.LittleEndian.Uint64(a[i:]), binary.LittleEndian.Uint64(b[i:])
+		count += bits.OnesCount64(x&y&andMask | (x^y)&xorMask)
+	}
+	if n < nbBytes {
+		x, y := load64(a, n), load64(b, n)
+		count += bits.OnesCount64(x&y&andMask | (x^y)&xorMask)
+	}
+	return count
+}
+
+// HammingDistance returns the number of positions at which the bits of the receiving bit-array and other differ.
+// Both bit-arrays must have the same length, otherwise HammingDistance will panic.
+func (ba *BitArray) HammingDistance(other *BitArray) int {
+	return ba.countWith(other, countXor)
+}
+
+// AndCount returns the number of bits set to `1` in both the receiving bit-array and other,
+// it is the same as the Count of their bitwise AND without allocating it.
+// Both bit-arrays must have the same length, otherwise AndCount will panic.
+func (ba *BitArray) AndCount(other *BitArray) int {
+	return ba.countWith(other, countAnd)
+}
+
+// OrCount returns the number of bits set to `1` in the receiving bit-array or in other,
+// it is the same as the Count of their bitwise OR without allocating it.
+// Both bit-arrays must have the same length, otherwise OrCount will panic.
+func (ba *BitArray) OrCount(other *BitArray) int {
+	return ba.countWith(other, countOr)
+}
+
+// Jaccard returns the Jaccard similarity |A ∩ B| / |A ∪ B| of the sets of positions of the bits set to `1`
+// in the receiving bit-array and in other. It is 1 when both have no bit set.
+// Both bit-arrays must have the same length, otherwise Jaccard will panic.
+func (ba *BitArray) Jaccard(other *BitArray) float64 {
+	or := ba.OrCount(other)
+	if or == 0 {
+		return 1
+	}
+	return float64(ba.AndCount(other)) / float64(or)
+}
+
+// Tanimoto returns the Tanimoto similarity A·B / (|A|² + |B|² - A·B) of the receiving bit-array and other
+// seen as vectors of 0's and 1's. It is 1 when both have no bit set.
+// On bit vectors it is equal to the Jaccard similarity, it is computed from the counts of both bit-arrays
+// and of their intersection, which is the usual way chemical fingerprints are compared.
+// Both bit-arrays must have the same length, otherwise Tanimoto will panic.
+func (ba *BitArray) Tanimoto(other *BitArray) float64 {
+	and := ba.AndCount(other)
+	union := ba.Count() + other.Count() - and
+	if union == 0 {
+		return 1
+	}
+	return float64(and) / float64(union)
+}
@@ -0,0 +1,98 @@
+package bitarray
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		id       int
+		a        string
+		b        string
+		hamming  int
+		and      int
+		or       int
+		jaccard  float64
+		tanimoto float64
+	}{
+		{0, "", "", 0, 0, 0, 1, 1},
+		{1, "0000", "0000", 0, 0, 0, 1, 1},
+		{2, "1", "0", 1, 0, 1, 0, 0},
+		{3, "1100", "1010", 2, 1, 3, 1.0 / 3, 1.0 / 3},
+		{4, "1111", "1111", 0, 4, 4, 1, 1},
+		{
+			5,
+			"110111101010110110111110111011110000110111101010110110111110111011110000",
+			"000100000010101100110100100101011010000001110010011111010101011110101010",
+			37, 22, 59, 22.0 / 59, 22.0 / 59,
+		},
+	}
+
+	for _, test := range tests {
+		a, b := New(), New()
+		a.AppendString(test.a)
+		b.AppendString(test.b)
+
+		if got := a.HammingDistance(b); got != test.hamming {
+			t.Errorf("%d: HammingDistance returned bad data %d, want %d", test.id, got, test.hamming)
+		}
+		if got := a.AndCount(b); got != test.and {
+			t.Errorf("%d: AndCount returned bad data %d, want %d", test.id, got, test.and)
+		}
+		if got := a.OrCount(b); got != test.or {
+			t.Errorf("%d: OrCount returned bad data %d, want %d", test.id, got, test.or)
+		}
+		if got := a.Jaccard(b); math.Abs(got-test.jaccard) > 1e-12 {
+			t.Errorf("%d: Jaccard returned bad data %v, want %v", test.id, got, test.jaccard)
+		}
+		if got := a.Tanimoto(b); math.Abs(got-test.tanimoto) > 1e-12 {
+			t.Errorf("%d: Tanimoto returned bad data %v, want %v", test.id, got, test.tanimoto)
+		}
+
+		// the counts must agree with the materialized bitwise operations
+		and, or, xor := a.Clone(), a.Clone(), a.Clone()
+		and.And(b)
+		or.Or(b)
+		xor.Xor(b)
+		if and.Count() != test.and || or.Count() != test.or || xor.Count() != test.hamming {
+			t.Errorf("%d: bad test data", test.id)
+		}
+	}
+}
+
+func TestSimilarityAllocs(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a, b := randomBitArray(r, 1000), randomBitArray(r, 1000)
+	allocs := testing.AllocsPerRun(10, func() {
+		a.HammingDistance(b)
+		a.AndCount(b)
+		a.OrCount(b)
+		a.Jaccard(b)
+		a.Tanimoto(b)
+	})
+	if allocs != 0 {
+		t.Errorf("similarity metrics allocated %.0f times, want 0", allocs)
+	}
+}
+
+func TestSimilarityPanics(t *testing.T) {
+	a, b := NewZeros(10), NewZeros(11)
+	for id, f := range []func(){
+		func() { a.HammingDistance(b) },
+		func() { a.AndCount(b) },
+		func() { a.OrCount(b) },
+		func() { a.Jaccard(b) },
+		func() { a.Tanimoto(b) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,216 @@
+package bitarray
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// snapshotChunkBytes is the granularity, in bytes, at which the data shared with snapshots is copied.
+const snapshotChunkBytes = 512
+
+// Snapshot is an immutable view of a bit-array at the time it was taken.
+// It shares the data of the bit-array: before the bit-array overwrites a chunk of 4096 bits
+// seen by a snapshot for the first time, it saves a copy of the chunk for its snapshots.
+// Taking a snapshot is O(1) and a snapshot only costs the chunks modified after it was taken.
+//
+// A snapshot may be read by several goroutines while the bit-array keeps being modified by another one.
+//
+// Once no snapshot of a bit-array is live, its writes stop saving chunks. A snapshot stops being live
+// when it is released with Release or, failing that, when it is garbage collected.
+type Snapshot struct {
+	cow      *cowState
+	epoch    *cowEpoch
+	data     []byte
+	length   int
+	released int32 // atomic
+}
+
+// cowState is the copy-on-write state shared by a bit-array and its snapshots.
+type cowState struct {
+	live   int64 // atomic, number of snapshots not released yet
+	mu     sync.Mutex
+	latest *cowEpoch
+	dirty  bool // whether the bit-array has been written to since the latest epoch began, only used by the writer
+}
+
+// cowEpoch holds the chunks saved between two successive snapshots of different contents.
+// The content of a chunk at the time of an epoch is its first saved copy in this epoch or a later one,
+// or the data of the bit-array itself if it has not been written to since.
+type cowEpoch struct {
+	nbBytes int
+	saved   map[int][]byte // guarded by cowState.mu for writes
+	next    *cowEpoch      // guarded by cowState.mu
+}
+
+// Snapshot returns a read-only view of the bit-array in its current state.
+func (ba *BitArray) Snapshot() *Snapshot {
+	nbBytes := (ba.Len() + 7) >> 3
+	if ba.cow != nil && atomic.LoadInt64(&ba.cow.live) == 0 {
+		// the previous snapshots are all released, start afresh
+		ba.cow = nil
+	}
+	if ba.cow == nil {
+		ba.cow = &cowState{latest: &cowEpoch{nbBytes: nbBytes, saved: map[int][]byte{}}}
+	} else if ba.cow.dirty || ba.cow.latest.nbBytes != nbBytes {
+		e := &cowEpoch{nbBytes: nbBytes, saved: map[int][]byte{}}
+		ba.cow.mu.Lock()
+		ba.cow.latest.next = e
+		ba.cow.latest = e
+		ba.cow.mu.Unlock()
+	}
+	ba.cow.dirty = false
+	atomic.AddInt64(&ba.cow.live, 1)
+	s := &Snapshot{cow: ba.cow, epoch: ba.cow.latest, data: ba.data[:nbBytes], length: ba.Len()}
+	runtime.SetFinalizer(s, (*Snapshot).Release)
+	return s
+}
+
+// Release marks the snapshot as no longer used, it must not be read afterwards.
+// Releasing a snapshot is optional, but it stops the bit-array from saving chunks for it
+// without waiting for the garbage collector. Releasing a snapshot twice has no effect.
+func (s *Snapshot) Release() {
+	if atomic.CompareAndSwapInt32(&s.released, 0, 1) {
+		runtime.SetFinalizer(s, nil)
+		atomic.AddInt64(&s.cow.live, -1)
+	}
+}
+
+// beforeWrite must be called before overwriting the bytes [from, to) of the data of the bit-array,
+// it saves the chunks still shared with snapshots. Appending bytes does not require it.
+func (ba *BitArray) beforeWrite(from, to int) {
+	if ba.cow == nil {
+		return
+	}
+	if atomic.LoadInt64(&ba.cow.live) == 0 {
+		// no snapshot can read the saved chunks anymore, and only the writer takes new ones
+		ba.cow = nil
+		return
+	}
+	ba.cow.save(ba.data, from, to)
+}
+
+func (cs *cowState) save(data []byte, from, to int) {
+	cs.dirty = true
+	e := cs.latest
+	if to > e.nbBytes {
+		to = e.nbBytes
+	}
+	for c := from / snapshotChunkBytes; c*snapshotChunkBytes < to; c++ {
+		if _, ok := e.saved[c]; ok {
+			continue
+		}
+		end := (c + 1) * snapshotChunkBytes
+		if end > e.nbBytes {
+			end = e.nbBytes
+		}
+		chunk := make([]byte, end-c*snapshotChunkBytes)
+		copy(chunk, data[c*snapshotChunkBytes:end])
+
+		cs.mu.Lock()
+		e.saved[c] = chunk
+		cs.mu.Unlock()
+	}
+}
+
+// Len returns the length (number of bits) of the snapshot.
+func (s *Snapshot) Len() int {
+	return s.length
+}
+
+// GetBit returns the bit at position `index` and will panic if index is out of range.
+func (s *Snapshot) GetBit(index int) byte {
+	if index < 0 || index >= s.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", index, s.length))
+	}
+	var b [1]byte
+	s.read(b[:], index>>3)
+	return b[0] >> (7 - index&0x7) & 1
+}
+
+// Extract extracts the bits in the range [i, j) into a uint64 with the same semantics as BitArray.Extract.
+func (s *Snapshot) Extract(i, j int) uint64 {
+	if i < 0 || j < 0 {
+		panic(fmt.Sprintf("negative indexes are invalid; given (i=%d, j=%d)", i, j))
+	}
+	if i >= j {
+		panic(fmt.Sprintf("invalid indexes %d >= %d", i, j))
+	}
+	if j > s.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", j, s.length))
+	}
+	if j-i > 64 {
+		panic(fmt.Sprintf("the number of queried bits should not be greater than 64 bits; j - i = %d", j-i))
+	}
+
+	var buf [9]byte
+	from := i >> 3
+	n := (j+7)>>3 - from
+	s.read(buf[:n], from)
+	return (&BitArray{data: buf[:n]}).bitsAt(i&0x7, j-i)
+}
+
+// Count returns the number of bits set to `1` in the snapshot.
+func (s *Snapshot) Count() int {
+	count := 0
+	s.forEachChunk(func(chunk []byte) {
+		for len(chunk) >= 8 {
+			count += bits.OnesCount64(binary.LittleEndian.Uint64(chunk))
+			chunk = chunk[8:]
+		}
+		for _, b := range chunk {
+			count += bits.OnesCount8(b)
+		}
+	})
+	return count
+}
+
+// BitArray returns a new bit-array with the bits of the snapshot.
+func (s *Snapshot) BitArray() *BitArray {
+	ba := NewZeros(s.length)
+	s.read(ba.data[:len(s.data)], 0)
+	return ba
+}
+
+// chunk returns the chunk c as it was when the snapshot was taken, s.cow.mu must be held.
+func (s *Snapshot) chunk(c int) []byte {
+	for e := s.epoch; e != nil; e = e.next {
+		if chunk, ok := e.saved[c]; ok {
+			return chunk
+		}
+	}
+	end := (c + 1) * snapshotChunkBytes
+	if end > len(s.data) {
+		end = len(s.data)
+	}
+	return s.data[c*snapshotChunkBytes : end]
+}
+
+// read copies the bytes of the snapshot starting from byte `from` into dst.
+func (s *Snapshot) read(dst []byte, from int) {
+	s.cow.mu.Lock()
+	defer s.cow.mu.Unlock()
+	for len(dst) > 0 {
+		c, r := from/snapshotChunkBytes, from%snapshotChunkBytes
+		n := copy(dst, s.chunk(c)[r:])
+		dst, from = dst[n:], from+n
+	}
+	// the data shared with the bit-array must not be released while it is being copied
+	runtime.KeepAlive(s)
+}
+
+// forEachChunk calls f with copies of the successive chunks of the snapshot.
+func (s *Snapshot) forEachChunk(f func(chunk []byte)) {
+	buf := make([]byte, snapshotChunkBytes)
+	for from := 0; from < len(s.data); from += snapshotChunkBytes {
+		chunk := buf
+		if len(s.data)-from < len(chunk) {
+			chunk = chunk[:len(s.data)-from]
+		}
+		s.read(chunk, from)
+		f(chunk)
+	}
+}
@@ -0,0 +1,229 @@
+package bitarray
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		id     int
+		bits   string
+		mutate func(ba *BitArray)
+		result string
+	}{
+		{0, "", func(ba *BitArray) { ba.AppendOne() }, "1"},
+		{1, "101", func(ba *BitArray) { ba.AppendString("11") }, "10111"},
+		{2, "10100000", func(ba *BitArray) { ba.AppendOne() }, "101000001"},
+		{3, "1010", func(ba *BitArray) { ba.SetBit(1) }, "1110"},
+		{4, "1010", func(ba *BitArray) { ba.ClearBit(0) }, "0010"},
+		{5, "1010", func(ba *BitArray) { ba.Append64(0xff, 3) }, "1010111"},
+		{6, "1010", func(ba *BitArray) { ba.Xor(NewZeros(4)); ba.Or(NewZeros(4)); ba.AndNot(NewZeros(4)) }, "1010"},
+		{7, "1010", func(ba *BitArray) { ones := New(); ones.AppendString("0110"); ba.And(ones) }, "0010"},
+		{8, "1010", func(ba *BitArray) { ba.AppendBitArray(ba) }, "10101010"},
+		{9, "101011110000", func(ba *BitArray) { ba.setBitsAt(2, 8, 0b00110011) }, "100011001100"},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bits)
+		s := ba.Snapshot()
+		test.mutate(ba)
+
+		want := New()
+		want.AppendString(test.bits)
+		if got := s.BitArray(); !sameBits(got, want) {
+			t.Errorf("%d: snapshot changed to %08b, want %08b", test.id, got.Bytes(), want.Bytes())
+		}
+		if s.Len() != want.Len() || s.Count() != want.Count() {
+			t.Errorf("%d: Len and Count returned bad data (%d, %d), want (%d, %d)", test.id, s.Len(), s.Count(), want.Len(), want.Count())
+		}
+		for i := 0; i < want.Len(); i++ {
+			if s.GetBit(i) != want.GetBit(i) {
+				t.Errorf("%d: GetBit(%d) returned bad data %d, want %d", test.id, i, s.GetBit(i), want.GetBit(i))
+			}
+		}
+
+		result := New()
+		result.AppendString(test.result)
+		if !sameBits(ba, result) {
+			t.Errorf("%d: bit-array is %08b, want %08b", test.id, ba.Bytes(), result.Bytes())
+		}
+	}
+}
+
+func TestSnapshotChunks(t *testing.T) {
+	ba := NewZeros(10 * 8 * snapshotChunkBytes)
+	s1 := ba.Snapshot()
+	if s2 := ba.Snapshot(); s2.epoch != s1.epoch {
+		t.Errorf("snapshots of an unchanged bit-array do not share their epoch")
+	}
+
+	ba.SetBit(3 * 8 * snapshotChunkBytes)
+	ba.SetBit(3*8*snapshotChunkBytes + 1)
+	if len(s1.epoch.saved) != 1 {
+		t.Errorf("%d chunks were saved, want 1", len(s1.epoch.saved))
+	}
+
+	s3 := ba.Snapshot()
+	if s3.epoch == s1.epoch {
+		t.Errorf("snapshots of a modified bit-array share their epoch")
+	}
+	ba.ClearBit(3 * 8 * snapshotChunkBytes)
+	if s1.Count() != 0 || s3.Count() != 2 || ba.Count() != 1 {
+		t.Errorf("Count returned bad data (%d, %d, %d), want (0, 2, 1)", s1.Count(), s3.Count(), ba.Count())
+	}
+}
+
+func TestSnapshotRelease(t *testing.T) {
+	ba := NewZeros(4 * 8 * snapshotChunkBytes)
+	s1, s2 := ba.Snapshot(), ba.Snapshot()
+	s1.Release()
+	s1.Release()
+	ba.SetBit(0)
+	if ba.cow == nil || len(s2.epoch.saved) != 1 || s2.GetBit(0) != 0 {
+		t.Errorf("a write did not save its chunk for a live snapshot")
+	}
+
+	s2.Release()
+	ba.SetBit(8 * snapshotChunkBytes)
+	if ba.cow != nil || len(s2.epoch.saved) != 1 {
+		t.Errorf("a write saved its chunk with no live snapshot")
+	}
+
+	// dropped snapshots are released when garbage collected
+	ba.Snapshot()
+	cow := ba.cow
+	for i := 0; i < 100 && atomic.LoadInt64(&cow.live) != 0; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	ba.SetBit(2 * 8 * snapshotChunkBytes)
+	if ba.cow != nil {
+		t.Errorf("a write saved its chunk after its snapshot was garbage collected")
+	}
+
+	s3 := ba.Snapshot()
+	ba.Append8(0xff, 8)
+	if len(s3.epoch.saved) != 0 {
+		t.Errorf("appending to a bit-array with no padding saved %d chunks, want 0", len(s3.epoch.saved))
+	}
+}
+
+func TestSnapshotRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ba := New()
+	var snapshots []*Snapshot
+	var copies []*BitArray
+
+	for step := 0; step < 2000; step++ {
+		switch op := r.Intn(10); {
+		case op == 0:
+			snapshots = append(snapshots, ba.Snapshot())
+			copies = append(copies, ba.Clone())
+		case op < 4 || ba.Len() == 0:
+			ba.Append64(r.Uint64(), r.Intn(65))
+		case op < 7:
+			ba.SetBit(r.Intn(ba.Len()))
+		case op < 9:
+			ba.ClearBit(r.Intn(ba.Len()))
+		default:
+			ba.Xor(randomBitArray(r, ba.Len()))
+		}
+	}
+
+	for id, s := range snapshots {
+		if got := s.BitArray(); !sameBits(got, copies[id]) {
+			t.Fatalf("%d: snapshot changed", id)
+		}
+		if s.Count() != copies[id].Count() {
+			t.Errorf("%d: Count returned bad data %d, want %d", id, s.Count(), copies[id].Count())
+		}
+		for q := 0; q < 20 && s.Len() > 0; q++ {
+			i := r.Intn(s.Len())
+			j := i + 1 + r.Intn(s.Len()-i)
+			if j-i > 64 {
+				j = i + 64
+			}
+			if got, want := s.Extract(i, j), copies[id].Extract(i, j); got != want {
+				t.Errorf("%d: Extract(%d, %d) returned bad data %x, want %x", id, i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	ba := NewZeros(4 * 8 * snapshotChunkBytes)
+	var wg sync.WaitGroup
+	for round := 0; round < 10; round++ {
+		s, want := ba.Snapshot(), ba.Clone()
+		for reader := 0; reader < 4; reader++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 5; k++ {
+					if !sameBits(s.BitArray(), want) || s.Count() != want.Count() {
+						t.Errorf("snapshot changed while being read")
+						return
+					}
+				}
+			}()
+		}
+
+		for k := 0; k < 500; k++ {
+			ba.SetBit((k * 7919) % ba.Len())
+			ba.Append8(byte(k), 5)
+		}
+	}
+	wg.Wait()
+}
+
+// Appending whole bytes to a bit-array without padding must not write the bytes read by its snapshots.
+func TestSnapshotConcurrentAppendBytes(t *testing.T) {
+	ba := NewZeros(8 * snapshotChunkBytes)
+	var wg sync.WaitGroup
+	for round := 0; round < 10; round++ {
+		s, want := ba.Snapshot(), ba.Clone()
+		for reader := 0; reader < 4; reader++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 200; k++ {
+					if i := s.Len() - 1 - k%8; s.GetBit(i) != want.GetBit(i) {
+						t.Errorf("snapshot changed while being read")
+						return
+					}
+				}
+			}()
+		}
+
+		for k := 0; k < 500; k++ {
+			ba.Append8(byte(k), 8)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSnapshotPanics(t *testing.T) {
+	s := NewZeros(10).Snapshot()
+	for id, f := range []func(){
+		func() { s.GetBit(10) },
+		func() { s.GetBit(-1) },
+		func() { s.Extract(5, 5) },
+		func() { s.Extract(0, 11) },
+		func() { s.Extract(-1, 3) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
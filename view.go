@@ -0,0 +1,118 @@
+package bitarray
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// View is a read-only window over the bits [i, j) of a bit-array, it does not copy them.
+// A view refers to the bit-array itself, not to a copy of its bytes: the bits it returns are always the
+// current bits of the bit-array in its range, modifications made after the view was created
+// (SetBit, ClearBit, And, ...) are visible through it, and bits appended to the bit-array are not part of it.
+// Use BitArray, or a Snapshot of the bit-array, to keep the bits of a range as they are.
+// If the bit-array is replaced by a shorter one (ReadFrom, UnmarshalBinary), reading the view may panic.
+//
+// A view is a small value, creating it does not allocate.
+type View struct {
+	ba     *BitArray
+	offset int
+	length int
+}
+
+// View returns a view of the bits in the range [i, j) of the bit-array.
+// Indexes must not be negative, j must be greater or equal to i and j must not be out of range, otherwise View will panic.
+func (ba *BitArray) View(i, j int) View {
+	checkViewRange(i, j, ba.Len())
+	return View{ba: ba, offset: i, length: j - i}
+}
+
+func checkViewRange(i, j, length int) {
+	if i < 0 || j < 0 {
+		panic(fmt.Sprintf("negative indexes are invalid; given (i=%d, j=%d)", i, j))
+	}
+	if i > j {
+		panic(fmt.Sprintf("invalid indexes %d > %d", i, j))
+	}
+	if j > length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", j, length))
+	}
+}
+
+// View returns a view of the bits in the range [i, j) of the view with the same conditions as BitArray.View.
+func (v View) View(i, j int) View {
+	checkViewRange(i, j, v.length)
+	return View{ba: v.ba, offset: v.offset + i, length: j - i}
+}
+
+// Len returns the length (number of bits) of the view.
+func (v View) Len() int {
+	return v.length
+}
+
+// GetBit returns the bit at position `index` of the view and will panic if index is out of range.
+func (v View) GetBit(index int) byte {
+	if index < 0 || index >= v.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", index, v.length))
+	}
+	return v.ba.GetBit(v.offset + index)
+}
+
+// Extract extracts the bits in the range [i, j) of the view into a uint64 with the same semantics as BitArray.Extract.
+func (v View) Extract(i, j int) uint64 {
+	if i < 0 || j < 0 {
+		panic(fmt.Sprintf("negative indexes are invalid; given (i=%d, j=%d)", i, j))
+	}
+	if i >= j {
+		panic(fmt.Sprintf("invalid indexes %d >= %d", i, j))
+	}
+	if j > v.length {
+		panic(fmt.Sprintf("bit index out of range [%d] with length %d", j, v.length))
+	}
+	return v.ba.Extract(v.offset+i, v.offset+j)
+}
+
+// Count returns the number of bits set to `1` in the view.
+func (v View) Count() int {
+	count := 0
+	pos, end := v.offset, v.offset+v.length
+	for ; pos+64 <= end; pos += 64 {
+		count += bits.OnesCount64(v.ba.bitsAt(pos, 64))
+	}
+	return count + bits.OnesCount64(v.ba.bitsAt(pos, end-pos))
+}
+
+// NextOne returns the position in the view of the first bit set to `1` at position `index` or after it,
+// or -1 if there is no such bit. It will panic if index is negative.
+// Iterating over all the set bits is done with:
+//
+//	for i := v.NextOne(0); i >= 0; i = v.NextOne(i + 1) { ... }
+func (v View) NextOne(index int) int {
+	if index < 0 {
+		panic(fmt.Sprintf("negative index is invalid; given %d", index))
+	}
+
+	end := v.offset + v.length
+	for pos := v.offset + index; pos < end; pos += 64 {
+		n := 64
+		if end-pos < n {
+			n = end - pos
+		}
+		if w := v.ba.bitsAt(pos, n) << uint(64-n); w != 0 {
+			return pos - v.offset + bits.LeadingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// BitArray returns a new bit-array with the bits of the view.
+func (v View) BitArray() *BitArray {
+	res := NewZeros(v.length)
+	for k := 0; k<<6 < v.length; k++ {
+		n := v.length - k<<6
+		if n > 64 {
+			n = 64
+		}
+		store64(res.data, k<<3, v.ba.bitsAt(v.offset+k<<6, n)<<uint(64-n))
+	}
+	return res
+}
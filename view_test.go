@@ -0,0 +1,148 @@
+package bitarray
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	tests := []struct {
+		id    int
+		bits  string
+		i     int
+		j     int
+		view  string
+		count int
+		ones  []int
+	}{
+		{0, "", 0, 0, "", 0, nil},
+		{1, "1011", 1, 1, "", 0, nil},
+		{2, "1011", 0, 4, "1011", 3, []int{0, 2, 3}},
+		{3, "1011", 1, 3, "01", 1, []int{1}},
+		{4, "0000000000010000000000000000000000000000000000000000000000000000000000000100001", 5, 79, "00000010000000000000000000000000000000000000000000000000000000000000100001", 3, []int{6, 68, 73}},
+	}
+
+	for _, test := range tests {
+		ba := New()
+		ba.AppendString(test.bits)
+		v := ba.View(test.i, test.j)
+		want := New()
+		want.AppendString(test.view)
+
+		if v.Len() != want.Len() {
+			t.Errorf("%d: Len returned bad data %d, want %d", test.id, v.Len(), want.Len())
+		}
+		if got := v.BitArray(); !sameBits(got, want) {
+			t.Errorf("%d: BitArray returned bad data %08b, want %08b", test.id, got.Bytes(), want.Bytes())
+		}
+		if v.Count() != test.count {
+			t.Errorf("%d: Count returned bad data %d, want %d", test.id, v.Count(), test.count)
+		}
+		var ones []int
+		for i := v.NextOne(0); i >= 0; i = v.NextOne(i + 1) {
+			ones = append(ones, i)
+		}
+		if len(ones) != len(test.ones) {
+			t.Errorf("%d: NextOne returned bad data %v, want %v", test.id, ones, test.ones)
+			continue
+		}
+		for k := range ones {
+			if ones[k] != test.ones[k] {
+				t.Errorf("%d: NextOne returned bad data %v, want %v", test.id, ones, test.ones)
+			}
+		}
+	}
+}
+
+func TestViewRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ba := randomBitArray(r, 3000)
+	for id := 0; id < 200; id++ {
+		i := r.Intn(ba.Len())
+		j := i + r.Intn(ba.Len()-i+1)
+		v := ba.View(i, j)
+		want := ba.ExtractBitArray(i, j)
+
+		if got := v.BitArray(); !sameBits(got, want) {
+			t.Fatalf("%d: BitArray of View(%d, %d) returned bad data", id, i, j)
+		}
+		if v.Count() != want.Count() {
+			t.Errorf("%d: Count returned bad data %d, want %d", id, v.Count(), want.Count())
+		}
+		if v.Len() == 0 {
+			continue
+		}
+
+		k := r.Intn(v.Len())
+		if v.GetBit(k) != want.GetBit(k) {
+			t.Errorf("%d: GetBit(%d) returned bad data %d, want %d", id, k, v.GetBit(k), want.GetBit(k))
+		}
+		if got, want := v.NextOne(k), want.NextOne(k); got != want {
+			t.Errorf("%d: NextOne(%d) returned bad data %d, want %d", id, k, got, want)
+		}
+		l := k + 1 + r.Intn(v.Len()-k)
+		if l-k > 64 {
+			l = k + 64
+		}
+		if got, want := v.Extract(k, l), want.Extract(k, l); got != want {
+			t.Errorf("%d: Extract(%d, %d) returned bad data %x, want %x", id, k, l, got, want)
+		}
+
+		sub := v.View(k, l)
+		if got := sub.BitArray(); !sameBits(got, want.ExtractBitArray(k, l)) {
+			t.Errorf("%d: BitArray of a sub-view returned bad data", id)
+		}
+	}
+}
+
+func TestViewFollowsParent(t *testing.T) {
+	ba := New()
+	ba.AppendString("00000000")
+	v := ba.View(2, 6)
+
+	ba.SetBit(3)
+	ba.SetBit(7)
+	// the view follows the bit-array when its storage is reallocated
+	for k := 0; k < 1000; k++ {
+		ba.AppendOne()
+	}
+	ba.SetBit(4)
+
+	if got := v.Extract(0, 4); got != 0b0110 {
+		t.Errorf("Extract returned bad data %04b, want 0110", got)
+	}
+	if v.Len() != 4 || v.Count() != 2 {
+		t.Errorf("Len and Count returned bad data (%d, %d), want (4, 2)", v.Len(), v.Count())
+	}
+
+	owned := v.BitArray()
+	ba.ClearBit(3)
+	if owned.GetBit(1) != 1 || v.GetBit(1) != 0 {
+		t.Errorf("BitArray does not own its bits")
+	}
+}
+
+func TestViewPanics(t *testing.T) {
+	ba := NewZeros(10)
+	v := ba.View(2, 8)
+	for id, f := range []func(){
+		func() { ba.View(-1, 3) },
+		func() { ba.View(4, 3) },
+		func() { ba.View(0, 11) },
+		func() { v.View(0, 7) },
+		func() { v.GetBit(6) },
+		func() { v.GetBit(-1) },
+		func() { v.Extract(0, 7) },
+		func() { v.Extract(3, 3) },
+		func() { v.NextOne(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", id)
+				}
+			}()
+			f()
+		}()
+	}
+}
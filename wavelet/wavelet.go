@@ -0,0 +1,214 @@
+// Package wavelet implements the wavelet matrix, a compact representation of sequences of symbols
+// answering access, rank, select, quantile and range frequency queries in time proportional to
+// the number of bits of the largest symbol.
+//
+// Level l stores, for every position of the sequence as rearranged by the previous levels,
+// the l-th most significant bit of the symbol. Positions are then stably partitioned
+// with all the 0's first for the next level.
+package wavelet
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// Matrix is an immutable wavelet matrix over a sequence of uint32 symbols.
+type Matrix struct {
+	n      int
+	levels []*bitarray.RankSelect
+	zeros  []int // number of 0's of each level
+}
+
+// New builds the wavelet matrix of seq. The number of levels is the number of bits of the largest symbol,
+// an alphabet of 2^16 symbols gives 16 levels.
+func New(seq []uint32) *Matrix {
+	var max uint32
+	for _, c := range seq {
+		if c > max {
+			max = c
+		}
+	}
+	depth := bits.Len32(max)
+	if depth == 0 {
+		depth = 1
+	}
+
+	m := &Matrix{
+		n:      len(seq),
+		levels: make([]*bitarray.RankSelect, depth),
+		zeros:  make([]int, depth),
+	}
+
+	current := make([]uint32, len(seq))
+	copy(current, seq)
+	next := make([]uint32, len(seq))
+	for l := 0; l < depth; l++ {
+		shift := uint(depth - 1 - l)
+		level := bitarray.NewZeros(len(seq))
+		zeros := 0
+		for i, c := range current {
+			if c>>shift&1 == 1 {
+				level.SetBit(i)
+			} else {
+				zeros++
+			}
+		}
+
+		z, o := 0, zeros
+		for _, c := range current {
+			if c>>shift&1 == 1 {
+				next[o] = c
+				o++
+			} else {
+				next[z] = c
+				z++
+			}
+		}
+		current, next = next, current
+
+		m.levels[l] = bitarray.NewRankSelect(level)
+		m.zeros[l] = zeros
+	}
+	return m
+}
+
+// Len returns the length of the sequence.
+func (m *Matrix) Len() int {
+	return m.n
+}
+
+// Depth returns the number of levels of the matrix, that is the number of bits per symbol.
+func (m *Matrix) Depth() int {
+	return len(m.levels)
+}
+
+func (m *Matrix) bit(c uint32, l int) uint32 {
+	return c >> uint(len(m.levels)-1-l) & 1
+}
+
+// down maps position i of level l to its position in level l+1, following bit b.
+func (m *Matrix) down(l, i int, b uint32) int {
+	if b == 0 {
+		return m.levels[l].Rank0(i)
+	}
+	return m.zeros[l] + m.levels[l].Rank1(i)
+}
+
+func (m *Matrix) checkRange(i, j int) {
+	if i < 0 || j > m.n || i > j {
+		panic(fmt.Sprintf("invalid range [%d, %d) with length %d", i, j, m.n))
+	}
+}
+
+// Access returns the i-th symbol of the sequence and will panic if i is out of range.
+func (m *Matrix) Access(i int) uint32 {
+	if i < 0 || i >= m.n {
+		panic(fmt.Sprintf("index out of range [%d] with length %d", i, m.n))
+	}
+
+	var c uint32
+	for l, rs := range m.levels {
+		b := uint32(1)
+		if rs.Rank1(i+1) == rs.Rank1(i) {
+			b = 0
+		}
+		c = c<<1 | b
+		i = m.down(l, i, b)
+	}
+	return c
+}
+
+// Rank returns the number of occurrences of symbol c in the range [0, i).
+// It will panic if i is negative or greater than the length of the sequence.
+func (m *Matrix) Rank(c uint32, i int) int {
+	m.checkRange(0, i)
+	if bits.Len32(c) > len(m.levels) {
+		return 0
+	}
+
+	s, e := 0, i
+	for l := range m.levels {
+		b := m.bit(c, l)
+		s, e = m.down(l, s, b), m.down(l, e, b)
+	}
+	return e - s
+}
+
+// Select returns the position of the k-th occurrence of symbol c, k starting from 0,
+// or -1 if c occurs k times or less.
+func (m *Matrix) Select(c uint32, k int) int {
+	if k < 0 || bits.Len32(c) > len(m.levels) {
+		return -1
+	}
+
+	s, e := 0, m.n
+	for l := range m.levels {
+		b := m.bit(c, l)
+		s, e = m.down(l, s, b), m.down(l, e, b)
+	}
+	if k >= e-s {
+		return -1
+	}
+
+	pos := s + k
+	for l := len(m.levels) - 1; l >= 0; l-- {
+		if m.bit(c, l) == 0 {
+			pos = m.levels[l].Select0(pos)
+		} else {
+			pos = m.levels[l].Select1(pos - m.zeros[l])
+		}
+	}
+	return pos
+}
+
+// Quantile returns the k-th smallest symbol, k starting from 0, in the range [i, j).
+// Quantile(i, j, (j-i)/2) returns the median of the range.
+// It will panic if the range is invalid or if k is not smaller than j - i.
+func (m *Matrix) Quantile(i, j, k int) uint32 {
+	m.checkRange(i, j)
+	if k < 0 || k >= j-i {
+		panic(fmt.Sprintf("quantile index out of range [%d] with range length %d", k, j-i))
+	}
+
+	var c uint32
+	for l, rs := range m.levels {
+		zeros := rs.Rank0(j) - rs.Rank0(i)
+		b := uint32(0)
+		if k >= zeros {
+			b = 1
+			k -= zeros
+		}
+		c = c<<1 | b
+		i, j = m.down(l, i, b), m.down(l, j, b)
+	}
+	return c
+}
+
+// RangeFreq returns the number of symbols c in the range [i, j) such that lo <= c < hi.
+// It will panic if the range [i, j) is invalid.
+func (m *Matrix) RangeFreq(i, j int, lo, hi uint32) int {
+	m.checkRange(i, j)
+	if lo >= hi {
+		return 0
+	}
+	return m.countLess(i, j, hi) - m.countLess(i, j, lo)
+}
+
+// countLess returns the number of symbols lower than x in the range [i, j).
+func (m *Matrix) countLess(i, j int, x uint32) int {
+	if bits.Len32(x) > len(m.levels) {
+		return j - i
+	}
+
+	count := 0
+	for l, rs := range m.levels {
+		b := m.bit(x, l)
+		if b == 1 {
+			count += rs.Rank0(j) - rs.Rank0(i)
+		}
+		i, j = m.down(l, i, b), m.down(l, j, b)
+	}
+	return count
+}
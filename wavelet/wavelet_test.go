@@ -0,0 +1,107 @@
+package wavelet
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomSequence(r *rand.Rand, n int, sigma uint32) []uint32 {
+	seq := make([]uint32, n)
+	for i := range seq {
+		seq[i] = uint32(r.Int63n(int64(sigma)))
+	}
+	return seq
+}
+
+func TestWaveletMatrix(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		id  int
+		seq []uint32
+	}{
+		{0, []uint32{}},
+		{1, []uint32{0, 0, 0}},
+		{2, []uint32{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5}},
+		{3, randomSequence(r, 1000, 4)},
+		{4, randomSequence(r, 3000, 1<<16)},
+		{5, randomSequence(r, 2000, 300)},
+	}
+
+	for _, test := range tests {
+		m := New(test.seq)
+		if m.Len() != len(test.seq) {
+			t.Errorf("%d: Len returned %d, want %d", test.id, m.Len(), len(test.seq))
+		}
+
+		occurrences := map[uint32][]int{}
+		for i, c := range test.seq {
+			if m.Access(i) != c {
+				t.Fatalf("%d: Access(%d) returned %d, want %d", test.id, i, m.Access(i), c)
+			}
+			if m.Rank(c, i) != len(occurrences[c]) {
+				t.Fatalf("%d: Rank(%d, %d) returned %d, want %d", test.id, c, i, m.Rank(c, i), len(occurrences[c]))
+			}
+			occurrences[c] = append(occurrences[c], i)
+		}
+
+		for c, positions := range occurrences {
+			for k, i := range positions {
+				if m.Select(c, k) != i {
+					t.Fatalf("%d: Select(%d, %d) returned %d, want %d", test.id, c, k, m.Select(c, k), i)
+				}
+			}
+			if m.Select(c, len(positions)) != -1 {
+				t.Errorf("%d: Select(%d, %d) returned %d, want -1", test.id, c, len(positions), m.Select(c, len(positions)))
+			}
+		}
+		if m.Select(1<<20, 0) != -1 || m.Rank(1<<20, len(test.seq)) != 0 {
+			t.Errorf("%d: queries on an absent symbol did not return nothing", test.id)
+		}
+
+		for q := 0; q < 50 && len(test.seq) > 0; q++ {
+			i := r.Intn(len(test.seq))
+			j := i + 1 + r.Intn(len(test.seq)-i)
+			sorted := append([]uint32{}, test.seq[i:j]...)
+			sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+
+			k := r.Intn(j - i)
+			if m.Quantile(i, j, k) != sorted[k] {
+				t.Fatalf("%d: Quantile(%d, %d, %d) returned %d, want %d", test.id, i, j, k, m.Quantile(i, j, k), sorted[k])
+			}
+
+			lo, hi := sorted[r.Intn(len(sorted))], sorted[r.Intn(len(sorted))]+1
+			want := 0
+			for _, c := range sorted {
+				if lo <= c && c < hi {
+					want++
+				}
+			}
+			if m.RangeFreq(i, j, lo, hi) != want {
+				t.Fatalf("%d: RangeFreq(%d, %d, %d, %d) returned %d, want %d", test.id, i, j, lo, hi, m.RangeFreq(i, j, lo, hi), want)
+			}
+			if m.RangeFreq(i, j, 0, 1<<31) != j-i {
+				t.Fatalf("%d: RangeFreq over the whole alphabet returned %d, want %d", test.id, m.RangeFreq(i, j, 0, 1<<31), j-i)
+			}
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		id    int
+		seq   []uint32
+		depth int
+	}{
+		{0, []uint32{}, 1},
+		{1, []uint32{1, 0}, 1},
+		{2, []uint32{255}, 8},
+		{3, []uint32{1, 1 << 15}, 16},
+	}
+
+	for _, test := range tests {
+		if d := New(test.seq).Depth(); d != test.depth {
+			t.Errorf("%d: Depth returned %d, want %d", test.id, d, test.depth)
+		}
+	}
+}
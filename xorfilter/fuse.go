@@ -0,0 +1,91 @@
+package xorfilter
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// maxSegmentLength is the maximum number of fingerprints of a segment of a binary fuse filter.
+const maxSegmentLength = 1 << 18
+
+// BinaryFuse is a 3-wise binary fuse filter: the array of fingerprints is split in segments
+// whose length is a power of two, the three positions of a key lying in three consecutive segments.
+// It uses about 1.13 fingerprints per key for large sets and is faster to build than a xor filter.
+type BinaryFuse struct {
+	seed          uint64
+	segmentLength int
+	segmentCount  int
+	fingerprints  *bitarray.PackedInts
+}
+
+// NewBinaryFuse builds a binary fuse filter with fingerprints of fingerprintBits bits from keys, duplicates are ignored.
+// It will panic if fingerprintBits is neither 8 nor 16.
+func NewBinaryFuse(keys []uint64, fingerprintBits int) (*BinaryFuse, error) {
+	checkFingerprintBits(fingerprintBits)
+	keys = unique(keys)
+
+	f := &BinaryFuse{}
+	f.segmentLength, f.segmentCount = fuseParameters(len(keys))
+
+	fingerprints, seed, err := build(keys, f.arrayLength(), fingerprintBits, f.positions)
+	if err != nil {
+		return nil, err
+	}
+	f.seed, f.fingerprints = seed, fingerprints
+	return f, nil
+}
+
+// fuseParameters returns the length and the number of segments of the filter of n keys
+// with the sizing rules of the reference implementation.
+func fuseParameters(n int) (segmentLength, segmentCount int) {
+	segmentLength = maxSegmentLength
+	if l := 1 << uint(math.Floor(math.Log(math.Max(float64(n), 1))/math.Log(3.33)+2.25)); l < segmentLength {
+		segmentLength = l
+	}
+
+	capacity := 0
+	if n > 1 {
+		sizeFactor := math.Max(1.125, 0.875+0.25*math.Log(1e6)/math.Log(float64(n)))
+		capacity = int(math.Round(float64(n) * sizeFactor))
+	}
+
+	segmentCount = (capacity + segmentLength - 1) / segmentLength
+	if segmentCount <= 2 {
+		segmentCount = 1
+	} else {
+		segmentCount -= 2
+	}
+	return segmentLength, segmentCount
+}
+
+// arrayLength returns the number of fingerprints, the keys spanning two segments more than segmentCount.
+func (f *BinaryFuse) arrayLength() int {
+	return (f.segmentCount + 2) * f.segmentLength
+}
+
+// positions returns the positions of a hash in three consecutive segments.
+func (f *BinaryFuse) positions(hash uint64) [3]int {
+	hi, _ := bits.Mul64(hash, uint64(f.segmentCount*f.segmentLength))
+	mask := uint64(f.segmentLength - 1)
+	h0 := int(hi)
+	h1 := (h0 + f.segmentLength) ^ int(hash>>18&mask)
+	h2 := (h0 + 2*f.segmentLength) ^ int(hash&mask)
+	return [3]int{h0, h1, h2}
+}
+
+// Contains returns whether key may be in the filter. A false result means that key is not in the filter.
+func (f *BinaryFuse) Contains(key uint64) bool {
+	return contains(f.fingerprints, f.seed, key, f.positions)
+}
+
+// FingerprintBits returns the number of bits of the fingerprints, 8 or 16.
+func (f *BinaryFuse) FingerprintBits() int {
+	return f.fingerprints.Width()
+}
+
+// SizeInBits returns the number of bits of the fingerprints array.
+func (f *BinaryFuse) SizeInBits() int {
+	return f.fingerprints.SizeInBits()
+}
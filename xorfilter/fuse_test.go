@@ -0,0 +1,68 @@
+package xorfilter
+
+import "testing"
+
+func TestBinaryFuse(t *testing.T) {
+	tests := []struct {
+		id              int
+		keys            []uint64
+		fingerprintBits int
+	}{
+		{0, []uint64{}, 8},
+		{1, []uint64{42}, 16},
+		{2, []uint64{1, 2, 3, 2, 1}, 8},
+		{3, randomKeys(1, 1000), 16},
+		{4, randomKeys(2, 10000), 8},
+		{5, randomKeys(3, 1000000), 8},
+		{6, randomKeys(4, 100000), 16},
+	}
+
+	for _, test := range tests {
+		f, err := NewBinaryFuse(test.keys, test.fingerprintBits)
+		if err != nil {
+			t.Fatalf("%d: NewBinaryFuse returned error %v", test.id, err)
+		}
+		checkFilter(t, test.id, f, test.keys, test.fingerprintBits)
+	}
+}
+
+func TestBinaryFuseSize(t *testing.T) {
+	keys := randomKeys(5, 1000000)
+	f, err := NewBinaryFuse(keys, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewXor(keys, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perKey := float64(f.SizeInBits()) / float64(len(keys)); perKey > 1.14*8 {
+		t.Errorf("%g bits per key, want about %g", perKey, 1.13*8)
+	}
+	if f.SizeInBits() >= x.SizeInBits() {
+		t.Errorf("binary fuse filter uses %d bits, more than the %d bits of the xor filter", f.SizeInBits(), x.SizeInBits())
+	}
+}
+
+func TestFuseParameters(t *testing.T) {
+	tests := []struct {
+		id            int
+		n             int
+		segmentLength int
+		segmentCount  int
+	}{
+		{0, 0, 4, 1},
+		{1, 1, 4, 1},
+		{2, 1000, 1 << 7, 9},
+		{3, 1000000, 1 << 13, 136},
+	}
+
+	for _, test := range tests {
+		segmentLength, segmentCount := fuseParameters(test.n)
+		if segmentLength != test.segmentLength || segmentCount != test.segmentCount {
+			t.Errorf("%d: fuseParameters returned (%d, %d), want (%d, %d)",
+				test.id, segmentLength, segmentCount, test.segmentLength, test.segmentCount)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package xorfilter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+// The serialized form of a filter is made of big-endian integers:
+//	- 4 bytes: the magic, "XORF" for a xor filter and "BFUS" for a binary fuse filter,
+//	- uint8: the number of bits of the fingerprints, 8 or 16,
+//	- uint64: the seed,
+//	- for a xor filter, uint32: the length of the blocks,
+//	- for a binary fuse filter, uint32: the length of the segments and uint32: the number of segments,
+//	- the fingerprints, on 1 or 2 bytes each.
+
+const (
+	xorMagic        = "XORF"
+	binaryFuseMagic = "BFUS"
+	// maxFingerprints bounds the size of the arrays read from untrusted data.
+	maxFingerprints = 1 << 31
+)
+
+// ErrInvalidFormat is returned when reading a malformed serialized filter.
+var ErrInvalidFormat = errors.New("xorfilter: invalid format")
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (f *Xor) SerializedSizeInBytes() int {
+	return 4 + 1 + 8 + 4 + f.fingerprints.Len()*f.FingerprintBits()/8
+}
+
+// WriteTo writes the filter to w.
+func (f *Xor) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, f.SerializedSizeInBytes())
+	buf = appendHeader(buf, xorMagic, f.fingerprints, f.seed)
+	buf = appendUint32(buf, uint32(f.blockLength))
+	buf = appendFingerprints(buf, f.fingerprints)
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *Xor) MarshalBinary() ([]byte, error) {
+	return marshal(f)
+}
+
+// ReadFrom replaces the filter by the one read from r.
+// It reads exactly the bytes of one filter, so filters stored back to back can be read with successive calls.
+func (f *Xor) ReadFrom(r io.Reader) (int64, error) {
+	var header [17]byte
+	n, err := io.ReadFull(r, header[:])
+	read := int64(n)
+	if err != nil {
+		return read, unexpectedEOF(err)
+	}
+
+	fingerprintBits, seed, err := parseHeader(header[:], xorMagic)
+	if err != nil {
+		return read, err
+	}
+	blockLength := int64(binary.BigEndian.Uint32(header[13:]))
+	if blockLength == 0 || 3*blockLength > maxFingerprints {
+		return read, fmt.Errorf("%w: block length %d", ErrInvalidFormat, blockLength)
+	}
+
+	fingerprints, n64, err := readFingerprints(r, int(3*blockLength), fingerprintBits)
+	read += n64
+	if err != nil {
+		return read, err
+	}
+
+	f.seed, f.blockLength, f.fingerprints = seed, int(blockLength), fingerprints
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *Xor) UnmarshalBinary(data []byte) error {
+	return unmarshal(f, data)
+}
+
+// SerializedSizeInBytes returns the number of bytes written by WriteTo.
+func (f *BinaryFuse) SerializedSizeInBytes() int {
+	return 4 + 1 + 8 + 4 + 4 + f.fingerprints.Len()*f.FingerprintBits()/8
+}
+
+// WriteTo writes the filter to w.
+func (f *BinaryFuse) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, f.SerializedSizeInBytes())
+	buf = appendHeader(buf, binaryFuseMagic, f.fingerprints, f.seed)
+	buf = appendUint32(buf, uint32(f.segmentLength))
+	buf = appendUint32(buf, uint32(f.segmentCount))
+	buf = appendFingerprints(buf, f.fingerprints)
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *BinaryFuse) MarshalBinary() ([]byte, error) {
+	return marshal(f)
+}
+
+// ReadFrom replaces the filter by the one read from r.
+// It reads exactly the bytes of one filter, so filters stored back to back can be read with successive calls.
+func (f *BinaryFuse) ReadFrom(r io.Reader) (int64, error) {
+	var header [21]byte
+	n, err := io.ReadFull(r, header[:])
+	read := int64(n)
+	if err != nil {
+		return read, unexpectedEOF(err)
+	}
+
+	fingerprintBits, seed, err := parseHeader(header[:], binaryFuseMagic)
+	if err != nil {
+		return read, err
+	}
+	segmentLength := int64(binary.BigEndian.Uint32(header[13:]))
+	segmentCount := int64(binary.BigEndian.Uint32(header[17:]))
+	if segmentLength == 0 || segmentLength > maxSegmentLength || segmentLength&(segmentLength-1) != 0 ||
+		segmentCount == 0 || (segmentCount+2)*segmentLength > maxFingerprints {
+		return read, fmt.Errorf("%w: %d segments of length %d", ErrInvalidFormat, segmentCount, segmentLength)
+	}
+
+	fingerprints, n64, err := readFingerprints(r, int((segmentCount+2)*segmentLength), fingerprintBits)
+	read += n64
+	if err != nil {
+		return read, err
+	}
+
+	f.seed, f.segmentLength, f.segmentCount, f.fingerprints = seed, int(segmentLength), int(segmentCount), fingerprints
+	return read, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *BinaryFuse) UnmarshalBinary(data []byte) error {
+	return unmarshal(f, data)
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendHeader(buf []byte, magic string, fingerprints *bitarray.PackedInts, seed uint64) []byte {
+	buf = append(buf, magic...)
+	buf = append(buf, byte(fingerprints.Width()))
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seed)
+	return append(buf, b[:]...)
+}
+
+func appendFingerprints(buf []byte, fingerprints *bitarray.PackedInts) []byte {
+	for i := 0; i < fingerprints.Len(); i++ {
+		if fingerprints.Width() == 8 {
+			buf = append(buf, byte(fingerprints.Get(i)))
+		} else {
+			buf = append(buf, byte(fingerprints.Get(i)>>8), byte(fingerprints.Get(i)))
+		}
+	}
+	return buf
+}
+
+// parseHeader checks the magic and returns the number of bits of the fingerprints and the seed.
+func parseHeader(header []byte, magic string) (int, uint64, error) {
+	if string(header[:4]) != magic {
+		return 0, 0, fmt.Errorf("%w: bad magic %q, want %q", ErrInvalidFormat, header[:4], magic)
+	}
+	fingerprintBits := int(header[4])
+	if fingerprintBits != 8 && fingerprintBits != 16 {
+		return 0, 0, fmt.Errorf("%w: %d bits fingerprints", ErrInvalidFormat, fingerprintBits)
+	}
+	return fingerprintBits, binary.BigEndian.Uint64(header[5:]), nil
+}
+
+func readFingerprints(r io.Reader, n, fingerprintBits int) (*bitarray.PackedInts, int64, error) {
+	// n comes from an untrusted header: grow the buffer as bytes arrive instead of allocating it upfront
+	var buf bytes.Buffer
+	read, err := io.CopyN(&buf, r, int64(n)*int64(fingerprintBits/8))
+	if err != nil {
+		return nil, read, unexpectedEOF(err)
+	}
+	data := buf.Bytes()
+
+	fingerprints := bitarray.NewPackedInts(fingerprintBits, n)
+	for i := 0; i < n; i++ {
+		if fingerprintBits == 8 {
+			fingerprints.Set(i, uint64(data[i]))
+		} else {
+			fingerprints.Set(i, uint64(binary.BigEndian.Uint16(data[2*i:])))
+		}
+	}
+	return fingerprints, read, nil
+}
+
+func marshal(f io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshal(f io.ReaderFrom, data []byte) error {
+	n, err := f.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if n != int64(len(data)) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, int64(len(data))-n)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package xorfilter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	keys := randomKeys(6, 5000)
+	x8, _ := NewXor(keys, 8)
+	x16, _ := NewXor(keys, 16)
+	f8, _ := NewBinaryFuse(keys, 8)
+	f16, _ := NewBinaryFuse(keys, 16)
+
+	tests := []struct {
+		id  int
+		f   Filter
+		new func() Filter
+	}{
+		{0, x8, func() Filter { return &Xor{} }},
+		{1, x16, func() Filter { return &Xor{} }},
+		{2, f8, func() Filter { return &BinaryFuse{} }},
+		{3, f16, func() Filter { return &BinaryFuse{} }},
+	}
+
+	type serializable interface {
+		Filter
+		SerializedSizeInBytes() int
+		MarshalBinary() ([]byte, error)
+		UnmarshalBinary([]byte) error
+	}
+
+	for _, test := range tests {
+		f := test.f.(serializable)
+		data, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != f.SerializedSizeInBytes() {
+			t.Errorf("%d: SerializedSizeInBytes returned %d, want %d", test.id, f.SerializedSizeInBytes(), len(data))
+		}
+
+		g := test.new().(serializable)
+		if err := g.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%d: UnmarshalBinary returned error %v", test.id, err)
+		}
+		checkFilter(t, test.id, g, keys, f.FingerprintBits())
+
+		again, _ := g.MarshalBinary()
+		if !bytes.Equal(again, data) {
+			t.Errorf("%d: MarshalBinary of the read filter returned different data", test.id)
+		}
+	}
+}
+
+func TestMarshalBinaryLayout(t *testing.T) {
+	f, _ := NewXor([]uint64{1, 2, 3}, 16)
+	data, _ := f.MarshalBinary()
+
+	if string(data[:4]) != "XORF" || data[4] != 16 {
+		t.Errorf("MarshalBinary returned bad header %x", data[:5])
+	}
+	// 32 + ceil(1.23*3) = 36 fingerprints, 12 per block
+	if len(data) != 4+1+8+4+36*2 || data[13] != 0 || data[14] != 0 || data[15] != 0 || data[16] != 12 {
+		t.Errorf("MarshalBinary returned bad data %x", data)
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	x, _ := NewXor([]uint64{1, 2, 3}, 8)
+	xor, _ := x.MarshalBinary()
+	fuse, _ := NewBinaryFuse([]uint64{1, 2, 3}, 8)
+	fuseData, _ := fuse.MarshalBinary()
+
+	withByte := func(data []byte, i int, b byte) []byte {
+		data = append([]byte{}, data...)
+		data[i] = b
+		return data
+	}
+
+	tests := []struct {
+		id   int
+		f    interface{ UnmarshalBinary([]byte) error }
+		data []byte
+		err  error
+	}{
+		{0, &Xor{}, nil, io.ErrUnexpectedEOF},
+		{1, &Xor{}, xor[:10], io.ErrUnexpectedEOF},
+		{2, &Xor{}, xor[:len(xor)-1], io.ErrUnexpectedEOF},
+		{3, &Xor{}, fuseData, ErrInvalidFormat},
+		{4, &Xor{}, withByte(xor, 4, 12), ErrInvalidFormat},
+		{5, &Xor{}, append(append([]byte{}, xor...), 0), ErrInvalidFormat},
+		{6, &Xor{}, withByte(withByte(xor, 15, 0), 16, 0), ErrInvalidFormat},
+		{7, &BinaryFuse{}, xor, ErrInvalidFormat},
+		{8, &BinaryFuse{}, fuseData[:len(fuseData)-1], io.ErrUnexpectedEOF},
+		{9, &BinaryFuse{}, withByte(fuseData, 16, 3), ErrInvalidFormat},
+		{10, &BinaryFuse{}, withByte(fuseData, 20, 0), ErrInvalidFormat},
+		{11, &BinaryFuse{}, withByte(fuseData, 14, 0x10), ErrInvalidFormat},
+	}
+
+	for _, test := range tests {
+		if err := test.f.UnmarshalBinary(test.data); !errors.Is(err, test.err) {
+			t.Errorf("%d: UnmarshalBinary returned error %v, want %v", test.id, err, test.err)
+		}
+	}
+}
+
+// Headers announcing huge filters must not allocate them before their bytes are read.
+func TestReadFromLargeHeader(t *testing.T) {
+	tests := []struct {
+		id     int
+		f      interface{ UnmarshalBinary([]byte) error }
+		header []byte
+	}{
+		// 3 blocks of 2^29 16-bit fingerprints
+		{0, &Xor{}, []byte{'X', 'O', 'R', 'F', 16, 0, 0, 0, 0, 0, 0, 0, 0, 0x20, 0, 0, 0}},
+		// 8190 + 2 segments of 2^18 16-bit fingerprints
+		{1, &BinaryFuse{}, []byte{'B', 'F', 'U', 'S', 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x04, 0, 0, 0, 0, 0x1F, 0xFE}},
+	}
+
+	for _, test := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if err := test.f.UnmarshalBinary(test.header); err != io.ErrUnexpectedEOF {
+			t.Errorf("%d: UnmarshalBinary returned error %v, want %v", test.id, err, io.ErrUnexpectedEOF)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("%d: UnmarshalBinary allocated %d bytes for a truncated filter", test.id, allocated)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package xorfilter implements xor filters and binary fuse filters, static probabilistic sets
+// smaller and faster to query than Bloom filters (Graf and Lemire, 2020 and 2022).
+//
+// A filter is built once from a set of keys, which are expected to be already hashed to uint64 values.
+// Each key is mapped to three positions of an array of fingerprints such that the XOR of the three
+// fingerprints is the fingerprint of the key. The fingerprints are stored on 8 or 16 bits in a packed bit-array,
+// the false positive rate being about 2^-8 or 2^-16.
+//
+// The construction can fail for a given seed, it is then retried with the next seed of a fixed sequence
+// so that building a filter from the same keys always gives the same filter.
+package xorfilter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/taki-mekhalfa/bitarray"
+)
+
+const (
+	// maxAttempts is the number of seeds tried before giving up the construction of a filter.
+	maxAttempts = 100
+	// initialSeedState is the initial state of the sequence of seeds.
+	initialSeedState = 0x726b2b9d438b9d4d
+)
+
+// ErrConstructionFailed is returned when no seed allowed to build a filter.
+var ErrConstructionFailed = errors.New("xorfilter: construction failed")
+
+// Filter is implemented by the filters of the package.
+type Filter interface {
+	// Contains returns whether key may be in the filter. A false result means that key is not in the filter.
+	Contains(key uint64) bool
+	// FingerprintBits returns the number of bits of the fingerprints, 8 or 16.
+	FingerprintBits() int
+	// SizeInBits returns the number of bits of the fingerprints array.
+	SizeInBits() int
+}
+
+// Xor is a xor filter: the array of fingerprints is split in three blocks, each key having one position in each block.
+// It uses about 1.23 fingerprints per key.
+type Xor struct {
+	seed         uint64
+	blockLength  int
+	fingerprints *bitarray.PackedInts
+}
+
+// NewXor builds a xor filter with fingerprints of fingerprintBits bits from keys, duplicates are ignored.
+// It will panic if fingerprintBits is neither 8 nor 16.
+func NewXor(keys []uint64, fingerprintBits int) (*Xor, error) {
+	checkFingerprintBits(fingerprintBits)
+	keys = unique(keys)
+
+	capacity := 32 + (123*len(keys)+99)/100
+	blockLength := capacity / 3
+	f := &Xor{blockLength: blockLength}
+
+	fingerprints, seed, err := build(keys, 3*blockLength, fingerprintBits, f.positions)
+	if err != nil {
+		return nil, err
+	}
+	f.seed, f.fingerprints = seed, fingerprints
+	return f, nil
+}
+
+// positions returns the position of a hash in each of the three blocks.
+func (f *Xor) positions(hash uint64) [3]int {
+	bl := uint32(f.blockLength)
+	return [3]int{
+		int(reduce(uint32(hash), bl)),
+		int(bl + reduce(uint32(rotl64(hash, 21)), bl)),
+		int(2*bl + reduce(uint32(rotl64(hash, 42)), bl)),
+	}
+}
+
+// Contains returns whether key may be in the filter. A false result means that key is not in the filter.
+func (f *Xor) Contains(key uint64) bool {
+	return contains(f.fingerprints, f.seed, key, f.positions)
+}
+
+// FingerprintBits returns the number of bits of the fingerprints, 8 or 16.
+func (f *Xor) FingerprintBits() int {
+	return f.fingerprints.Width()
+}
+
+// SizeInBits returns the number of bits of the fingerprints array.
+func (f *Xor) SizeInBits() int {
+	return f.fingerprints.SizeInBits()
+}
+
+func checkFingerprintBits(fingerprintBits int) {
+	if fingerprintBits != 8 && fingerprintBits != 16 {
+		panic(fmt.Sprintf("fingerprints should have 8 or 16 bits; given %d", fingerprintBits))
+	}
+}
+
+// unique returns the sorted distinct keys.
+func unique(keys []uint64) []uint64 {
+	sorted := make([]uint64, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := 0
+	for i, k := range sorted {
+		if i == 0 || k != sorted[n-1] {
+			sorted[n] = k
+			n++
+		}
+	}
+	return sorted[:n]
+}
+
+// build computes the fingerprints of an array of size positions for the keys, trying successive seeds.
+func build(keys []uint64, size, fingerprintBits int, positions func(uint64) [3]int) (*bitarray.PackedInts, uint64, error) {
+	state := uint64(initialSeedState)
+	hashes := make([]uint64, len(keys))
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		seed := splitmix64(&state)
+		for i, k := range keys {
+			hashes[i] = mixsplit(k, seed)
+		}
+
+		if stack, slots, ok := peel(hashes, size, positions); ok {
+			return assign(stack, slots, size, fingerprintBits, positions), seed, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: %d keys, %d seeds tried", ErrConstructionFailed, len(keys), maxAttempts)
+}
+
+// peel repeatedly removes a key having a position used by no other remaining key.
+// It returns the removed hashes with their positions in the order they were removed
+// and whether all the keys could be removed.
+func peel(hashes []uint64, size int, positions func(uint64) [3]int) ([]uint64, []int, bool) {
+	counts := make([]uint32, size)
+	xorMasks := make([]uint64, size)
+	for _, h := range hashes {
+		for _, p := range positions(h) {
+			counts[p]++
+			xorMasks[p] ^= h
+		}
+	}
+
+	queue := make([]int, 0, size)
+	for p, c := range counts {
+		if c == 1 {
+			queue = append(queue, p)
+		}
+	}
+
+	stack := make([]uint64, 0, len(hashes))
+	slots := make([]int, 0, len(hashes))
+	for len(queue) > 0 {
+		p := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if counts[p] != 1 {
+			continue
+		}
+
+		// the only remaining hash at p is the XOR of the hashes at p
+		h := xorMasks[p]
+		stack = append(stack, h)
+		slots = append(slots, p)
+		for _, q := range positions(h) {
+			counts[q]--
+			xorMasks[q] ^= h
+			if counts[q] == 1 {
+				queue = append(queue, q)
+			}
+		}
+	}
+	return stack, slots, len(stack) == len(hashes)
+}
+
+// assign sets the fingerprints in the reverse order of peeling so that the position of each key is still free.
+func assign(stack []uint64, slots []int, size, fingerprintBits int, positions func(uint64) [3]int) *bitarray.PackedInts {
+	values := make([]uint64, size)
+	for i := len(stack) - 1; i >= 0; i-- {
+		h := stack[i]
+		pos := positions(h)
+		values[slots[i]] = fingerprint(h, fingerprintBits) ^ values[pos[0]] ^ values[pos[1]] ^ values[pos[2]]
+	}
+	return bitarray.EncodePackedInts(values, fingerprintBits)
+}
+
+func contains(fingerprints *bitarray.PackedInts, seed, key uint64, positions func(uint64) [3]int) bool {
+	h := mixsplit(key, seed)
+	pos := positions(h)
+	f := fingerprints.Get(pos[0]) ^ fingerprints.Get(pos[1]) ^ fingerprints.Get(pos[2])
+	return f == fingerprint(h, fingerprints.Width())
+}
+
+// hashing
+
+func fingerprint(hash uint64, fingerprintBits int) uint64 {
+	return (hash ^ hash>>32) & (1<<uint(fingerprintBits) - 1)
+}
+
+// murmur64 is the 64-bit finalizer of MurmurHash3.
+func murmur64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func mixsplit(key, seed uint64) uint64 {
+	return murmur64(key + seed)
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+func rotl64(x uint64, r uint) uint64 {
+	return x<<r | x>>(64-r)
+}
+
+// reduce maps x to [0, n) without a division (Lemire's fast alternative to the modulo).
+func reduce(x, n uint32) uint32 {
+	return uint32(uint64(x) * uint64(n) >> 32)
+}
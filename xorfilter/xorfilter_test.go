@@ -0,0 +1,135 @@
+package xorfilter
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomKeys(seed int64, n int) []uint64 {
+	r := rand.New(rand.NewSource(seed))
+	keys := make([]uint64, n)
+	for i := range keys {
+		keys[i] = r.Uint64()
+	}
+	return keys
+}
+
+// checkFilter checks that all the keys are found and that the false positive rate is close to 2^-bits.
+func checkFilter(t *testing.T, id int, f Filter, keys []uint64, fingerprintBits int) {
+	t.Helper()
+	for _, k := range keys {
+		if !f.Contains(k) {
+			t.Fatalf("%d: Contains returned false for key %d", id, k)
+		}
+	}
+	if f.FingerprintBits() != fingerprintBits {
+		t.Errorf("%d: FingerprintBits returned %d, want %d", id, f.FingerprintBits(), fingerprintBits)
+	}
+
+	const queries = 1000000
+	falsePositives := 0
+	for _, k := range randomKeys(int64(id)+1000, queries) {
+		if f.Contains(k) {
+			falsePositives++
+		}
+	}
+	want := float64(queries) / float64(uint64(1)<<uint(fingerprintBits))
+	if float64(falsePositives) > 1.5*want+5 {
+		t.Errorf("%d: %d false positives out of %d queries, want about %g", id, falsePositives, queries, want)
+	}
+}
+
+func TestXor(t *testing.T) {
+	tests := []struct {
+		id              int
+		keys            []uint64
+		fingerprintBits int
+	}{
+		{0, []uint64{}, 8},
+		{1, []uint64{42}, 16},
+		{2, []uint64{1, 2, 3, 2, 1}, 8},
+		{3, randomKeys(1, 10000), 8},
+		{4, randomKeys(2, 100000), 16},
+		{5, append(randomKeys(3, 5000), randomKeys(3, 5000)...), 8},
+	}
+
+	for _, test := range tests {
+		f, err := NewXor(test.keys, test.fingerprintBits)
+		if err != nil {
+			t.Fatalf("%d: NewXor returned error %v", test.id, err)
+		}
+		checkFilter(t, test.id, f, test.keys, test.fingerprintBits)
+
+		if perKey := float64(f.SizeInBits()) / float64(len(unique(test.keys))); len(test.keys) >= 10000 && perKey > 1.24*float64(test.fingerprintBits) {
+			t.Errorf("%d: %g bits per key, want about %g", test.id, perKey, 1.23*float64(test.fingerprintBits))
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	keys := randomKeys(4, 20000)
+	shuffled := append([]uint64{}, keys...)
+	rand.New(rand.NewSource(5)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	x1, _ := NewXor(keys, 8)
+	x2, _ := NewXor(shuffled, 8)
+	b1, _ := x1.MarshalBinary()
+	b2, _ := x2.MarshalBinary()
+	if string(b1) != string(b2) {
+		t.Errorf("NewXor returned different filters for the same keys")
+	}
+
+	f1, _ := NewBinaryFuse(keys, 16)
+	f2, _ := NewBinaryFuse(shuffled, 16)
+	b1, _ = f1.MarshalBinary()
+	b2, _ = f2.MarshalBinary()
+	if string(b1) != string(b2) {
+		t.Errorf("NewBinaryFuse returned different filters for the same keys")
+	}
+}
+
+func TestInvalidFingerprintBits(t *testing.T) {
+	tests := []struct {
+		id int
+		f  func()
+	}{
+		{0, func() { NewXor(nil, 4) }},
+		{1, func() { NewXor(nil, 32) }},
+		{2, func() { NewBinaryFuse(nil, 0) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: did not panic", test.id)
+				}
+			}()
+			test.f()
+		}()
+	}
+}
+
+func TestUnique(t *testing.T) {
+	tests := []struct {
+		id   int
+		keys []uint64
+		want []uint64
+	}{
+		{0, []uint64{}, []uint64{}},
+		{1, []uint64{3, 1, 3, 2, 1}, []uint64{1, 2, 3}},
+		{2, []uint64{0, 0, 0}, []uint64{0}},
+	}
+
+	for _, test := range tests {
+		got := unique(test.keys)
+		if len(got) != len(test.want) {
+			t.Fatalf("%d: unique returned bad data %v, want %v", test.id, got, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Fatalf("%d: unique returned bad data %v, want %v", test.id, got, test.want)
+			}
+		}
+	}
+}